@@ -1,14 +1,11 @@
 package main
 
 import (
-	"io/ioutil"
 	"net"
 	"net/http"
 	"runtime"
 	"time"
 
-	"net/url"
-
 	log "github.com/Sirupsen/logrus"
 	"github.com/stefanprodan/syros/models"
 )
@@ -19,14 +16,11 @@ type ClusterCollector struct {
 	HostName    string
 	Environment string
 	Topic       string
+	Probe       ClusterProbe
 }
 
-func NewClusterCollector(name string, address string, env string) (*ClusterCollector, error) {
-	u, err := url.Parse(address)
-	if err != nil {
-		return nil, err
-	}
-	host, _, err := net.SplitHostPort(u.Host)
+func NewClusterCollector(name string, address string, config ClusterProbeConfig, env string) (*ClusterCollector, error) {
+	probe, err := NewClusterProbe(config)
 	if err != nil {
 		return nil, err
 	}
@@ -36,7 +30,8 @@ func NewClusterCollector(name string, address string, env string) (*ClusterColle
 		ApiAddress:  address,
 		Environment: env,
 		Topic:       "cluster",
-		HostName:    host,
+		HostName:    endpointHost(address),
+		Probe:       probe,
 	}
 
 	return c, nil
@@ -44,9 +39,6 @@ func NewClusterCollector(name string, address string, env string) (*ClusterColle
 
 func (col *ClusterCollector) Collect() (*models.ClusterPayload, error) {
 	start := time.Now().UTC()
-	client := &http.Client{
-		Transport: DefaultTransport(),
-	}
 
 	payload := &models.ClusterPayload{
 		Environment: col.Environment,
@@ -56,30 +48,10 @@ func (col *ClusterCollector) Collect() (*models.ClusterPayload, error) {
 			Environment: col.Environment,
 			Id:          models.Hash(col.ApiAddress),
 			Collected:   time.Now().UTC(),
-			Status:      "offline",
 		},
 	}
 
-	resp, err := client.Get(col.ApiAddress)
-	if err != nil {
-		payload.HealthCheck.Output = err.Error()
-		return payload, nil
-	}
-
-	if resp.StatusCode == 200 {
-		payload.HealthCheck.Status = "leader"
-	} else {
-		payload.HealthCheck.Status = "follower"
-	}
-
-	defer resp.Body.Close()
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		payload.HealthCheck.Output = err.Error()
-		return payload, nil
-	}
-
-	payload.HealthCheck.Output = string(body)
+	payload.HealthCheck.Status, payload.HealthCheck.Output = col.Probe.Probe(col.ApiAddress)
 
 	log.Debugf("%v collect duration: %v status %v", col.ApiAddress, time.Now().UTC().Sub(start), payload.HealthCheck.Status)
 	return payload, nil
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	consul "github.com/hashicorp/consul/api"
+)
+
+// Cluster member statuses
+const (
+	clusterLeader   = "leader"
+	clusterFollower = "follower"
+	clusterOffline  = "offline"
+)
+
+// ClusterProbe decides if a cluster member is the leader, a follower or offline
+type ClusterProbe interface {
+	Probe(endpoint string) (string, string)
+}
+
+// NewClusterProbe returns the probe implementation by type, http is the default
+func NewClusterProbe(config ClusterProbeConfig) (ClusterProbe, error) {
+	timeout := 10 * time.Second
+	if len(config.Timeout) > 0 {
+		d, err := time.ParseDuration(config.Timeout)
+		if err != nil {
+			return nil, err
+		}
+		timeout = d
+	}
+
+	switch config.Type {
+	case "http", "":
+		p := &httpProbe{
+			Status:   config.Status,
+			JSONPath: config.JSONPath,
+			Match:    config.Match,
+			client: &http.Client{
+				Transport: DefaultTransport(),
+				Timeout:   timeout,
+			},
+		}
+		if len(config.Regex) > 0 {
+			re, err := regexp.Compile(config.Regex)
+			if err != nil {
+				return nil, err
+			}
+			p.Regex = re
+		}
+		// without body matchers the leader is the member answering 200
+		if p.Status == 0 && p.Regex == nil && len(p.JSONPath) < 1 {
+			p.Status = http.StatusOK
+		}
+		return p, nil
+	case "tcp":
+		return &tcpProbe{Timeout: timeout}, nil
+	case "consul-lock":
+		if len(config.LockKey) < 1 {
+			return nil, fmt.Errorf("Consul lock probe requires a lock key")
+		}
+		cfg := consul.DefaultConfig()
+		if len(config.Consul) > 0 {
+			cfg.Address = config.Consul
+		}
+		cfg.HttpClient = &http.Client{
+			Transport: DefaultTransport(),
+			Timeout:   timeout,
+		}
+		client, err := consul.NewClient(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return &consulLockProbe{LockKey: config.LockKey, client: client}, nil
+	default:
+		return nil, fmt.Errorf("Cluster probe %v not supported", config.Type)
+	}
+}
+
+// httpProbe marks the member as leader if all the configured matchers pass,
+// a non zero Status must equal the response code, the JSONPath value must equal Match
+// or be truthy if Match is empty and the Regex must match the body
+type httpProbe struct {
+	Status   int
+	JSONPath string
+	Match    string
+	Regex    *regexp.Regexp
+	client   *http.Client
+}
+
+func (p *httpProbe) Probe(endpoint string) (string, string) {
+	resp, err := p.client.Get(endpoint)
+	if err != nil {
+		return clusterOffline, err.Error()
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return clusterOffline, err.Error()
+	}
+	output := string(body)
+
+	if p.Status != 0 && resp.StatusCode != p.Status {
+		return clusterFollower, output
+	}
+	if p.Regex != nil && !p.Regex.Match(body) {
+		return clusterFollower, output
+	}
+	if len(p.JSONPath) > 0 {
+		var doc interface{}
+		if err := json.Unmarshal(body, &doc); err != nil {
+			return clusterFollower, fmt.Sprintf("JSON decode error %v %v", err, output)
+		}
+		value, found := jsonPathValue(doc, p.JSONPath)
+		if !found || !jsonMatch(value, p.Match) {
+			return clusterFollower, output
+		}
+	}
+
+	return clusterLeader, output
+}
+
+var (
+	jsonPathSegment = regexp.MustCompile(`^([^\[]*)((?:\[\d+\])*)$`)
+	jsonPathIndex   = regexp.MustCompile(`\d+`)
+)
+
+// jsonPathValue resolves a dot notation path like $.members[0].role
+func jsonPathValue(doc interface{}, path string) (interface{}, bool) {
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	if len(path) < 1 {
+		return doc, true
+	}
+
+	current := doc
+	for _, segment := range strings.Split(path, ".") {
+		parts := jsonPathSegment.FindStringSubmatch(segment)
+		if parts == nil {
+			return nil, false
+		}
+		if len(parts[1]) > 0 {
+			obj, ok := current.(map[string]interface{})
+			if !ok {
+				return nil, false
+			}
+			if current, ok = obj[parts[1]]; !ok {
+				return nil, false
+			}
+		}
+		for _, idx := range jsonPathIndex.FindAllString(parts[2], -1) {
+			arr, ok := current.([]interface{})
+			i, _ := strconv.Atoi(idx)
+			if !ok || i >= len(arr) {
+				return nil, false
+			}
+			current = arr[i]
+		}
+	}
+	return current, true
+}
+
+func jsonMatch(value interface{}, match string) bool {
+	if len(match) > 0 {
+		return fmt.Sprintf("%v", value) == match
+	}
+	switch v := value.(type) {
+	case bool:
+		return v
+	case string:
+		return len(v) > 0 && v != "false"
+	case float64:
+		return v != 0
+	case nil:
+		return false
+	default:
+		return true
+	}
+}
+
+// tcpProbe marks the member as leader if the port accepts connections,
+// a refused connection means the host is up but not leading
+type tcpProbe struct {
+	Timeout time.Duration
+}
+
+func (p *tcpProbe) Probe(endpoint string) (string, string) {
+	address := endpoint
+	if u, err := url.Parse(endpoint); err == nil && len(u.Host) > 0 {
+		address = u.Host
+	}
+
+	conn, err := net.DialTimeout("tcp", address, p.Timeout)
+	if err != nil {
+		if strings.Contains(err.Error(), "refused") {
+			return clusterFollower, err.Error()
+		}
+		return clusterOffline, err.Error()
+	}
+	conn.Close()
+	return clusterLeader, fmt.Sprintf("Connected to %v", address)
+}
+
+// consulLockProbe marks the member as leader if it holds the lock key,
+// the holder is the node of the lock session or the key value written by the lock owner
+type consulLockProbe struct {
+	LockKey string
+	client  *consul.Client
+}
+
+func (p *consulLockProbe) Probe(endpoint string) (string, string) {
+	pair, _, err := p.client.KV().Get(p.LockKey, nil)
+	if err != nil {
+		return clusterOffline, err.Error()
+	}
+	if pair == nil || len(pair.Session) < 1 {
+		return clusterFollower, fmt.Sprintf("Lock %v is not held", p.LockKey)
+	}
+
+	member := endpointHost(endpoint)
+	session, _, err := p.client.Session().Info(pair.Session, nil)
+	if err != nil {
+		return clusterOffline, err.Error()
+	}
+
+	holder := string(pair.Value)
+	if session != nil {
+		holder = session.Node
+	}
+	output := fmt.Sprintf("Lock %v held by %v session %v", p.LockKey, holder, pair.Session)
+	if strings.EqualFold(holder, member) || strings.EqualFold(string(pair.Value), member) || strings.EqualFold(string(pair.Value), endpoint) {
+		return clusterLeader, output
+	}
+	return clusterFollower, output
+}
+
+// endpointHost returns the host of an URL, a host:port address or a plain host name
+func endpointHost(endpoint string) string {
+	if u, err := url.Parse(endpoint); err == nil && len(u.Host) > 0 {
+		endpoint = u.Host
+	}
+	if host, _, err := net.SplitHostPort(endpoint); err == nil {
+		return host
+	}
+	return endpoint
+}
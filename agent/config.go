@@ -18,10 +18,14 @@ type Config struct {
 }
 
 type CollectorConfig struct {
-	Docker  ApiCollectorConfig     `json:"docker" yaml:"docker"`
-	Consul  ApiCollectorConfig     `json:"consul" yaml:"consul"`
-	VSphere ApiCollectorConfig     `json:"vsphere" yaml:"vsphere"`
-	Cluster ClusterCollectorConfig `json:"cluster" yaml:"cluster"`
+	Docker        ApiCollectorConfig      `json:"docker" yaml:"docker"`
+	Consul        ConsulCollectorConfig   `json:"consul" yaml:"consul"`
+	VSphere       VSphereCollectorConfig  `json:"vsphere" yaml:"vsphere"`
+	Jenkins       ApiCollectorConfig      `json:"jenkins" yaml:"jenkins"`
+	Registry      ApiCollectorConfig      `json:"registry" yaml:"registry"`
+	Cluster       ClusterCollectorConfig  `json:"cluster" yaml:"cluster"`
+	Incidents     IncidentCollectorConfig `json:"incidents" yaml:"incidents"`
+	Elasticsearch ElasticCollectorConfig  `json:"elasticsearch" yaml:"elasticsearch"`
 }
 
 type ApiCollectorConfig struct {
@@ -36,9 +40,78 @@ type ClusterCollectorConfig struct {
 	Services []ClusteredServiceConfig `json:"services" yaml:"services"`
 }
 
-type ClusteredServiceConfig struct {
-	Name      string   `json:"name" yaml:"name"`
+// ConsulCollectorConfig filters the catalog services by name,
+// the KV pairs under the watched prefixes are collected with the catalog.
+// The KV values are served as is to every API user, watch only prefixes without secrets.
+// In watch mode the cron is ignored, the changed checks are published as soon as Consul reports them
+// and a full payload is sent every resync interval, the interval must be lower than the indexer DatabaseStale
+type ConsulCollectorConfig struct {
+	Endpoints  []string `json:"endpoints" yaml:"endpoints"`
+	Include    []string `json:"include" yaml:"include"`
+	Exclude    []string `json:"exclude" yaml:"exclude"`
+	KVPrefixes []string `json:"kv_prefixes" yaml:"kv_prefixes"`
+	Cron       string   `json:"cron" yaml:"cron"`
+	Watch      bool     `json:"watch" yaml:"watch"`
+	Resync     string   `json:"resync" yaml:"resync"`
+}
+
+// VSphereCollectorConfig limits the collection to the listed datacenters, all datacenters are collected if empty.
+// Performance enables the collection of the VMs and hosts realtime stats,
+// samples is the number of 20 seconds realtime samples aggregated on each run and should cover the cron interval
+type VSphereCollectorConfig struct {
+	Endpoints   []string `json:"endpoints" yaml:"endpoints"`
+	Include     []string `json:"include" yaml:"include"`
+	Exclude     []string `json:"exclude" yaml:"exclude"`
+	Datacenters []string `json:"datacenters" yaml:"datacenters"`
+	Cron        string   `json:"cron" yaml:"cron"`
+	Performance bool     `json:"performance" yaml:"performance"`
+	Samples     int      `json:"samples" yaml:"samples"`
+}
+
+// IncidentCollectorConfig selects the incident provider,
+// endpoints are the provider API addresses and since is the number of days to collect
+type IncidentCollectorConfig struct {
+	Provider  string   `json:"provider" yaml:"provider"`
 	Endpoints []string `json:"endpoints" yaml:"endpoints"`
+	Token     string   `json:"token" yaml:"token"`
+	Since     int      `json:"since" yaml:"since"`
+	Cron      string   `json:"cron" yaml:"cron"`
+}
+
+// ElasticCollectorConfig describes the log documents, group by is name or id
+// and selects how the container field values are joined to the Docker containers
+type ElasticCollectorConfig struct {
+	Endpoints      []string `json:"endpoints" yaml:"endpoints"`
+	Index          string   `json:"index" yaml:"index"`
+	GroupBy        string   `json:"group_by" yaml:"group_by"`
+	ContainerField string   `json:"container_field" yaml:"container_field"`
+	LevelField     string   `json:"level_field" yaml:"level_field"`
+	TimeField      string   `json:"time_field" yaml:"time_field"`
+	Interval       string   `json:"interval" yaml:"interval"`
+	Range          string   `json:"range" yaml:"range"`
+	Include        []string `json:"include" yaml:"include"`
+	Exclude        []string `json:"exclude" yaml:"exclude"`
+	Cron           string   `json:"cron" yaml:"cron"`
+}
+
+type ClusteredServiceConfig struct {
+	Name      string             `json:"name" yaml:"name"`
+	Endpoints []string           `json:"endpoints" yaml:"endpoints"`
+	Probe     ClusterProbeConfig `json:"probe" yaml:"probe"`
+}
+
+// ClusterProbeConfig selects how leadership is detected, type is http, tcp or consul-lock.
+// The http probe matches the status code, a JSON path value and a regex on the body,
+// the consul-lock probe reads the lock key from the Consul address and the endpoints are the member names
+type ClusterProbeConfig struct {
+	Type     string `json:"type" yaml:"type"`
+	Timeout  string `json:"timeout" yaml:"timeout"`
+	Status   int    `json:"status" yaml:"status"`
+	JSONPath string `json:"jsonpath" yaml:"jsonpath"`
+	Match    string `json:"match" yaml:"match"`
+	Regex    string `json:"regex" yaml:"regex"`
+	Consul   string `json:"consul" yaml:"consul"`
+	LockKey  string `json:"lock_key" yaml:"lock_key"`
 }
 
 func LoadCollectorConfig(path string) (*CollectorConfig, error) {
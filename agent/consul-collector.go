@@ -1,7 +1,9 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"net/url"
 	"strings"
 	"time"
 
@@ -12,13 +14,21 @@ import (
 
 type ConsulCollector struct {
 	ApiAddress  string
+	Include     []string
+	Exclude     []string
+	KVPrefixes  []string
 	Environment string
 	Topic       string
 	Client      *consul.Client
 	StopChan    chan bool
+	LastIndex   uint64
+	Datacenter  string
+	// ctx is canceled on stop to interrupt the watch blocking queries
+	ctx    context.Context
+	cancel context.CancelFunc
 }
 
-func NewConsulCollector(address string, env string) (*ConsulCollector, error) {
+func NewConsulCollector(address string, config ConsulCollectorConfig, env string) (*ConsulCollector, error) {
 	cfg := consul.DefaultConfig()
 	cfg.Address = address
 
@@ -29,15 +39,25 @@ func NewConsulCollector(address string, env string) (*ConsulCollector, error) {
 
 	c := &ConsulCollector{
 		ApiAddress:  address,
+		Include:     config.Include,
+		Exclude:     config.Exclude,
+		KVPrefixes:  config.KVPrefixes,
 		Environment: env,
 		Topic:       "consul",
 		Client:      client,
 		StopChan:    make(chan bool, 1),
 	}
+	c.ctx, c.cancel = context.WithCancel(context.Background())
 
 	return c, nil
 }
 
+// Stop ends the watch and interrupts the blocking query in flight
+func (col *ConsulCollector) Stop() {
+	col.cancel()
+	close(col.StopChan)
+}
+
 func (col *ConsulCollector) Collect() (*models.ConsulPayload, error) {
 	start := time.Now().UTC()
 
@@ -53,21 +73,149 @@ func (col *ConsulCollector) Collect() (*models.ConsulPayload, error) {
 	if len(checks) == 0 {
 		return nil, fmt.Errorf("Consul no health checks found: %v", checks)
 	}
+	col.LastIndex = meta.LastIndex
+	res, nodes := col.mapChecks(checks)
+
+	payload := &models.ConsulPayload{
+		HealthChecks: res,
+		NodeChecks:   nodes,
+		Environment:  col.Environment,
+		Collected:    start,
+	}
+
+	// the health checks are published even if the catalog can't be read
+	if err := col.collectCatalog(payload); err != nil {
+		log.Errorf("Consul collector %v catalog error %v", col.ApiAddress, err)
+		payload.Catalog = false
+		payload.Services = nil
+		payload.KV = nil
+	}
+
+	log.Debugf("%v collect duration: %v health checks %v node checks %v services %v kv %v", col.ApiAddress, time.Now().UTC().Sub(start),
+		len(payload.HealthChecks), len(payload.NodeChecks), len(payload.Services), len(payload.KV))
+	return payload, nil
+}
+
+// mapChecks splits the service and node checks
+func (col *ConsulCollector) mapChecks(checks consul.HealthChecks) ([]models.ConsulHealthCheck, []models.ConsulNodeCheck) {
 	res := make([]models.ConsulHealthCheck, 0)
+	nodes := make([]models.ConsulNodeCheck, 0)
 	for _, ck := range checks {
-		// filter services
 		if len(ck.ServiceID) > 0 {
 			res = append(res, MapConsulCheck(col.Environment, ck))
+		} else {
+			nodes = append(nodes, MapConsulNodeCheck(col.Environment, ck))
 		}
 	}
+	return res, nodes
+}
 
-	payload := &models.ConsulPayload{
-		HealthChecks: res,
-		Environment:  col.Environment,
+// catalogService is decoded from the raw catalog API so the service meta
+// is available regardless of the Consul client version
+type catalogService struct {
+	Node           string
+	Address        string
+	ServiceID      string
+	ServiceName    string
+	ServiceAddress string
+	ServicePort    int
+	ServiceTags    []string
+	ServiceMeta    map[string]string
+}
+
+// collectCatalog adds the service instances and the watched KV pairs to the payload,
+// the documents are tagged with the datacenter so the indexer prunes each endpoint's data separately
+func (col *ConsulCollector) collectCatalog(payload *models.ConsulPayload) error {
+	dc, err := col.datacenter()
+	if err != nil {
+		return err
 	}
+	payload.Datacenter = dc
 
-	log.Debugf("%v collect duration: %v health checks %v", col.ApiAddress, time.Now().UTC().Sub(start), len(payload.HealthChecks))
-	return payload, nil
+	services, _, err := col.Client.Catalog().Services(nil)
+	if err != nil {
+		return err
+	}
+
+	payload.Services = make([]models.ConsulService, 0)
+	for name := range services {
+		if !applyFilter(name, col.Include, col.Exclude) {
+			continue
+		}
+		instances := []catalogService{}
+		if _, err := col.Client.Raw().Query("/v1/catalog/service/"+url.PathEscape(name), &instances, nil); err != nil {
+			return err
+		}
+		for _, i := range instances {
+			payload.Services = append(payload.Services, MapConsulService(col.Environment, dc, i, payload.Collected))
+		}
+	}
+
+	payload.KV = make([]models.ConsulKV, 0)
+	for _, prefix := range col.KVPrefixes {
+		pairs, _, err := col.Client.KV().List(prefix, nil)
+		if err != nil {
+			return err
+		}
+		for _, pair := range pairs {
+			payload.KV = append(payload.KV, models.ConsulKV{
+				Id:          models.Hash(col.Environment + dc + pair.Key),
+				Key:         pair.Key,
+				Value:       string(pair.Value),
+				ModifyIndex: pair.ModifyIndex,
+				Datacenter:  dc,
+				Collected:   payload.Collected,
+				Environment: col.Environment,
+			})
+		}
+	}
+
+	payload.Catalog = true
+	return nil
+}
+
+// datacenter returns the datacenter of the Consul agent, it's read once per collector
+func (col *ConsulCollector) datacenter() (string, error) {
+	if len(col.Datacenter) > 0 {
+		return col.Datacenter, nil
+	}
+
+	self, err := col.Client.Agent().Self()
+	if err != nil {
+		return "", err
+	}
+	dc, _ := self["Config"]["Datacenter"].(string)
+	if len(dc) < 1 {
+		return "", fmt.Errorf("Consul agent %v has no datacenter", col.ApiAddress)
+	}
+	col.Datacenter = dc
+	return dc, nil
+}
+
+func MapConsulService(env string, dc string, i catalogService, collected time.Time) models.ConsulService {
+	svc := models.ConsulService{
+		Id:             models.Hash(env + dc + i.Node + i.ServiceID),
+		Name:           i.ServiceName,
+		ServiceID:      i.ServiceID,
+		Node:           i.Node,
+		Address:        i.Address,
+		ServiceAddress: i.ServiceAddress,
+		Port:           i.ServicePort,
+		Tags:           i.ServiceTags,
+		Meta:           i.ServiceMeta,
+		Datacenter:     dc,
+		Collected:      collected,
+		Environment:    env,
+	}
+	if svc.Tags == nil {
+		svc.Tags = make([]string, 0)
+	}
+	if host, container, _, ok := models.ParseRegistratorServiceID(i.ServiceID); ok {
+		svc.HostName = host
+		svc.ContainerName = container
+	}
+
+	return svc
 }
 
 func MapConsulCheck(env string, ck *consul.HealthCheck) models.ConsulHealthCheck {
@@ -95,3 +243,19 @@ func MapConsulCheck(env string, ck *consul.HealthCheck) models.ConsulHealthCheck
 
 	return check
 }
+
+// MapConsulNodeCheck maps a check without service, the check id is unique only per node
+func MapConsulNodeCheck(env string, ck *consul.HealthCheck) models.ConsulNodeCheck {
+	return models.ConsulNodeCheck{
+		Id:          models.Hash(ck.Node + ck.CheckID),
+		HostId:      models.Hash(ck.Node),
+		Node:        ck.Node,
+		CheckID:     ck.CheckID,
+		Name:        ck.Name,
+		Notes:       ck.Notes,
+		Output:      ck.Output,
+		Status:      ck.Status,
+		Collected:   time.Now().UTC(),
+		Environment: env,
+	}
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	consul "github.com/hashicorp/consul/api"
+	"github.com/nats-io/go-nats"
+	"github.com/stefanprodan/syros/models"
+)
+
+// consulWatch publishes the changed health checks using Consul blocking queries
+// and a full payload including the catalog every resync interval
+type consulWatch struct {
+	collector *ConsulCollector
+	nats      *nats.EncodedConn
+	metrics   *Prometheus
+	config    *Config
+}
+
+func parseResync(resync string) (time.Duration, error) {
+	if len(resync) < 1 {
+		return 2 * time.Minute, nil
+	}
+	d, err := time.ParseDuration(resync)
+	if err != nil {
+		return 0, err
+	}
+	if d < 10*time.Second {
+		return 0, fmt.Errorf("Consul resync interval %v must be at least 10s", resync)
+	}
+	return d, nil
+}
+
+func (w consulWatch) Run(resync time.Duration) {
+	log.Infof("Consul collector %v watch mode resync interval %v", w.collector.ApiAddress, resync)
+	known := make(map[string]string)
+	next := time.Now()
+
+	for {
+		select {
+		case <-w.collector.StopChan:
+			log.Infof("Consul collector %v watch stopped", w.collector.ApiAddress)
+			return
+		default:
+		}
+
+		if !time.Now().Before(next) {
+			t1 := time.Now()
+			payload, err := w.collector.Collect()
+			if err == nil {
+				known = checkStates(payload)
+			}
+			w.publish(payload, err, t1)
+			next = time.Now().Add(resync)
+			if err != nil {
+				if !w.wait(10 * time.Second) {
+					return
+				}
+				continue
+			}
+		}
+
+		t1 := time.Now()
+		payload, err := w.collector.WatchChecks(time.Until(next), known)
+		if err != nil && w.collector.ctx.Err() != nil {
+			log.Infof("Consul collector %v watch stopped", w.collector.ApiAddress)
+			return
+		}
+		if err != nil || len(payload.HealthChecks)+len(payload.NodeChecks) > 0 {
+			w.publish(payload, err, t1)
+		}
+		if err != nil && !w.wait(10*time.Second) {
+			return
+		}
+	}
+}
+
+// wait pauses the watch after an error, returns false if the watch was stopped meanwhile
+func (w consulWatch) wait(d time.Duration) bool {
+	select {
+	case <-w.collector.StopChan:
+		log.Infof("Consul collector %v watch stopped", w.collector.ApiAddress)
+		return false
+	case <-time.After(d):
+		return true
+	}
+}
+
+func (w consulWatch) publish(payload *models.ConsulPayload, err error, t1 time.Time) {
+	status := "200"
+	if err != nil {
+		status = "500"
+		log.Errorf("Consul collector %v error %v", w.collector.ApiAddress, err)
+	} else {
+		err = w.nats.Publish(w.collector.Topic, payload)
+		if err != nil {
+			status = "500"
+			log.Errorf("Consul collector %v Nats natsPublish error %v", w.collector.ApiAddress, err)
+		}
+	}
+
+	t2 := time.Now()
+	w.metrics.requestsTotal.WithLabelValues("consul", w.collector.ApiAddress, status).Inc()
+	w.metrics.requestsLatency.WithLabelValues("consul", w.collector.ApiAddress, status).Observe(t2.Sub(t1).Seconds())
+}
+
+// WatchChecks blocks until the health checks change or the wait time expires,
+// returns only the checks with a new status or output and updates the known states
+func (col *ConsulCollector) WatchChecks(wait time.Duration, known map[string]string) (*models.ConsulPayload, error) {
+	if wait < time.Second {
+		wait = time.Second
+	}
+
+	opts := &consul.QueryOptions{
+		WaitIndex: col.LastIndex,
+		WaitTime:  wait,
+	}
+	checks, meta, err := col.Client.Health().State("any", opts.WithContext(col.ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	payload := &models.ConsulPayload{
+		HealthChecks: make([]models.ConsulHealthCheck, 0),
+		NodeChecks:   make([]models.ConsulNodeCheck, 0),
+		Environment:  col.Environment,
+		Collected:    time.Now().UTC(),
+	}
+
+	// the index can go backwards after a Consul restart, the next query starts over
+	if meta.LastIndex < col.LastIndex {
+		col.LastIndex = 0
+		return payload, nil
+	}
+	if meta.LastIndex == col.LastIndex {
+		return payload, nil
+	}
+	col.LastIndex = meta.LastIndex
+
+	res, nodes := col.mapChecks(checks)
+	current := make(map[string]bool)
+	for _, check := range res {
+		current[check.Id] = true
+		if state := check.Status + check.Output; known[check.Id] != state {
+			known[check.Id] = state
+			payload.HealthChecks = append(payload.HealthChecks, check)
+		}
+	}
+	for _, check := range nodes {
+		current[check.Id] = true
+		if state := check.Status + check.Output; known[check.Id] != state {
+			known[check.Id] = state
+			payload.NodeChecks = append(payload.NodeChecks, check)
+		}
+	}
+
+	// removed checks are left to the indexer garbage collector
+	for id := range known {
+		if !current[id] {
+			delete(known, id)
+		}
+	}
+
+	log.Debugf("%v watch index %v changed health checks %v node checks %v", col.ApiAddress, col.LastIndex, len(payload.HealthChecks), len(payload.NodeChecks))
+	return payload, nil
+}
+
+func checkStates(payload *models.ConsulPayload) map[string]string {
+	known := make(map[string]string)
+	for _, check := range payload.HealthChecks {
+		known[check.Id] = check.Status + check.Output
+	}
+	for _, check := range payload.NodeChecks {
+		known[check.Id] = check.Status + check.Output
+	}
+	return known
+}
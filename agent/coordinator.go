@@ -12,6 +12,7 @@ type Coordinator struct {
 	CollectorConfig *CollectorConfig
 	Cron            *cron.Cron
 	metrics         *Prometheus
+	watchers        []*ConsulCollector
 }
 
 func NewCoordinator(config *Config, collector *CollectorConfig, nc *nats.EncodedConn, cron *cron.Cron) (*Coordinator, error) {
@@ -39,9 +40,17 @@ func (cor *Coordinator) Register() {
 	}
 
 	for _, c := range cor.CollectorConfig.Consul.Endpoints {
-		col, err := NewConsulCollector(c, cor.Config.Environment)
+		col, err := NewConsulCollector(c, cor.CollectorConfig.Consul, cor.Config.Environment)
 		if err != nil {
 			log.Errorf("Collector %v init error", c)
+		} else if cor.CollectorConfig.Consul.Watch {
+			resync, err := parseResync(cor.CollectorConfig.Consul.Resync)
+			if err != nil {
+				log.Errorf("Collector %v init error %v", c, err)
+				continue
+			}
+			cor.watchers = append(cor.watchers, col)
+			go consulWatch{col, cor.NatsConnection, cor.metrics, cor.Config}.Run(resync)
 		} else {
 			cor.Cron.AddJob(cor.CollectorConfig.Consul.Cron,
 				consulJob{col, cor.NatsConnection, cor.metrics, cor.Config})
@@ -50,9 +59,9 @@ func (cor *Coordinator) Register() {
 
 	for _, c := range cor.CollectorConfig.Cluster.Services {
 		for _, t := range c.Endpoints {
-			col, err := NewClusterCollector(c.Name, t, cor.Config.Environment)
+			col, err := NewClusterCollector(c.Name, t, c.Probe, cor.Config.Environment)
 			if err != nil {
-				log.Errorf("Collector %v init error", c)
+				log.Errorf("Collector %v init error %v", c.Name, err)
 			} else {
 				cor.Cron.AddJob(cor.CollectorConfig.Cluster.Cron,
 					clusterJob{col, cor.NatsConnection, cor.metrics, cor.Config})
@@ -61,7 +70,7 @@ func (cor *Coordinator) Register() {
 	}
 
 	for _, c := range cor.CollectorConfig.VSphere.Endpoints {
-		col, err := NewVSphereCollector(c, cor.CollectorConfig.VSphere.Include, cor.CollectorConfig.VSphere.Exclude, cor.Config.Environment)
+		col, err := NewVSphereCollector(c, cor.CollectorConfig.VSphere, cor.Config.Environment)
 		if err != nil {
 			log.Errorf("Collector %v init error", c)
 		} else {
@@ -70,9 +79,57 @@ func (cor *Coordinator) Register() {
 		}
 	}
 
+	for _, c := range cor.CollectorConfig.Jenkins.Endpoints {
+		col, err := NewJenkinsCollector(c, cor.CollectorConfig.Jenkins.Include, cor.CollectorConfig.Jenkins.Exclude, cor.Config.Environment)
+		if err != nil {
+			log.Errorf("Collector %v init error", c)
+		} else {
+			cor.Cron.AddJob(cor.CollectorConfig.Jenkins.Cron,
+				jenkinsJob{col, cor.NatsConnection, cor.metrics, cor.Config})
+		}
+	}
+
+	for _, c := range cor.CollectorConfig.Registry.Endpoints {
+		col, err := NewRegistryCollector(c, cor.CollectorConfig.Registry.Include, cor.CollectorConfig.Registry.Exclude, cor.Config.Environment)
+		if err != nil {
+			log.Errorf("Collector %v init error", c)
+		} else {
+			cor.Cron.AddJob(cor.CollectorConfig.Registry.Cron,
+				registryJob{col, cor.NatsConnection, cor.metrics, cor.Config})
+		}
+	}
+
+	for _, c := range cor.CollectorConfig.Incidents.Endpoints {
+		provider, err := NewIncidentProvider(cor.CollectorConfig.Incidents.Provider, c, cor.CollectorConfig.Incidents.Token)
+		if err != nil {
+			log.Errorf("Collector %v init error %v", c, err)
+			continue
+		}
+		col, err := NewIncidentCollector(provider, cor.CollectorConfig.Incidents.Since)
+		if err != nil {
+			log.Errorf("Collector %v init error", c)
+		} else {
+			cor.Cron.AddJob(cor.CollectorConfig.Incidents.Cron,
+				incidentJob{col, c, cor.NatsConnection, cor.metrics, cor.Config})
+		}
+	}
+
+	for _, c := range cor.CollectorConfig.Elasticsearch.Endpoints {
+		col, err := NewElasticsearchCollector(c, cor.CollectorConfig.Elasticsearch, cor.Config.Environment)
+		if err != nil {
+			log.Errorf("Collector %v init error %v", c, err)
+		} else {
+			cor.Cron.AddJob(cor.CollectorConfig.Elasticsearch.Cron,
+				elasticsearchJob{col, cor.NatsConnection, cor.metrics, cor.Config})
+		}
+	}
+
 	cor.Cron.Start()
 }
 
 func (cor *Coordinator) Deregister() {
 	cor.Cron.Stop()
+	for _, w := range cor.watchers {
+		w.Stop()
+	}
 }
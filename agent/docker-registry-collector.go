@@ -0,0 +1,211 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/stefanprodan/syros/models"
+)
+
+const manifestV2 = "application/vnd.docker.distribution.manifest.v2+json"
+
+var linkNextRegex = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+// RegistryCollector walks the catalog of a Docker Registry v2,
+// credentials can be set in the endpoint URL as user:password@host
+type RegistryCollector struct {
+	ApiAddress  string
+	Registry    string
+	Include     []string
+	Exclude     []string
+	Environment string
+	Topic       string
+	client      *http.Client
+}
+
+func NewRegistryCollector(address string, include []string, exclude []string, env string) (*RegistryCollector, error) {
+	u, err := url.Parse(address)
+	if err != nil {
+		return nil, err
+	}
+	if len(u.Host) < 1 {
+		return nil, fmt.Errorf("Registry address %v has no host", address)
+	}
+
+	c := &RegistryCollector{
+		ApiAddress:  strings.TrimSuffix(address, "/"),
+		Registry:    u.Host,
+		Include:     include,
+		Exclude:     exclude,
+		Environment: env,
+		Topic:       "registry",
+		client: &http.Client{
+			Transport: DefaultTransport(),
+			Timeout:   30 * time.Second,
+		},
+	}
+
+	return c, nil
+}
+
+func (col *RegistryCollector) Collect() (*models.RegistryPayload, error) {
+	start := time.Now().UTC()
+	payload := &models.RegistryPayload{
+		Registry:  col.Registry,
+		Images:    make([]models.RegistryImage, 0),
+		Skipped:   make([]string, 0),
+		Collected: start,
+	}
+
+	repositories, err := col.catalog()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, repo := range repositories {
+		if !applyFilter(repo, col.Include, col.Exclude) {
+			log.Debugf("%v repository excluded by filter", repo)
+			continue
+		}
+
+		// a repository with failed calls is skipped so the indexer keeps its previous images
+		tags, err := col.tags(repo)
+		if err != nil {
+			log.Warnf("Registry %v tags list failed for %v %v", col.Registry, repo, err)
+			payload.Skipped = append(payload.Skipped, repo)
+			continue
+		}
+
+		failed := false
+		for _, tag := range tags {
+			image, err := col.image(repo, tag)
+			if err != nil {
+				log.Warnf("Registry %v manifest failed for %v:%v %v", col.Registry, repo, tag, err)
+				failed = true
+				continue
+			}
+			image.Collected = start
+			payload.Images = append(payload.Images, *image)
+		}
+		if failed {
+			payload.Skipped = append(payload.Skipped, repo)
+		}
+	}
+
+	log.Debugf("%v collect duration: %v images %v", col.Registry, time.Now().UTC().Sub(start), len(payload.Images))
+	return payload, nil
+}
+
+// catalog follows the Link header pagination of /v2/_catalog
+func (col *RegistryCollector) catalog() ([]string, error) {
+	repositories := make([]string, 0)
+	next := "/v2/_catalog?n=100"
+	for len(next) > 0 {
+		data := struct {
+			Repositories []string `json:"repositories"`
+		}{}
+		header, err := col.get(next, "", &data)
+		if err != nil {
+			return nil, err
+		}
+		repositories = append(repositories, data.Repositories...)
+
+		next = ""
+		if m := linkNextRegex.FindStringSubmatch(header.Get("Link")); len(m) > 1 {
+			next = m[1]
+		}
+	}
+
+	return repositories, nil
+}
+
+func (col *RegistryCollector) tags(repo string) ([]string, error) {
+	data := struct {
+		Tags []string `json:"tags"`
+	}{}
+	if _, err := col.get("/v2/"+repo+"/tags/list", "", &data); err != nil {
+		return nil, err
+	}
+	return data.Tags, nil
+}
+
+// image reads the manifest digest and size, the push date is the image config creation date
+func (col *RegistryCollector) image(repo string, tag string) (*models.RegistryImage, error) {
+	manifest := struct {
+		Config struct {
+			Size   int64  `json:"size"`
+			Digest string `json:"digest"`
+		} `json:"config"`
+		Layers []struct {
+			Size int64 `json:"size"`
+		} `json:"layers"`
+	}{}
+	header, err := col.get("/v2/"+repo+"/manifests/"+tag, manifestV2, &manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	image := &models.RegistryImage{
+		Id:          models.Hash(fmt.Sprintf("%v/%v:%v", col.Registry, repo, tag)),
+		Registry:    col.Registry,
+		Repository:  repo,
+		Tag:         tag,
+		Digest:      header.Get("Docker-Content-Digest"),
+		Size:        manifest.Config.Size,
+		Environment: col.Environment,
+	}
+	for _, layer := range manifest.Layers {
+		image.Size += layer.Size
+	}
+
+	if len(manifest.Config.Digest) > 0 {
+		config := struct {
+			Created time.Time `json:"created"`
+		}{}
+		if _, err := col.get("/v2/"+repo+"/blobs/"+manifest.Config.Digest, "", &config); err == nil {
+			image.Pushed = config.Created.UTC()
+		}
+	}
+
+	return image, nil
+}
+
+func (col *RegistryCollector) get(path string, accept string, result interface{}) (http.Header, error) {
+	address := path
+	if !strings.HasPrefix(path, "http") {
+		address = col.ApiAddress + path
+	}
+	req, err := http.NewRequest("GET", address, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(accept) > 0 {
+		req.Header.Set("Accept", accept)
+	}
+
+	resp, err := col.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Registry API %v returned %v", path, resp.StatusCode)
+	}
+
+	if err := json.Unmarshal(body, result); err != nil {
+		return nil, err
+	}
+	return resp.Header, nil
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newRegistryFake serves the registry:2 catalog, tags, manifests and config blobs,
+// the tags list of broken and the manifest of flaky:2 return 500
+func newRegistryFake(t *testing.T) *httptest.Server {
+	tags := map[string][]string{
+		"team/api": {"1", "2"},
+		"web":      {"latest"},
+		"broken":   {"1"},
+		"flaky":    {"1", "2"},
+	}
+	pushed := time.Date(2017, 6, 1, 10, 0, 0, 0, time.UTC)
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := r.URL.Path
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case path == "/v2/_catalog":
+			// two pages linked by the Link header
+			if r.URL.Query().Get("last") == "" {
+				w.Header().Set("Link", `</v2/_catalog?last=web&n=2>; rel="next"`)
+				json.NewEncoder(w).Encode(map[string][]string{"repositories": {"team/api", "web"}})
+				return
+			}
+			json.NewEncoder(w).Encode(map[string][]string{"repositories": {"broken", "flaky"}})
+		case strings.HasSuffix(path, "/tags/list"):
+			repo := strings.TrimSuffix(strings.TrimPrefix(path, "/v2/"), "/tags/list")
+			if repo == "broken" {
+				http.Error(w, "unavailable", http.StatusInternalServerError)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"name": repo, "tags": tags[repo]})
+		case strings.Contains(path, "/manifests/"):
+			parts := strings.SplitN(strings.TrimPrefix(path, "/v2/"), "/manifests/", 2)
+			if parts[0] == "flaky" && parts[1] == "2" {
+				http.Error(w, "unavailable", http.StatusInternalServerError)
+				return
+			}
+			if r.Header.Get("Accept") != manifestV2 {
+				t.Errorf("Manifest request Accept %q, want %v", r.Header.Get("Accept"), manifestV2)
+			}
+			w.Header().Set("Docker-Content-Digest", fmt.Sprintf("sha256:%v-%v", strings.Replace(parts[0], "/", "-", -1), parts[1]))
+			fmt.Fprintf(w, `{"schemaVersion":2,"config":{"size":100,"digest":"sha256:config"},"layers":[{"size":1000},{"size":2000}]}`)
+		case strings.HasSuffix(path, "/blobs/sha256:config"):
+			json.NewEncoder(w).Encode(map[string]time.Time{"created": pushed})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func TestRegistryCollector(t *testing.T) {
+	ts := newRegistryFake(t)
+	defer ts.Close()
+
+	col, err := NewRegistryCollector(ts.URL, []string{}, []string{"web"}, "ci")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payload, err := col.Collect()
+	if err != nil {
+		t.Fatalf("Collect failed %v", err)
+	}
+
+	images := make(map[string]bool)
+	for _, image := range payload.Images {
+		images[image.Repository+":"+image.Tag] = true
+		if image.Repository == "team/api" && image.Tag == "1" {
+			if image.Size != 3100 || image.Digest != "sha256:team-api-1" || image.Pushed.Year() != 2017 {
+				t.Errorf("Image team/api:1 size %v digest %v pushed %v", image.Size, image.Digest, image.Pushed)
+			}
+		}
+		if image.Registry != col.Registry || !image.Collected.Equal(payload.Collected) {
+			t.Errorf("Image %v registry %v collected %v", image.Repository, image.Registry, image.Collected)
+		}
+	}
+
+	want := []string{"team/api:1", "team/api:2", "flaky:1"}
+	if len(payload.Images) != len(want) {
+		t.Errorf("Collected %v images, want %v", images, want)
+	}
+	for _, name := range want {
+		if !images[name] {
+			t.Errorf("Image %v not collected", name)
+		}
+	}
+
+	if strings.Join(payload.Skipped, ",") != "broken,flaky" {
+		t.Errorf("Skipped %v, want broken and flaky", payload.Skipped)
+	}
+}
+
+func TestRegistryCollectorCatalogError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+	}))
+	defer ts.Close()
+
+	col, _ := NewRegistryCollector(ts.URL, []string{}, []string{}, "ci")
+	if _, err := col.Collect(); err == nil {
+		t.Error("Collect should fail when the catalog is not readable")
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/nats-io/go-nats"
+)
+
+type registryJob struct {
+	collector *RegistryCollector
+	nats      *nats.EncodedConn
+	metrics   *Prometheus
+	config    *Config
+}
+
+func (j registryJob) Run() {
+	status := "200"
+	t1 := time.Now()
+
+	payload, err := j.collector.Collect()
+	if err != nil {
+		status = "500"
+		log.Errorf("Registry collector %v error %v", j.collector.Registry, err)
+	} else {
+		err = j.nats.Publish(j.collector.Topic, payload)
+		if err != nil {
+			status = "500"
+			log.Errorf("Registry collector %v Nats natsPublish error %v", j.collector.Registry, err)
+		}
+	}
+
+	t2 := time.Now()
+	j.metrics.requestsTotal.WithLabelValues("registry", j.collector.Registry, status).Inc()
+	j.metrics.requestsLatency.WithLabelValues("registry", j.collector.Registry, status).Observe(t2.Sub(t1).Seconds())
+}
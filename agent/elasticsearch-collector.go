@@ -0,0 +1,270 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/stefanprodan/syros/models"
+)
+
+// esPageSize is the number of composite buckets read per search request
+const esPageSize = 1000
+
+// ElasticsearchCollector counts the container log lines per level and time bucket
+// with a composite aggregation on the container, time bucket and level fields read page by page
+type ElasticsearchCollector struct {
+	ApiAddress  string
+	Index       string
+	GroupBy     string
+	Field       string
+	LevelField  string
+	TimeField   string
+	Interval    string
+	Range       string
+	Include     []string
+	Exclude     []string
+	Environment string
+	Topic       string
+	client      *http.Client
+}
+
+func NewElasticsearchCollector(address string, config ElasticCollectorConfig, env string) (*ElasticsearchCollector, error) {
+	c := &ElasticsearchCollector{
+		ApiAddress:  strings.TrimSuffix(address, "/"),
+		Index:       config.Index,
+		GroupBy:     config.GroupBy,
+		Field:       config.ContainerField,
+		LevelField:  config.LevelField,
+		TimeField:   config.TimeField,
+		Interval:    config.Interval,
+		Range:       config.Range,
+		Include:     config.Include,
+		Exclude:     config.Exclude,
+		Environment: env,
+		Topic:       "logs",
+		client: &http.Client{
+			Transport: DefaultTransport(),
+			Timeout:   60 * time.Second,
+		},
+	}
+
+	if len(c.Index) < 1 {
+		c.Index = "logstash-*"
+	}
+	if len(c.GroupBy) < 1 {
+		c.GroupBy = "name"
+	}
+	if c.GroupBy != "name" && c.GroupBy != "id" {
+		return nil, fmt.Errorf("Elasticsearch group by %v not supported, allowed name or id", c.GroupBy)
+	}
+	if len(c.Field) < 1 {
+		c.Field = "docker.container.name"
+		if c.GroupBy == "id" {
+			c.Field = "docker.container.id"
+		}
+	}
+	if len(c.LevelField) < 1 {
+		c.LevelField = "level"
+	}
+	if len(c.TimeField) < 1 {
+		c.TimeField = "@timestamp"
+	}
+	if len(c.Interval) < 1 {
+		c.Interval = "1h"
+	}
+	if len(c.Range) < 1 {
+		c.Range = "24h"
+	}
+
+	return c, nil
+}
+
+// Query returns the aggregation request body of a page,
+// after is the after_key of the previous page or nil for the first one
+func (col *ElasticsearchCollector) Query(after map[string]interface{}) map[string]interface{} {
+	composite := map[string]interface{}{
+		"size": esPageSize,
+		"sources": []interface{}{
+			map[string]interface{}{
+				"container": map[string]interface{}{
+					"terms": map[string]interface{}{"field": col.Field},
+				},
+			},
+			map[string]interface{}{
+				"bucket": map[string]interface{}{
+					"date_histogram": map[string]interface{}{
+						"field":          col.TimeField,
+						"fixed_interval": col.Interval,
+					},
+				},
+			},
+			map[string]interface{}{
+				"level": map[string]interface{}{
+					"terms": map[string]interface{}{"field": col.LevelField, "missing_bucket": true},
+				},
+			},
+		},
+	}
+	if after != nil {
+		composite["after"] = after
+	}
+
+	return map[string]interface{}{
+		"size": 0,
+		"query": map[string]interface{}{
+			"range": map[string]interface{}{
+				col.TimeField: map[string]interface{}{
+					"gte": "now-" + col.Range,
+				},
+			},
+		},
+		"aggs": map[string]interface{}{
+			"stats": map[string]interface{}{
+				"composite": composite,
+			},
+		},
+	}
+}
+
+type esCompositePage struct {
+	Aggregations struct {
+		Stats struct {
+			AfterKey map[string]interface{} `json:"after_key"`
+			Buckets  []struct {
+				Key struct {
+					Container interface{} `json:"container"`
+					Bucket    interface{} `json:"bucket"`
+					Level     interface{} `json:"level"`
+				} `json:"key"`
+				DocCount int64 `json:"doc_count"`
+			} `json:"buckets"`
+		} `json:"stats"`
+	} `json:"aggregations"`
+}
+
+func (col *ElasticsearchCollector) Collect() (*models.LogPayload, error) {
+	start := time.Now().UTC()
+	payload := &models.LogPayload{
+		Endpoint:    col.ApiAddress,
+		Environment: col.Environment,
+		Stats:       make([]models.LogStat, 0),
+		Collected:   start,
+	}
+
+	// each composite bucket holds one level, the levels of a container and time bucket are merged in one stat
+	index := make(map[string]int)
+	var after map[string]interface{}
+	for {
+		page, err := col.search(col.Query(after))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, b := range page.Aggregations.Stats.Buckets {
+			container := strings.TrimPrefix(fmt.Sprintf("%v", b.Key.Container), "/")
+			if !applyFilter(container, col.Include, col.Exclude) {
+				continue
+			}
+			ms, ok := b.Key.Bucket.(float64)
+			if !ok {
+				continue
+			}
+			bucket := time.Unix(0, int64(ms)*int64(time.Millisecond)).UTC()
+
+			id := models.Hash(col.Environment + col.Field + container + strconv.FormatInt(bucket.Unix(), 10))
+			idx, found := index[id]
+			if !found {
+				payload.Stats = append(payload.Stats, models.LogStat{
+					Id:          id,
+					Environment: col.Environment,
+					GroupBy:     col.GroupBy,
+					Field:       col.Field,
+					LevelField:  col.LevelField,
+					Container:   container,
+					Bucket:      bucket,
+					Collected:   start,
+				})
+				idx = len(payload.Stats) - 1
+				index[id] = idx
+			}
+
+			stat := &payload.Stats[idx]
+			level := ""
+			if b.Key.Level != nil {
+				level = fmt.Sprintf("%v", b.Key.Level)
+			}
+			switch logLevel(level) {
+			case "info":
+				stat.Info += b.DocCount
+			case "warn":
+				stat.Warn += b.DocCount
+			case "error":
+				stat.Error += b.DocCount
+			default:
+				stat.Other += b.DocCount
+			}
+		}
+
+		after = page.Aggregations.Stats.AfterKey
+		if len(page.Aggregations.Stats.Buckets) < esPageSize || after == nil {
+			break
+		}
+	}
+
+	log.Debugf("Elasticsearch %v collect duration: %v stats %v", col.ApiAddress, time.Now().UTC().Sub(start), len(payload.Stats))
+	return payload, nil
+}
+
+func (col *ElasticsearchCollector) search(query map[string]interface{}) (*esCompositePage, error) {
+	body, err := json.Marshal(query)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", col.ApiAddress+"/"+col.Index+"/_search", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := col.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Elasticsearch search returned %v %v", resp.StatusCode, string(data))
+	}
+
+	page := &esCompositePage{}
+	if err := json.Unmarshal(data, page); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// logLevel maps the level names used by the common logging libraries to info, warn or error
+func logLevel(level string) string {
+	switch strings.ToUpper(level) {
+	case "INFO", "INFORMATION", "NOTICE":
+		return "info"
+	case "WARN", "WARNING":
+		return "warn"
+	case "ERROR", "ERR", "FATAL", "CRITICAL", "CRIT", "PANIC", "EMERG", "ALERT":
+		return "error"
+	default:
+		return "other"
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newElasticsearchFake answers the composite aggregation with a full first page
+// of 500 containers with info and error lines and a second page read after the first after_key
+func newElasticsearchFake(t *testing.T, requests *int) *httptest.Server {
+	bucket := time.Date(2017, 6, 1, 10, 0, 0, 0, time.UTC).UnixNano() / int64(time.Millisecond)
+	key := func(container string, level interface{}) map[string]interface{} {
+		return map[string]interface{}{"container": container, "bucket": bucket, "level": level}
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/logs-*/_search" {
+			http.NotFound(w, r)
+			return
+		}
+		*requests++
+
+		query := struct {
+			Aggs struct {
+				Stats struct {
+					Composite struct {
+						Size    int                                     `json:"size"`
+						After   map[string]interface{}                  `json:"after"`
+						Sources []map[string]map[string]json.RawMessage `json:"sources"`
+					} `json:"composite"`
+				} `json:"stats"`
+			} `json:"aggs"`
+		}{}
+		if err := json.NewDecoder(r.Body).Decode(&query); err != nil {
+			t.Fatal(err)
+		}
+		composite := query.Aggs.Stats.Composite
+		if len(composite.Sources) != 3 {
+			t.Fatalf("Composite sources %v, want container, bucket and level", len(composite.Sources))
+		}
+		histogram := map[string]interface{}{}
+		json.Unmarshal(composite.Sources[1]["bucket"]["date_histogram"], &histogram)
+		if histogram["fixed_interval"] != "1h" || histogram["interval"] != nil {
+			t.Errorf("Date histogram %v, want fixed_interval 1h", histogram)
+		}
+
+		buckets := make([]map[string]interface{}, 0)
+		var after map[string]interface{}
+		if composite.After == nil {
+			for i := 0; len(buckets) < composite.Size; i++ {
+				buckets = append(buckets,
+					map[string]interface{}{"key": key(fmt.Sprintf("/c%03d", i), "ERROR"), "doc_count": 2},
+					map[string]interface{}{"key": key(fmt.Sprintf("/c%03d", i), "info"), "doc_count": 10})
+			}
+			after = buckets[len(buckets)-1]["key"].(map[string]interface{})
+		} else {
+			if composite.After["container"] != "/c499" {
+				t.Errorf("Second page after %v, want the last key of the first page", composite.After)
+			}
+			buckets = append(buckets,
+				map[string]interface{}{"key": key("/c499", "warning"), "doc_count": 3},
+				map[string]interface{}{"key": key("/web", nil), "doc_count": 4})
+			after = buckets[len(buckets)-1]["key"].(map[string]interface{})
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"aggregations": map[string]interface{}{
+				"stats": map[string]interface{}{"after_key": after, "buckets": buckets},
+			},
+		})
+	}))
+}
+
+func TestElasticsearchCollector(t *testing.T) {
+	requests := 0
+	ts := newElasticsearchFake(t, &requests)
+	defer ts.Close()
+
+	col, err := NewElasticsearchCollector(ts.URL, ElasticCollectorConfig{Index: "logs-*", Exclude: []string{"c001"}}, "dev")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payload, err := col.Collect()
+	if err != nil {
+		t.Fatalf("Collect failed %v", err)
+	}
+
+	if requests != 2 {
+		t.Errorf("Search requests %v, want 2 pages", requests)
+	}
+	if len(payload.Stats) != 500 {
+		t.Fatalf("Stats %v, want 499 containers plus web", len(payload.Stats))
+	}
+
+	stats := make(map[string]int)
+	for i, s := range payload.Stats {
+		stats[s.Container] = i
+		if s.Bucket.Hour() != 10 || s.Environment != "dev" {
+			t.Errorf("Stat %v bucket %v environment %v", s.Container, s.Bucket, s.Environment)
+		}
+	}
+	if _, found := stats["c001"]; found {
+		t.Error("Container c001 should be excluded by filter")
+	}
+
+	c := payload.Stats[stats["c499"]]
+	if c.Info != 10 || c.Error != 2 || c.Warn != 3 {
+		t.Errorf("Container c499 info %v error %v warn %v, want the levels of both pages merged", c.Info, c.Error, c.Warn)
+	}
+	if web := payload.Stats[stats["web"]]; web.Other != 4 {
+		t.Errorf("Container web other %v, want the lines without level", web.Other)
+	}
+}
+
+func TestElasticsearchCollectorError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":{"type":"too_many_buckets_exception"}}`, http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	col, _ := NewElasticsearchCollector(ts.URL, ElasticCollectorConfig{}, "dev")
+	if _, err := col.Collect(); err == nil {
+		t.Error("Collect should fail on search errors")
+	}
+}
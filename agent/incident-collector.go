@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/stefanprodan/syros/models"
+)
+
+// IncidentProvider reads incidents and on-call shifts from an incident management service
+type IncidentProvider interface {
+	Name() string
+	Incidents(since time.Time, until time.Time) ([]models.Incident, error)
+	OnCalls() ([]models.OnCall, error)
+}
+
+// NewIncidentProvider returns the provider implementation by name
+func NewIncidentProvider(provider string, address string, token string) (IncidentProvider, error) {
+	switch provider {
+	case "pagerduty", "":
+		return NewPagerDutyProvider(address, token), nil
+	default:
+		return nil, fmt.Errorf("Incident provider %v not supported", provider)
+	}
+}
+
+type IncidentCollector struct {
+	Provider IncidentProvider
+	Since    time.Duration
+	Topic    string
+}
+
+func NewIncidentCollector(provider IncidentProvider, sinceDays int) (*IncidentCollector, error) {
+	if sinceDays < 1 {
+		sinceDays = 30
+	}
+
+	c := &IncidentCollector{
+		Provider: provider,
+		Since:    time.Duration(sinceDays) * 24 * time.Hour,
+		Topic:    "incidents",
+	}
+
+	return c, nil
+}
+
+func (col *IncidentCollector) Collect() (*models.IncidentPayload, error) {
+	start := time.Now().UTC()
+	payload := &models.IncidentPayload{
+		Provider:  col.Provider.Name(),
+		Collected: start,
+	}
+
+	incidents, err := col.Provider.Incidents(start.Add(-col.Since), start)
+	if err != nil {
+		return nil, err
+	}
+	for i := range incidents {
+		incidents[i].Collected = start
+	}
+	payload.Incidents = incidents
+
+	oncalls, err := col.Provider.OnCalls()
+	if err != nil {
+		return nil, err
+	}
+	for i := range oncalls {
+		oncalls[i].Collected = start
+	}
+	payload.OnCalls = oncalls
+
+	log.Debugf("%v collect duration: %v incidents %v on-call %v", col.Provider.Name(),
+		time.Now().UTC().Sub(start), len(payload.Incidents), len(payload.OnCalls))
+	return payload, nil
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/nats-io/go-nats"
+)
+
+type incidentJob struct {
+	collector *IncidentCollector
+	address   string
+	nats      *nats.EncodedConn
+	metrics   *Prometheus
+	config    *Config
+}
+
+func (j incidentJob) Run() {
+	status := "200"
+	t1 := time.Now()
+	provider := j.collector.Provider.Name()
+
+	payload, err := j.collector.Collect()
+	if err != nil {
+		status = "500"
+		log.Errorf("Incident collector %v %v error %v", provider, j.address, err)
+	} else {
+		err = j.nats.Publish(j.collector.Topic, payload)
+		if err != nil {
+			status = "500"
+			log.Errorf("Incident collector %v %v Nats natsPublish error %v", provider, j.address, err)
+		}
+	}
+
+	t2 := time.Now()
+	j.metrics.requestsTotal.WithLabelValues(provider, j.address, status).Inc()
+	j.metrics.requestsLatency.WithLabelValues(provider, j.address, status).Observe(t2.Sub(t1).Seconds())
+}
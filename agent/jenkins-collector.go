@@ -0,0 +1,200 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/stefanprodan/syros/models"
+)
+
+// jenkinsMaxBuilds is the number of builds collected per job
+const jenkinsMaxBuilds = 25
+
+// jenkinsTagParams are the build parameters holding the produced image tag
+var jenkinsTagParams = []string{"TAG", "IMAGE_TAG", "VERSION", "tag", "version"}
+
+type JenkinsCollector struct {
+	ApiAddress  string
+	Include     []string
+	Exclude     []string
+	Environment string
+	Topic       string
+}
+
+func NewJenkinsCollector(address string, include []string, exclude []string, env string) (*JenkinsCollector, error) {
+	if _, err := url.Parse(address); err != nil {
+		return nil, err
+	}
+
+	c := &JenkinsCollector{
+		ApiAddress:  strings.TrimSuffix(address, "/"),
+		Include:     include,
+		Exclude:     exclude,
+		Environment: env,
+		Topic:       "jenkins",
+	}
+
+	return c, nil
+}
+
+// jenkinsFolderDepth is the number of nested folder levels read below the top level jobs
+const jenkinsFolderDepth = 3
+
+type jenkinsJobs struct {
+	Jobs []jenkinsJobTree `json:"jobs"`
+}
+
+// jenkinsJobTree is a job or a folder, folders have no builds and hold the nested jobs
+type jenkinsJobTree struct {
+	Name   string           `json:"name"`
+	Url    string           `json:"url"`
+	Jobs   []jenkinsJobTree `json:"jobs"`
+	Builds []struct {
+		Number    int    `json:"number"`
+		Url       string `json:"url"`
+		Result    string `json:"result"`
+		Building  bool   `json:"building"`
+		Duration  int64  `json:"duration"`
+		Timestamp int64  `json:"timestamp"`
+		Artifacts []struct {
+			FileName     string `json:"fileName"`
+			RelativePath string `json:"relativePath"`
+		} `json:"artifacts"`
+		Actions []struct {
+			Parameters []struct {
+				Name  string      `json:"name"`
+				Value interface{} `json:"value"`
+			} `json:"parameters"`
+			LastBuiltRevision struct {
+				SHA1 string `json:"SHA1"`
+			} `json:"lastBuiltRevision"`
+		} `json:"actions"`
+	} `json:"builds"`
+}
+
+// jenkinsTree returns the tree query of the jobs and their builds down to the folder depth
+func jenkinsTree(depth int) string {
+	fields := fmt.Sprintf("name,url,builds[number,url,result,building,duration,timestamp,"+
+		"artifacts[fileName,relativePath],actions[parameters[name,value],lastBuiltRevision[SHA1]]]{0,%v}", jenkinsMaxBuilds)
+	if depth > 0 {
+		fields += "," + jenkinsTree(depth-1)
+	}
+	return "jobs[" + fields + "]"
+}
+
+// flattenJobs walks the folders and names the nested jobs by their folder path
+func flattenJobs(jobs []jenkinsJobTree, prefix string) []jenkinsJobTree {
+	result := make([]jenkinsJobTree, 0)
+	for _, job := range jobs {
+		job.Name = prefix + job.Name
+		if len(job.Jobs) > 0 {
+			result = append(result, flattenJobs(job.Jobs, job.Name+"/")...)
+		}
+		if len(job.Builds) > 0 {
+			result = append(result, job)
+		}
+	}
+	return result
+}
+
+// Collect reads the jobs, including the ones in folders, and their last builds with a single tree query
+func (col *JenkinsCollector) Collect() (*models.JenkinsPayload, error) {
+	start := time.Now().UTC()
+	client := &http.Client{
+		Transport: DefaultTransport(),
+		Timeout:   60 * time.Second,
+	}
+
+	tree := jenkinsTree(jenkinsFolderDepth)
+	resp, err := client.Get(col.ApiAddress + "/api/json?tree=" + url.QueryEscape(tree))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Jenkins API returned %v %v", resp.StatusCode, string(body))
+	}
+
+	data := jenkinsJobs{}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return nil, err
+	}
+
+	payload := &models.JenkinsPayload{
+		Environment: col.Environment,
+		Builds:      make([]models.JenkinsBuild, 0),
+	}
+
+	for _, job := range flattenJobs(data.Jobs, "") {
+		if !applyFilter(job.Name, col.Include, col.Exclude) {
+			log.Debugf("%v job excluded by filter", job.Name)
+			continue
+		}
+
+		for _, b := range job.Builds {
+			build := models.JenkinsBuild{
+				Id:          models.Hash(fmt.Sprintf("%v/%v", job.Url, b.Number)),
+				Job:         job.Name,
+				Number:      b.Number,
+				Result:      strings.ToLower(b.Result),
+				Building:    b.Building,
+				Duration:    b.Duration,
+				Timestamp:   time.Unix(0, b.Timestamp*int64(time.Millisecond)).UTC(),
+				Url:         b.Url,
+				Tag:         strconv.Itoa(b.Number),
+				Artifacts:   make([]string, 0),
+				Environment: col.Environment,
+				Collected:   time.Now().UTC(),
+			}
+			if build.Building {
+				build.Result = "building"
+			}
+
+			for _, a := range b.Artifacts {
+				build.Artifacts = append(build.Artifacts, a.RelativePath)
+			}
+
+			// the image tag is taken from the build parameters, the build number is the fallback
+			tagFound := false
+			for _, action := range b.Actions {
+				if len(action.LastBuiltRevision.SHA1) > 0 {
+					build.Commit = action.LastBuiltRevision.SHA1
+				}
+				for _, p := range action.Parameters {
+					if !tagFound && contains(jenkinsTagParams, p.Name) {
+						if v := fmt.Sprint(p.Value); len(v) > 0 && p.Value != nil {
+							build.Tag = v
+							tagFound = true
+						}
+					}
+				}
+			}
+
+			payload.Builds = append(payload.Builds, build)
+		}
+	}
+
+	log.Debugf("%v collect duration: %v builds %v", col.ApiAddress, time.Now().UTC().Sub(start), len(payload.Builds))
+	return payload, nil
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
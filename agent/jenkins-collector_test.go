@@ -0,0 +1,116 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const jenkinsFoldersJSON = `{
+  "jobs": [
+    {
+      "name": "api",
+      "url": "http://jenkins/job/api/",
+      "builds": [
+        {
+          "number": 12, "url": "http://jenkins/job/api/12/", "result": "SUCCESS", "duration": 1000, "timestamp": 1500000000000,
+          "artifacts": [{"fileName": "api.tar", "relativePath": "dist/api.tar"}],
+          "actions": [{"parameters": [{"name": "TAG", "value": "1.2.0"}]}, {"lastBuiltRevision": {"SHA1": "abc"}}]
+        }
+      ]
+    },
+    {
+      "name": "team",
+      "url": "http://jenkins/job/team/",
+      "jobs": [
+        {
+          "name": "web",
+          "url": "http://jenkins/job/team/job/web/",
+          "jobs": [
+            {
+              "name": "master",
+              "url": "http://jenkins/job/team/job/web/job/master/",
+              "builds": [
+                {"number": 3, "url": "http://jenkins/job/team/job/web/job/master/3/", "building": true, "timestamp": 1500000000000}
+              ]
+            }
+          ]
+        },
+        {
+          "name": "legacy",
+          "url": "http://jenkins/job/team/job/legacy/",
+          "builds": [
+            {"number": 7, "url": "http://jenkins/job/team/job/legacy/7/", "result": "FAILURE", "timestamp": 1500000000000}
+          ]
+        }
+      ]
+    }
+  ]
+}`
+
+func TestJenkinsCollectorFolders(t *testing.T) {
+	var tree string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/json" {
+			http.NotFound(w, r)
+			return
+		}
+		tree = r.URL.Query().Get("tree")
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(jenkinsFoldersJSON))
+	}))
+	defer ts.Close()
+
+	col, err := NewJenkinsCollector(ts.URL+"/", []string{}, []string{"team/legacy"}, "ci")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payload, err := col.Collect()
+	if err != nil {
+		t.Fatalf("Collect failed %v", err)
+	}
+
+	if n := strings.Count(tree, "jobs["); n != jenkinsFolderDepth+1 {
+		t.Errorf("Tree query has %v jobs levels, want %v: %v", n, jenkinsFolderDepth+1, tree)
+	}
+
+	jobs := make(map[string]string)
+	for _, b := range payload.Builds {
+		jobs[b.Job] = b.Result
+	}
+	if len(payload.Builds) != 2 {
+		t.Fatalf("Collected %v builds, want 2: %v", len(payload.Builds), jobs)
+	}
+	if jobs["api"] != "success" {
+		t.Errorf("Job api result %q, want success", jobs["api"])
+	}
+	if jobs["team/web/master"] != "building" {
+		t.Errorf("Job team/web/master result %q, want building", jobs["team/web/master"])
+	}
+	if _, found := jobs["team/legacy"]; found {
+		t.Error("Job team/legacy should be excluded by filter")
+	}
+
+	for _, b := range payload.Builds {
+		if b.Job == "api" && (b.Tag != "1.2.0" || b.Commit != "abc" || len(b.Artifacts) != 1) {
+			t.Errorf("Build api tag %v commit %v artifacts %v", b.Tag, b.Commit, b.Artifacts)
+		}
+		if b.Environment != "ci" {
+			t.Errorf("Build %v environment %v, want ci", b.Job, b.Environment)
+		}
+	}
+}
+
+func TestJenkinsCollectorError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+	}))
+	defer ts.Close()
+
+	col, _ := NewJenkinsCollector(ts.URL, []string{}, []string{}, "ci")
+	if _, err := col.Collect(); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("Collect error %v, want 403", err)
+	}
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stefanprodan/syros/models"
+)
+
+// PagerDutyProvider reads incidents and on-call shifts from the PagerDuty REST API v2
+type PagerDutyProvider struct {
+	ApiAddress string
+	Token      string
+	client     *http.Client
+}
+
+func NewPagerDutyProvider(address string, token string) *PagerDutyProvider {
+	if len(address) < 1 {
+		address = "https://api.pagerduty.com"
+	}
+
+	return &PagerDutyProvider{
+		ApiAddress: strings.TrimSuffix(address, "/"),
+		Token:      token,
+		client: &http.Client{
+			Transport: DefaultTransport(),
+			Timeout:   30 * time.Second,
+		},
+	}
+}
+
+func (p *PagerDutyProvider) Name() string {
+	return "pagerduty"
+}
+
+type pdReference struct {
+	Id      string `json:"id"`
+	Summary string `json:"summary"`
+	Email   string `json:"email"`
+}
+
+type pdIncident struct {
+	Id                 string      `json:"id"`
+	IncidentNumber     int         `json:"incident_number"`
+	Title              string      `json:"title"`
+	Status             string      `json:"status"`
+	Urgency            string      `json:"urgency"`
+	HtmlUrl            string      `json:"html_url"`
+	CreatedAt          time.Time   `json:"created_at"`
+	LastStatusChangeAt time.Time   `json:"last_status_change_at"`
+	Service            pdReference `json:"service"`
+	Assignments        []struct {
+		Assignee pdReference `json:"assignee"`
+	} `json:"assignments"`
+	Acknowledgements []struct {
+		At time.Time `json:"at"`
+	} `json:"acknowledgements"`
+}
+
+// Incidents returns the incidents created in the time range and all the open incidents,
+// the open ones are read without a date range so older incidents are kept up to date
+func (p *PagerDutyProvider) Incidents(since time.Time, until time.Time) ([]models.Incident, error) {
+	params := url.Values{}
+	params.Set("since", since.Format(time.RFC3339))
+	params.Set("until", until.Format(time.RFC3339))
+	incidents, err := p.incidents(params)
+	if err != nil {
+		return nil, err
+	}
+
+	open := url.Values{}
+	open.Set("date_range", "all")
+	open.Add("statuses[]", models.IncidentTriggered)
+	open.Add("statuses[]", models.IncidentAcknowledged)
+	openIncidents, err := p.incidents(open)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]models.Incident, 0)
+	index := make(map[string]bool)
+	for _, i := range append(incidents, openIncidents...) {
+		if !index[i.Id] {
+			index[i.Id] = true
+			result = append(result, i)
+		}
+	}
+
+	return result, nil
+}
+
+// incidents follows the offset pagination of /incidents
+func (p *PagerDutyProvider) incidents(params url.Values) ([]models.Incident, error) {
+	result := make([]models.Incident, 0)
+	offset := 0
+	for {
+		data := struct {
+			Incidents []pdIncident `json:"incidents"`
+			More      bool         `json:"more"`
+		}{}
+
+		params.Set("limit", "100")
+		params.Set("offset", strconv.Itoa(offset))
+		params.Set("time_zone", "UTC")
+		if err := p.get("/incidents?"+params.Encode(), &data); err != nil {
+			return nil, err
+		}
+
+		for _, i := range data.Incidents {
+			incident := models.Incident{
+				Id:        models.Hash(p.Name() + i.Id),
+				Provider:  p.Name(),
+				Number:    i.IncidentNumber,
+				Title:     i.Title,
+				Status:    i.Status,
+				Urgency:   i.Urgency,
+				Service:   i.Service.Summary,
+				Assignees: make([]string, 0),
+				Url:       i.HtmlUrl,
+				Created:   i.CreatedAt.UTC(),
+			}
+			for _, a := range i.Assignments {
+				incident.Assignees = append(incident.Assignees, a.Assignee.Summary)
+			}
+			for _, a := range i.Acknowledgements {
+				if incident.Acknowledged.IsZero() || a.At.Before(incident.Acknowledged) {
+					incident.Acknowledged = a.At.UTC()
+				}
+			}
+			if i.Status == models.IncidentResolved {
+				incident.Resolved = i.LastStatusChangeAt.UTC()
+			}
+			result = append(result, incident)
+		}
+
+		if !data.More || len(data.Incidents) < 1 {
+			break
+		}
+		offset += len(data.Incidents)
+	}
+
+	return result, nil
+}
+
+// OnCalls follows the offset pagination of /oncalls
+func (p *PagerDutyProvider) OnCalls() ([]models.OnCall, error) {
+	result := make([]models.OnCall, 0)
+	offset := 0
+	for {
+		data := struct {
+			OnCalls []struct {
+				User             pdReference `json:"user"`
+				Schedule         pdReference `json:"schedule"`
+				EscalationPolicy pdReference `json:"escalation_policy"`
+				EscalationLevel  int         `json:"escalation_level"`
+				Start            *time.Time  `json:"start"`
+				End              *time.Time  `json:"end"`
+			} `json:"oncalls"`
+			More bool `json:"more"`
+		}{}
+
+		params := url.Values{}
+		params.Set("limit", "100")
+		params.Set("offset", strconv.Itoa(offset))
+		params.Set("time_zone", "UTC")
+		params.Set("include[]", "users")
+		if err := p.get("/oncalls?"+params.Encode(), &data); err != nil {
+			return nil, err
+		}
+
+		for _, o := range data.OnCalls {
+			oncall := models.OnCall{
+				Id:               models.Hash(p.Name() + o.User.Id + o.Schedule.Id + o.EscalationPolicy.Id + strconv.Itoa(o.EscalationLevel)),
+				Provider:         p.Name(),
+				User:             o.User.Summary,
+				Email:            o.User.Email,
+				Schedule:         o.Schedule.Summary,
+				EscalationPolicy: o.EscalationPolicy.Summary,
+				Level:            o.EscalationLevel,
+			}
+			// permanent on-call shifts have no start or end
+			if o.Start != nil {
+				oncall.Start = o.Start.UTC()
+			}
+			if o.End != nil {
+				oncall.End = o.End.UTC()
+			}
+			result = append(result, oncall)
+		}
+
+		if !data.More || len(data.OnCalls) < 1 {
+			break
+		}
+		offset += len(data.OnCalls)
+	}
+
+	return result, nil
+}
+
+func (p *PagerDutyProvider) get(path string, result interface{}) error {
+	req, err := http.NewRequest("GET", p.ApiAddress+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.pagerduty+json;version=2")
+	req.Header.Set("Authorization", "Token token="+p.Token)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("PagerDuty API %v returned %v %v", path, resp.StatusCode, string(body))
+	}
+
+	return json.Unmarshal(body, result)
+}
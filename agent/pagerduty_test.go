@@ -0,0 +1,141 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stefanprodan/syros/models"
+)
+
+// newPagerDutyFake serves two pages of incidents in the date range,
+// the open incidents without a date range and two pages of on-call shifts
+func newPagerDutyFake(t *testing.T) *httptest.Server {
+	incident := func(id string, number int, status string, created string) map[string]interface{} {
+		return map[string]interface{}{
+			"id":                    id,
+			"incident_number":       number,
+			"title":                 "Incident " + id,
+			"status":                status,
+			"urgency":               "high",
+			"html_url":              "https://pd/incidents/" + id,
+			"created_at":            created,
+			"last_status_change_at": "2017-06-02T10:00:00Z",
+			"service":               map[string]string{"id": "S1", "summary": "api"},
+			"assignments":           []map[string]interface{}{{"assignee": map[string]string{"id": "U1", "summary": "Jane"}}},
+		}
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Token token=secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		q := r.URL.Query()
+		data := map[string]interface{}{}
+		switch r.URL.Path {
+		case "/incidents":
+			switch {
+			case q.Get("date_range") == "all":
+				if strings.Join(q["statuses[]"], ",") != "triggered,acknowledged" {
+					t.Errorf("Open incidents statuses %v", q["statuses[]"])
+				}
+				data["incidents"] = []interface{}{
+					incident("P2", 2, models.IncidentAcknowledged, "2017-06-01T12:00:00Z"),
+					incident("P0", 0, models.IncidentTriggered, "2017-01-01T00:00:00Z"),
+				}
+			case len(q.Get("since")) < 1 || len(q.Get("until")) < 1:
+				t.Errorf("Incidents query without date range or statuses %v", q)
+			case q.Get("offset") == "0":
+				data["incidents"] = []interface{}{incident("P1", 1, models.IncidentResolved, "2017-06-01T10:00:00Z")}
+				data["more"] = true
+			default:
+				data["incidents"] = []interface{}{incident("P2", 2, models.IncidentAcknowledged, "2017-06-01T12:00:00Z")}
+			}
+		case "/oncalls":
+			user := func(id string) map[string]interface{} {
+				return map[string]interface{}{
+					"user":              map[string]string{"id": id, "summary": "User " + id, "email": id + "@example.com"},
+					"schedule":          map[string]string{"id": "SCH", "summary": "Primary"},
+					"escalation_policy": map[string]string{"id": "EP", "summary": "Default"},
+					"escalation_level":  1,
+				}
+			}
+			if q.Get("offset") == "0" {
+				data["oncalls"] = []interface{}{user("U1"), user("U2")}
+				data["more"] = true
+			} else {
+				data["oncalls"] = []interface{}{user("U3")}
+			}
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(data)
+	}))
+}
+
+func TestPagerDutyIncidents(t *testing.T) {
+	ts := newPagerDutyFake(t)
+	defer ts.Close()
+
+	p := NewPagerDutyProvider(ts.URL, "secret")
+	until := time.Date(2017, 6, 3, 0, 0, 0, 0, time.UTC)
+	incidents, err := p.Incidents(until.Add(-30*24*time.Hour), until)
+	if err != nil {
+		t.Fatalf("Incidents failed %v", err)
+	}
+
+	status := make(map[int]models.Incident)
+	for _, i := range incidents {
+		status[i.Number] = i
+	}
+	if len(incidents) != 3 {
+		t.Fatalf("Incidents %v, want 3 distinct", len(incidents))
+	}
+	if i := status[0]; i.Status != models.IncidentTriggered || i.Created.Year() != 2017 || i.Created.Month() != 1 {
+		t.Errorf("Open incident created before the range %+v", i)
+	}
+	if i := status[1]; i.Status != models.IncidentResolved || i.Resolved.IsZero() {
+		t.Errorf("Resolved incident %+v", i)
+	}
+	if i := status[2]; i.Service != "api" || len(i.Assignees) != 1 || i.Assignees[0] != "Jane" {
+		t.Errorf("Incident service %v assignees %v", i.Service, i.Assignees)
+	}
+}
+
+func TestPagerDutyOnCalls(t *testing.T) {
+	ts := newPagerDutyFake(t)
+	defer ts.Close()
+
+	oncalls, err := NewPagerDutyProvider(ts.URL, "secret").OnCalls()
+	if err != nil {
+		t.Fatalf("OnCalls failed %v", err)
+	}
+
+	users := make([]string, 0)
+	for _, o := range oncalls {
+		users = append(users, o.User)
+		if !o.Start.IsZero() || !o.End.IsZero() {
+			t.Errorf("Permanent shift of %v has start %v end %v", o.User, o.Start, o.End)
+		}
+	}
+	if got := fmt.Sprint(users); got != "[User U1 User U2 User U3]" {
+		t.Errorf("On-call users %v, want the users of both pages", got)
+	}
+}
+
+func TestPagerDutyError(t *testing.T) {
+	ts := newPagerDutyFake(t)
+	defer ts.Close()
+
+	_, err := NewPagerDutyProvider(ts.URL, "wrong").Incidents(time.Now().Add(-time.Hour), time.Now())
+	if err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("Incidents error %v, want 401", err)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net/url"
+	"path"
 	"strings"
 	"time"
 
@@ -11,6 +12,7 @@ import (
 	"github.com/stefanprodan/syros/models"
 	"github.com/vmware/govmomi"
 	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/object"
 	"github.com/vmware/govmomi/property"
 	"github.com/vmware/govmomi/vim25/mo"
 	"github.com/vmware/govmomi/vim25/types"
@@ -20,26 +22,46 @@ type VSphereCollector struct {
 	ApiAddress  string
 	Include     []string
 	Exclude     []string
+	Datacenters []string
+	Performance bool
+	Samples     int
 	Environment string
 	Topic       string
 }
 
-func NewVSphereCollector(address string, include []string, exclude []string, env string) (*VSphereCollector, error) {
+func NewVSphereCollector(address string, config VSphereCollectorConfig, env string) (*VSphereCollector, error) {
 
 	c := &VSphereCollector{
 		ApiAddress:  address,
-		Include:     include,
-		Exclude:     exclude,
+		Include:     config.Include,
+		Exclude:     config.Exclude,
+		Datacenters: config.Datacenters,
+		Performance: config.Performance,
+		Samples:     config.Samples,
 		Environment: env,
 		Topic:       "vsphere",
 	}
 
+	// 5 minutes of realtime stats
+	if c.Samples < 1 {
+		c.Samples = 15
+	}
+
 	return c, nil
 }
 
 func (col *VSphereCollector) Collect() (*models.VSpherePayload, error) {
 	start := time.Now().UTC()
-	payload := &models.VSpherePayload{}
+	payload := &models.VSpherePayload{
+		Datacenters:   make([]string, 0),
+		Hosts:         make([]models.VSphereHost, 0),
+		DataStores:    make([]models.VSphereDatastore, 0),
+		VMs:           make([]models.VSphereVM, 0),
+		ResourcePools: make([]models.VSphereResourcePool, 0),
+		Networks:      make([]models.VSphereNetwork, 0),
+		Snapshots:     make([]models.VSphereSnapshot, 0),
+		Metrics:       make([]models.VSphereMetric, 0),
+	}
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -57,64 +79,136 @@ func (col *VSphereCollector) Collect() (*models.VSpherePayload, error) {
 	pc := property.DefaultCollector(c.Client)
 	f := find.NewFinder(c.Client, true)
 
-	dc, err := f.DefaultDatacenter(ctx)
+	dcs, err := f.DatacenterList(ctx, "*")
 	if err != nil {
 		return nil, err
 	}
-	f.SetDatacenter(dc)
 
-	datastores, err := getDatastores(ctx, f, pc)
+	for _, dc := range dcs {
+		name := dc.Name()
+		if len(col.Datacenters) > 0 && !contains(col.Datacenters, name) {
+			log.Debugf("%v datacenter %v excluded by filter", col.ApiAddress, name)
+			continue
+		}
+
+		f.SetDatacenter(dc)
+		inv := vsphereInventory{
+			vcenter:    u.Host,
+			datacenter: name,
+			include:    col.Include,
+			exclude:    col.Exclude,
+		}
+		err = inv.collect(ctx, f, pc)
+		if err != nil {
+			return nil, fmt.Errorf("datacenter %v %v", name, err)
+		}
+
+		payload.Datacenters = append(payload.Datacenters, name)
+		payload.DataStores = append(payload.DataStores, inv.datastores...)
+		payload.Hosts = append(payload.Hosts, inv.hosts...)
+		payload.VMs = append(payload.VMs, inv.vms...)
+		payload.ResourcePools = append(payload.ResourcePools, inv.pools...)
+		payload.Networks = append(payload.Networks, inv.networks...)
+		payload.Snapshots = append(payload.Snapshots, inv.snapshots...)
+	}
+
+	if col.Performance {
+		metrics, err := getMetrics(ctx, c.Client, getPerfEntities(payload.Hosts, payload.VMs, col.Environment), col.Samples)
+		if err != nil {
+			log.Errorf("%v performance metrics collect failed %v", col.ApiAddress, err)
+		} else {
+			payload.Metrics = metrics
+		}
+	}
+
+	log.Debugf("%v collect duration: %v datacenters %v vms %v metrics %v", col.ApiAddress, time.Now().UTC().Sub(start),
+		len(payload.Datacenters), len(payload.VMs), len(payload.Metrics))
+	return payload, nil
+}
+
+// vsphereInventory holds the objects of a datacenter,
+// the IDs are prefixed with the vCenter and datacenter since the managed object references are only unique per vCenter
+type vsphereInventory struct {
+	vcenter    string
+	datacenter string
+	include    []string
+	exclude    []string
+	datastores []models.VSphereDatastore
+	hosts      []models.VSphereHost
+	vms        []models.VSphereVM
+	pools      []models.VSphereResourcePool
+	networks   []models.VSphereNetwork
+	snapshots  []models.VSphereSnapshot
+}
+
+func (inv *vsphereInventory) id(ref types.ManagedObjectReference) string {
+	return fmt.Sprintf("%v:%v:%v", inv.vcenter, inv.datacenter, ref.Value)
+}
+
+func (inv *vsphereInventory) collect(ctx context.Context, f *find.Finder, pc *property.Collector) error {
+	var err error
+	inv.datastores, err = inv.getDatastores(ctx, f, pc)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	clusters, err := getClusters(ctx, f, pc)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	hosts, err := getHosts(ctx, f, pc, clusters)
+	inv.hosts, err = inv.getHosts(ctx, f, pc, clusters)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	vms, err := getVMs(ctx, f, pc, hosts, datastores, col.Include, col.Exclude)
+	inv.pools, err = inv.getResourcePools(ctx, f, pc)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	for _, vm := range vms {
-		for i, host := range hosts {
+	inv.networks, err = inv.getNetworks(ctx, f, pc)
+	if err != nil {
+		return err
+	}
+
+	inv.vms, inv.snapshots, err = inv.getVMs(ctx, f, pc)
+	if err != nil {
+		return err
+	}
+
+	for _, vm := range inv.vms {
+		for i, host := range inv.hosts {
 			if vm.HostId == host.Id {
-				hosts[i].VMs++
+				inv.hosts[i].VMs++
 			}
 		}
 
-		for i, ds := range datastores {
+		for i, ds := range inv.datastores {
 			if vm.DatastoreId == ds.Id {
-				datastores[i].VMs++
+				inv.datastores[i].VMs++
 			}
 		}
 	}
 
-	payload.DataStores = datastores
-	payload.Hosts = hosts
-	payload.VMs = vms
-
-	log.Debugf("%v collect duration: %v vms %v", col.ApiAddress, time.Now().UTC().Sub(start), len(payload.VMs))
-	return payload, nil
+	return nil
 }
 
-func getDatastores(ctx context.Context, f *find.Finder, pc *property.Collector) ([]models.VSphereDatastore, error) {
+func (inv *vsphereInventory) getDatastores(ctx context.Context, f *find.Finder, pc *property.Collector) ([]models.VSphereDatastore, error) {
 	result := make([]models.VSphereDatastore, 0)
 	dss, err := f.DatastoreList(ctx, "*")
 	if err != nil {
+		if isNotFound(err) {
+			return result, nil
+		}
 		return result, err
 	}
 
 	var refs []types.ManagedObjectReference
+	paths := make(map[string]string)
 	for _, ds := range dss {
 		refs = append(refs, ds.Reference())
+		paths[ds.Reference().Value] = ds.InventoryPath
 	}
 
 	var dst []mo.Datastore
@@ -125,12 +219,15 @@ func getDatastores(ctx context.Context, f *find.Finder, pc *property.Collector)
 
 	for _, ds := range dst {
 		res := models.VSphereDatastore{
-			Name:      ds.Summary.Name,
-			Collected: time.Now().UTC(),
-			Capacity:  ds.Summary.Capacity,
-			Free:      ds.Summary.FreeSpace,
-			Type:      ds.Summary.Type,
-			Id:        ds.Summary.Datastore.Value,
+			Name:       ds.Summary.Name,
+			Collected:  time.Now().UTC(),
+			Capacity:   ds.Summary.Capacity,
+			Free:       ds.Summary.FreeSpace,
+			Type:       ds.Summary.Type,
+			Id:         inv.id(ds.Summary.Datastore.Reference()),
+			Ref:        ds.Summary.Datastore.Value,
+			Datacenter: inv.datacenter,
+			Folder:     inventoryFolder(paths[ds.Summary.Datastore.Value]),
 		}
 		result = append(result, res)
 	}
@@ -143,6 +240,9 @@ func getClusters(ctx context.Context, f *find.Finder, pc *property.Collector) (m
 
 	clusters, err := f.ClusterComputeResourceList(ctx, "*")
 	if err != nil {
+		if isNotFound(err) {
+			return result, nil
+		}
 		return result, err
 	}
 
@@ -182,19 +282,24 @@ func getHostCluster(hostId string, clusters map[string][]string) string {
 	return ""
 }
 
-func getHosts(ctx context.Context, f *find.Finder,
+func (inv *vsphereInventory) getHosts(ctx context.Context, f *find.Finder,
 	pc *property.Collector,
 	clusters map[string][]string) ([]models.VSphereHost, error) {
 	result := make([]models.VSphereHost, 0)
 
 	hosts, err := f.HostSystemList(ctx, "*")
 	if err != nil {
+		if isNotFound(err) {
+			return result, nil
+		}
 		return result, err
 	}
 
 	var hRefs []types.ManagedObjectReference
+	paths := make(map[string]string)
 	for _, h := range hosts {
 		hRefs = append(hRefs, h.Reference())
+		paths[h.Reference().Value] = h.InventoryPath
 	}
 
 	var hostList []mo.HostSystem
@@ -206,13 +311,16 @@ func getHosts(ctx context.Context, f *find.Finder,
 	for _, host := range hostList {
 		res := models.VSphereHost{
 			Name:       host.Name,
-			Id:         host.Summary.Host.Value,
+			Id:         inv.id(host.Summary.Host.Reference()),
+			Ref:        host.Summary.Host.Value,
+			Datacenter: inv.datacenter,
+			Folder:     inventoryFolder(paths[host.Summary.Host.Value]),
 			Collected:  time.Now().UTC(),
 			PowerState: fmt.Sprintf("%v", host.Runtime.PowerState),
 			BootTime:   host.Runtime.BootTime,
 			Cluster:    getHostCluster(host.Summary.Host.Value, clusters),
 			Memory:     host.Hardware.MemorySize,
-			NCPU:       int(host.Hardware.CpuInfo.NumCpuPackages * host.Hardware.CpuInfo.NumCpuCores),
+			NCPU:       int(host.Hardware.CpuInfo.NumCpuCores),
 		}
 
 		result = append(result, res)
@@ -221,31 +329,188 @@ func getHosts(ctx context.Context, f *find.Finder,
 	return result, nil
 }
 
-func getVMs(ctx context.Context, f *find.Finder,
-	pc *property.Collector,
-	hosts []models.VSphereHost,
-	datastores []models.VSphereDatastore,
-	include []string, exclude []string) ([]models.VSphereVM, error) {
+func (inv *vsphereInventory) getResourcePools(ctx context.Context, f *find.Finder, pc *property.Collector) ([]models.VSphereResourcePool, error) {
+	result := make([]models.VSphereResourcePool, 0)
+
+	pools, err := f.ResourcePoolList(ctx, "*")
+	if err != nil {
+		if isNotFound(err) {
+			return result, nil
+		}
+		return result, err
+	}
+
+	var refs []types.ManagedObjectReference
+	paths := make(map[string]string)
+	for _, p := range pools {
+		refs = append(refs, p.Reference())
+		paths[p.Reference().Value] = p.InventoryPath
+	}
+
+	var poolList []mo.ResourcePool
+	err = pc.Retrieve(ctx, refs, []string{"name", "owner", "vm", "config", "runtime"}, &poolList)
+	if err != nil {
+		return result, err
+	}
+
+	// the owner is the cluster or the standalone host compute resource
+	var owners []types.ManagedObjectReference
+	for _, p := range poolList {
+		owners = append(owners, p.Owner)
+	}
+	var ownerList []mo.ManagedEntity
+	for _, refs := range groupByType(owners) {
+		var list []mo.ManagedEntity
+		err = pc.Retrieve(ctx, refs, []string{"name"}, &list)
+		if err != nil {
+			return result, err
+		}
+		ownerList = append(ownerList, list...)
+	}
+	ownerNames := make(map[string]string)
+	for _, o := range ownerList {
+		ownerNames[o.Reference().Value] = o.Name
+	}
+
+	for _, p := range poolList {
+		res := models.VSphereResourcePool{
+			Id:          inv.id(p.Reference()),
+			Ref:         p.Reference().Value,
+			Name:        p.Name,
+			Path:        paths[p.Reference().Value],
+			Datacenter:  inv.datacenter,
+			Cluster:     ownerNames[p.Owner.Value],
+			CPUUsage:    p.Runtime.Cpu.OverallUsage,
+			MemoryUsage: p.Runtime.Memory.OverallUsage,
+			VMs:         len(p.Vm),
+			Collected:   time.Now().UTC(),
+		}
+
+		res.CPUReservation, res.CPULimit = allocation(p.Config.CpuAllocation)
+		res.MemoryReservation, res.MemoryLimit = allocation(p.Config.MemoryAllocation)
+
+		result = append(result, res)
+	}
+
+	return result, nil
+}
+
+// allocation returns the reservation and limit of a pool, the unset limit means unlimited
+func allocation(info types.ResourceAllocationInfo) (int64, int64) {
+	var reservation, limit int64 = 0, -1
+	if info.Reservation != nil {
+		reservation = *info.Reservation
+	}
+	if info.Limit != nil {
+		limit = *info.Limit
+	}
+	return reservation, limit
+}
+
+// groupByType splits the references by type, the property collector retrieves a single type per request
+func groupByType(refs []types.ManagedObjectReference) map[string][]types.ManagedObjectReference {
+	result := make(map[string][]types.ManagedObjectReference)
+	for _, ref := range refs {
+		result[ref.Type] = append(result[ref.Type], ref)
+	}
+	return result
+}
+
+func (inv *vsphereInventory) getNetworks(ctx context.Context, f *find.Finder, pc *property.Collector) ([]models.VSphereNetwork, error) {
+	result := make([]models.VSphereNetwork, 0)
+
+	networks, err := f.NetworkList(ctx, "*")
+	if err != nil {
+		if isNotFound(err) {
+			return result, nil
+		}
+		return result, err
+	}
+
+	var refs []types.ManagedObjectReference
+	paths := make(map[string]string)
+	for _, n := range networks {
+		var p string
+		switch o := n.(type) {
+		case *object.Network:
+			p = o.InventoryPath
+		case *object.DistributedVirtualPortgroup:
+			p = o.InventoryPath
+		case *object.OpaqueNetwork:
+			p = o.InventoryPath
+		default:
+			// distributed switches are listed along with their port groups
+			continue
+		}
+		refs = append(refs, n.Reference())
+		paths[n.Reference().Value] = p
+	}
+	if len(refs) < 1 {
+		return result, nil
+	}
+
+	var netList []mo.Network
+	for _, refs := range groupByType(refs) {
+		var list []mo.Network
+		err = pc.Retrieve(ctx, refs, []string{"name", "summary", "host", "vm"}, &list)
+		if err != nil {
+			return result, err
+		}
+		netList = append(netList, list...)
+	}
+
+	for _, n := range netList {
+		res := models.VSphereNetwork{
+			Id:         inv.id(n.Reference()),
+			Ref:        n.Reference().Value,
+			Name:       n.Name,
+			Type:       n.Reference().Type,
+			Datacenter: inv.datacenter,
+			Folder:     inventoryFolder(paths[n.Reference().Value]),
+			Hosts:      len(n.Host),
+			VMs:        len(n.Vm),
+			Collected:  time.Now().UTC(),
+		}
+
+		if n.Summary != nil {
+			res.Accessible = n.Summary.GetNetworkSummary().Accessible
+		}
+
+		result = append(result, res)
+	}
+
+	return result, nil
+}
+
+func (inv *vsphereInventory) getVMs(ctx context.Context, f *find.Finder,
+	pc *property.Collector) ([]models.VSphereVM, []models.VSphereSnapshot, error) {
 	result := make([]models.VSphereVM, 0)
+	snapshots := make([]models.VSphereSnapshot, 0)
 
 	vms, err := f.VirtualMachineList(ctx, "*")
 	if err != nil {
-		return result, err
+		if isNotFound(err) {
+			return result, snapshots, nil
+		}
+		return result, snapshots, err
 	}
 
 	var vmRefs []types.ManagedObjectReference
+	paths := make(map[string]string)
 	for _, vm := range vms {
 		vmRefs = append(vmRefs, vm.Reference())
+		paths[vm.Reference().Value] = vm.InventoryPath
 	}
 
 	var vmt []mo.VirtualMachine
-	err = pc.Retrieve(ctx, vmRefs, []string{"name", "summary", "guest", "datastore", "runtime", "storage"}, &vmt)
+	err = pc.Retrieve(ctx, vmRefs, []string{"name", "summary", "guest", "datastore", "runtime", "storage",
+		"resourcePool", "network", "snapshot"}, &vmt)
 	if err != nil {
-		return result, err
+		return result, snapshots, err
 	}
 
 	for _, vm := range vmt {
-		in := applyFilter(vm.Name, include, exclude)
+		in := applyFilter(vm.Name, inv.include, inv.exclude)
 		if !in {
 			log.Debugf("%v VM excluded by filter", vm.Name)
 			continue
@@ -253,8 +518,8 @@ func getVMs(ctx context.Context, f *find.Finder,
 
 		hasHost := false
 		var host models.VSphereHost
-		for _, h := range hosts {
-			if h.Id == vm.Summary.Runtime.Host.Value {
+		for _, h := range inv.hosts {
+			if vm.Summary.Runtime.Host != nil && h.Ref == vm.Summary.Runtime.Host.Value {
 				hasHost = true
 				host = h
 				break
@@ -272,8 +537,8 @@ func getVMs(ctx context.Context, f *find.Finder,
 
 		hasDatastore := false
 		var datastore models.VSphereDatastore
-		for _, h := range datastores {
-			if h.Id == vm.Datastore[0].Value {
+		for _, h := range inv.datastores {
+			if h.Ref == vm.Datastore[0].Value {
 				hasDatastore = true
 				datastore = h
 				break
@@ -292,7 +557,11 @@ func getVMs(ctx context.Context, f *find.Finder,
 			BootTime:      vm.Runtime.BootTime,
 			PowerState:    fmt.Sprintf("%v", vm.Runtime.PowerState),
 			Collected:     time.Now().UTC(),
-			Id:            vm.Summary.Vm.Value,
+			Id:            inv.id(vm.Summary.Vm.Reference()),
+			Ref:           vm.Summary.Vm.Value,
+			Datacenter:    inv.datacenter,
+			Folder:        inventoryFolder(paths[vm.Summary.Vm.Value]),
+			Networks:      make([]string, 0),
 			HostName:      host.Name,
 			HostId:        host.Id,
 			DatastoreId:   datastore.Id,
@@ -304,6 +573,30 @@ func getVMs(ctx context.Context, f *find.Finder,
 			res.IP = vm.Guest.IpAddress
 		}
 
+		if vm.ResourcePool != nil {
+			for _, p := range inv.pools {
+				if p.Ref == vm.ResourcePool.Value {
+					res.ResourcePool = p.Name
+					break
+				}
+			}
+		}
+
+		for _, ref := range vm.Network {
+			for _, n := range inv.networks {
+				if n.Ref == ref.Value {
+					res.Networks = append(res.Networks, n.Name)
+					break
+				}
+			}
+		}
+
+		if vm.Snapshot != nil {
+			vmSnapshots := inv.mapSnapshots(res, vm.Snapshot.RootSnapshotList)
+			res.Snapshots = len(vmSnapshots)
+			snapshots = append(snapshots, vmSnapshots...)
+		}
+
 		var storeSize int64
 		for _, store := range vm.Storage.PerDatastoreUsage {
 			storeSize += store.Committed + store.Uncommitted
@@ -313,7 +606,47 @@ func getVMs(ctx context.Context, f *find.Finder,
 		result = append(result, res)
 	}
 
-	return result, nil
+	return result, snapshots, nil
+}
+
+// mapSnapshots flattens the VM snapshot tree
+func (inv *vsphereInventory) mapSnapshots(vm models.VSphereVM, tree []types.VirtualMachineSnapshotTree) []models.VSphereSnapshot {
+	result := make([]models.VSphereSnapshot, 0)
+	now := time.Now().UTC()
+	for _, node := range tree {
+		res := models.VSphereSnapshot{
+			Id:          inv.id(node.Snapshot),
+			Ref:         node.Snapshot.Value,
+			Name:        node.Name,
+			Description: node.Description,
+			VMId:        vm.Id,
+			VMName:      vm.Name,
+			Datacenter:  inv.datacenter,
+			PowerState:  fmt.Sprintf("%v", node.State),
+			Quiesced:    node.Quiesced,
+			Created:     node.CreateTime.UTC(),
+			Age:         int64(now.Sub(node.CreateTime).Seconds()),
+			Collected:   now,
+			Environment: vm.Environment,
+		}
+		result = append(result, res)
+		result = append(result, inv.mapSnapshots(vm, node.ChildSnapshotList)...)
+	}
+
+	return result
+}
+
+// inventoryFolder returns the folder path of an object, e.g. /DC1/vm/prod for /DC1/vm/prod/vm1
+func inventoryFolder(inventoryPath string) string {
+	if len(inventoryPath) < 1 {
+		return ""
+	}
+	return path.Dir(inventoryPath)
+}
+
+func isNotFound(err error) bool {
+	_, ok := err.(*find.NotFoundError)
+	return ok
 }
 
 func applyFilter(vm string, include []string, exclude []string) bool {
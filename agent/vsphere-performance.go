@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/stefanprodan/syros/models"
+	"github.com/vmware/govmomi/performance"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+const (
+	// realtime stats are sampled every 20 seconds and kept for one hour
+	vsphereRealtime = 20
+	// vCenter limits the number of entities per query with vpxd.stats.maxQueryMetrics, defaults to 64
+	vsphereQueryBatch = 50
+)
+
+var vsphereCounters = []string{
+	"cpu.usage.average",
+	"cpu.ready.summation",
+	"mem.active.average",
+	"mem.vmmemctl.average",
+	"disk.maxTotalLatency.latest",
+	"net.usage.average",
+}
+
+type vspherePerfEntity struct {
+	Id          string
+	Ref         types.ManagedObjectReference
+	Type        string
+	Name        string
+	NCPU        int
+	Environment string
+}
+
+// getPerfEntities returns the powered on hosts and VMs, the powered off ones have no realtime stats
+func getPerfEntities(hosts []models.VSphereHost, vms []models.VSphereVM, env string) []vspherePerfEntity {
+	result := make([]vspherePerfEntity, 0)
+	for _, host := range hosts {
+		if host.PowerState != string(types.HostSystemPowerStatePoweredOn) {
+			continue
+		}
+		result = append(result, vspherePerfEntity{
+			Id:          host.Id,
+			Ref:         types.ManagedObjectReference{Type: "HostSystem", Value: host.Ref},
+			Type:        "host",
+			Name:        host.Name,
+			NCPU:        host.NCPU,
+			Environment: env,
+		})
+	}
+
+	for _, vm := range vms {
+		if vm.PowerState != string(types.VirtualMachinePowerStatePoweredOn) {
+			continue
+		}
+		result = append(result, vspherePerfEntity{
+			Id:          vm.Id,
+			Ref:         types.ManagedObjectReference{Type: "VirtualMachine", Value: vm.Ref},
+			Type:        "vm",
+			Name:        vm.Name,
+			NCPU:        vm.NCPU,
+			Environment: vm.Environment,
+		})
+	}
+
+	return result
+}
+
+// getMetrics queries the realtime stats of the entities and aggregates the last samples into one metric per entity
+func getMetrics(ctx context.Context, c *vim25.Client, entities []vspherePerfEntity, samples int) ([]models.VSphereMetric, error) {
+	result := make([]models.VSphereMetric, 0)
+	if len(entities) < 1 {
+		return result, nil
+	}
+
+	m := performance.NewManager(c)
+	counters, err := m.CounterInfoByName(ctx)
+	if err != nil {
+		return result, err
+	}
+
+	ids := make([]types.PerfMetricId, 0)
+	names := make(map[int32]string)
+	for _, name := range vsphereCounters {
+		counter, ok := counters[name]
+		if !ok {
+			log.Debugf("vSphere performance counter %v not found", name)
+			continue
+		}
+		// the empty instance selects the entity aggregate instead of the per CPU, disk or NIC values
+		ids = append(ids, types.PerfMetricId{CounterId: counter.Key, Instance: ""})
+		names[counter.Key] = name
+	}
+	if len(ids) < 1 {
+		return result, fmt.Errorf("no performance counters found")
+	}
+
+	refs := make(map[string]vspherePerfEntity)
+	for _, e := range entities {
+		refs[e.Ref.Value] = e
+	}
+
+	for i := 0; i < len(entities); i += vsphereQueryBatch {
+		end := i + vsphereQueryBatch
+		if end > len(entities) {
+			end = len(entities)
+		}
+
+		var spec []types.PerfQuerySpec
+		for _, e := range entities[i:end] {
+			spec = append(spec, types.PerfQuerySpec{
+				Entity:     e.Ref,
+				MetricId:   ids,
+				IntervalId: vsphereRealtime,
+				MaxSample:  int32(samples),
+				Format:     string(types.PerfFormatNormal),
+			})
+		}
+
+		series, err := m.Query(ctx, spec)
+		if err != nil {
+			return result, err
+		}
+
+		for _, s := range series {
+			em, ok := s.(*types.PerfEntityMetric)
+			if !ok || len(em.SampleInfo) < 1 {
+				continue
+			}
+			e, ok := refs[em.Entity.Value]
+			if !ok {
+				continue
+			}
+			result = append(result, mapVSphereMetric(e, em, names))
+		}
+	}
+
+	return result, nil
+}
+
+// mapVSphereMetric averages the samples of each counter, the disk latency is the max over the samples
+func mapVSphereMetric(e vspherePerfEntity, em *types.PerfEntityMetric, names map[int32]string) models.VSphereMetric {
+	last := em.SampleInfo[len(em.SampleInfo)-1]
+	interval := last.Interval
+	if interval < 1 {
+		interval = vsphereRealtime
+	}
+
+	metric := models.VSphereMetric{
+		Id:          fmt.Sprintf("%v:%v", e.Id, last.Timestamp.Unix()),
+		EntityId:    e.Id,
+		EntityType:  e.Type,
+		Name:        e.Name,
+		Interval:    interval,
+		Timestamp:   last.Timestamp.UTC(),
+		Environment: e.Environment,
+	}
+
+	for _, v := range em.Value {
+		series, ok := v.(*types.PerfMetricIntSeries)
+		if !ok {
+			continue
+		}
+
+		// negative values mark the samples without data
+		var sum, max, count int64
+		for _, val := range series.Value {
+			if val < 0 {
+				continue
+			}
+			sum += val
+			count++
+			if val > max {
+				max = val
+			}
+		}
+		if count < 1 {
+			continue
+		}
+		avg := float64(sum) / float64(count)
+
+		switch names[series.Id.CounterId] {
+		case "cpu.usage.average":
+			// hundredths of a percent
+			metric.CPUUsage = avg / 100
+		case "cpu.ready.summation":
+			// milliseconds spent ready to run in the sample interval, summed over all vCPUs
+			ncpu := e.NCPU
+			if ncpu < 1 {
+				ncpu = 1
+			}
+			metric.CPUReady = avg / float64(int64(interval)*1000*int64(ncpu)) * 100
+		case "mem.active.average":
+			metric.MemActive = int64(avg) * 1024
+		case "mem.vmmemctl.average":
+			metric.MemBallooned = int64(avg) * 1024
+		case "disk.maxTotalLatency.latest":
+			metric.DiskLatency = max
+		case "net.usage.average":
+			metric.NetThroughput = int64(avg) * 1024
+		}
+	}
+
+	return metric
+}
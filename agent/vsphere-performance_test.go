@@ -0,0 +1,114 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stefanprodan/syros/models"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func TestVSphereCollectorPerformance(t *testing.T) {
+	model := simulator.VPX()
+	defer model.Remove()
+	if err := model.Create(); err != nil {
+		t.Fatal(err)
+	}
+
+	s := model.Service.NewServer()
+	defer s.Close()
+
+	col, _ := NewVSphereCollector(s.URL.String(), VSphereCollectorConfig{Performance: true, Samples: 3}, "dev")
+	payload, err := col.Collect()
+	if err != nil {
+		t.Fatalf("Collect failed %v", err)
+	}
+
+	entities := getPerfEntities(payload.Hosts, payload.VMs, "dev")
+	if len(entities) < 1 {
+		t.Fatal("No powered on hosts or VMs found")
+	}
+	if len(payload.Metrics) != len(entities) {
+		t.Fatalf("Metrics %v, want one per powered on entity %v", len(payload.Metrics), len(entities))
+	}
+
+	ids := make(map[string]vspherePerfEntity)
+	for _, e := range entities {
+		ids[e.Id] = e
+	}
+	for _, m := range payload.Metrics {
+		e, found := ids[m.EntityId]
+		if !found {
+			t.Errorf("Metric %v of unknown entity %v", m.Id, m.EntityId)
+			continue
+		}
+		if m.EntityType != e.Type || m.Name != e.Name || m.Interval != vsphereRealtime || m.Timestamp.IsZero() {
+			t.Errorf("Metric %+v doesn't match entity %+v", m, e)
+		}
+		if m.CPUUsage < 0 || m.CPUUsage > 100 {
+			t.Errorf("Metric %v CPU usage %v out of range", m.Name, m.CPUUsage)
+		}
+	}
+}
+
+func TestGetPerfEntities(t *testing.T) {
+	hosts := []models.VSphereHost{
+		{Id: "h1", Ref: "host-1", Name: "esx-01", NCPU: 16, PowerState: string(types.HostSystemPowerStatePoweredOn)},
+		{Id: "h2", Ref: "host-2", Name: "esx-02", PowerState: string(types.HostSystemPowerStateStandBy)},
+	}
+	vms := []models.VSphereVM{
+		{Id: "v1", Ref: "vm-1", Name: "web", NCPU: 2, Environment: "prod", PowerState: string(types.VirtualMachinePowerStatePoweredOn)},
+		{Id: "v2", Ref: "vm-2", Name: "db", PowerState: string(types.VirtualMachinePowerStatePoweredOff)},
+	}
+
+	entities := getPerfEntities(hosts, vms, "dev")
+	if len(entities) != 2 {
+		t.Fatalf("Entities %+v, want the powered on host and VM", entities)
+	}
+	if h := entities[0]; h.Ref.Type != "HostSystem" || h.Ref.Value != "host-1" || h.Type != "host" || h.NCPU != 16 || h.Environment != "dev" {
+		t.Errorf("Host entity %+v", h)
+	}
+	if v := entities[1]; v.Ref.Type != "VirtualMachine" || v.Ref.Value != "vm-1" || v.Type != "vm" || v.Environment != "prod" {
+		t.Errorf("VM entity %+v", v)
+	}
+}
+
+func TestMapVSphereMetric(t *testing.T) {
+	names := map[int32]string{1: "cpu.usage.average", 2: "cpu.ready.summation", 3: "mem.active.average", 4: "disk.maxTotalLatency.latest"}
+	series := func(id int32, values ...int64) types.BasePerfMetricSeries {
+		return &types.PerfMetricIntSeries{PerfMetricSeries: types.PerfMetricSeries{Id: types.PerfMetricId{CounterId: id}}, Value: values}
+	}
+
+	now := time.Date(2017, 6, 1, 10, 0, 0, 0, time.UTC)
+	em := &types.PerfEntityMetric{
+		SampleInfo: []types.PerfSampleInfo{
+			{Timestamp: now.Add(-20 * time.Second), Interval: 20},
+			{Timestamp: now, Interval: 20},
+		},
+		Value: []types.BasePerfMetricSeries{
+			series(1, 2500, 3500),
+			// 800ms ready of 20s on 2 vCPUs is 2%
+			series(2, 800, -1),
+			series(3, 1024, 3072),
+			series(4, 5, 12),
+		},
+	}
+
+	m := mapVSphereMetric(vspherePerfEntity{Id: "v1", Type: "vm", Name: "web", NCPU: 2, Environment: "dev"}, em, names)
+	if m.Id != "v1:1496311200" || !m.Timestamp.Equal(now) || m.Interval != 20 {
+		t.Errorf("Metric id %v timestamp %v interval %v, want the last sample", m.Id, m.Timestamp, m.Interval)
+	}
+	if m.CPUUsage != 30 {
+		t.Errorf("CPU usage %v, want 30", m.CPUUsage)
+	}
+	if m.CPUReady != 2 {
+		t.Errorf("CPU ready %v, want 2 without the missing sample", m.CPUReady)
+	}
+	if m.MemActive != 2048*1024 {
+		t.Errorf("Memory active %v, want 2MB", m.MemActive)
+	}
+	if m.DiskLatency != 12 {
+		t.Errorf("Disk latency %v, want the max 12", m.DiskLatency)
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/stefanprodan/syros/models"
+)
+
+// AuditMiddleware records every non-GET request in the audit_log collection,
+// must be registered after RealIP and RequestID
+func (s *HttpServer) AuditMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" || r.Method == "HEAD" || r.Method == "OPTIONS" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		t1 := time.Now()
+		defer func() {
+			actor, role := s.requestIdentity(r)
+			rec := models.AuditRecord{
+				Actor:     actor,
+				Role:      role,
+				Method:    r.Method,
+				Route:     r.URL.Path,
+				RequestId: middleware.GetReqID(r.Context()),
+				SourceIP:  r.RemoteAddr,
+				Status:    ww.Status(),
+				Result:    "success",
+				Duration:  int64(time.Now().Sub(t1) / time.Millisecond),
+				Timestamp: t1.UTC(),
+			}
+
+			// a panic is recorded as failure and handed over to the Recoverer
+			p := recover()
+			if p != nil {
+				rec.Status = http.StatusInternalServerError
+			} else if rec.Status == 0 {
+				rec.Status = http.StatusOK
+			}
+			if rec.Status >= 400 {
+				rec.Result = "failure"
+			}
+
+			// the target is the chain of URL params, e.g. the host or container id
+			if rctx := chi.RouteContext(r.Context()); rctx != nil {
+				rec.Target = strings.Join(rctx.URLParams.Values, "/")
+			}
+
+			s.Repository.AuditInsert(rec)
+
+			if p != nil {
+				panic(p)
+			}
+		}()
+
+		next.ServeHTTP(ww, r)
+	})
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"regexp"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/stefanprodan/syros/models"
+	"gopkg.in/mgo.v2/bson"
+)
+
+type AuditQuery struct {
+	Actor  string
+	Role   string
+	Method string
+	Route  string
+	Target string
+	Result string
+	From   time.Time
+	To     time.Time
+	Limit  int
+}
+
+func (q AuditQuery) selector() bson.M {
+	sel := bson.M{}
+	if len(q.Actor) > 0 {
+		sel["actor"] = q.Actor
+	}
+	if len(q.Role) > 0 {
+		sel["role"] = q.Role
+	}
+	if len(q.Method) > 0 {
+		sel["method"] = q.Method
+	}
+	if len(q.Route) > 0 {
+		sel["route"] = bson.RegEx{Pattern: "^" + regexp.QuoteMeta(q.Route)}
+	}
+	if len(q.Target) > 0 {
+		sel["target"] = q.Target
+	}
+	if len(q.Result) > 0 {
+		sel["result"] = q.Result
+	}
+
+	ts := bson.M{}
+	if !q.From.IsZero() {
+		ts["$gte"] = q.From
+	}
+	if !q.To.IsZero() {
+		ts["$lte"] = q.To
+	}
+	if len(ts) > 0 {
+		sel["timestamp"] = ts
+	}
+
+	return sel
+}
+
+// AuditInsert appends a record to the audit log, records are never updated or removed
+func (repo *Repository) AuditInsert(rec models.AuditRecord) {
+	s := repo.Session.Copy()
+	defer s.Close()
+
+	if len(rec.Id) < 1 {
+		rec.Id, _ = models.NewUUID()
+	}
+
+	c := s.DB(repo.Config.Database).C("audit_log")
+	err := c.Insert(&rec)
+	if err != nil {
+		log.Errorf("Repository AuditInsert failed for request %v %v", rec.RequestId, err)
+	}
+}
+
+func (repo *Repository) AuditLog(query AuditQuery) ([]models.AuditRecord, error) {
+	s := repo.Session.Copy()
+	defer s.Close()
+
+	c := s.DB(repo.Config.Database).C("audit_log")
+	records := []models.AuditRecord{}
+	err := c.Find(query.selector()).Sort("-timestamp").Limit(query.Limit).All(&records)
+	if err != nil {
+		log.Errorf("Repository AuditLog query failed %v", err)
+		return nil, err
+	}
+
+	return records, nil
+}
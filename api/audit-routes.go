@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/csv"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/jwtauth"
+	"github.com/go-chi/render"
+)
+
+func (s *HttpServer) auditRoutes() chi.Router {
+	r := chi.NewRouter()
+
+	// JWT protected, audit role only
+	r.Group(func(r chi.Router) {
+		r.Use(jwtauth.Verifier(s.TokenAuth))
+		r.Use(jwtauth.Authenticator)
+		r.Use(RequireRole(roleAudit))
+
+		r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+			query, err := parseAuditQuery(r)
+			if err != nil {
+				render.Status(r, http.StatusBadRequest)
+				render.PlainText(w, r, err.Error())
+				return
+			}
+
+			records, err := s.Repository.AuditLog(query)
+			if err != nil {
+				render.Status(r, http.StatusInternalServerError)
+				render.PlainText(w, r, err.Error())
+				return
+			}
+			render.JSON(w, r, records)
+		})
+
+		r.Get("/export", func(w http.ResponseWriter, r *http.Request) {
+			query, err := parseAuditQuery(r)
+			if err != nil {
+				render.Status(r, http.StatusBadRequest)
+				render.PlainText(w, r, err.Error())
+				return
+			}
+
+			records, err := s.Repository.AuditLog(query)
+			if err != nil {
+				render.Status(r, http.StatusInternalServerError)
+				render.PlainText(w, r, err.Error())
+				return
+			}
+
+			w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+			w.Header().Set("Content-Disposition", "attachment; filename=audit_log.csv")
+			cw := csv.NewWriter(w)
+			cw.Write([]string{"timestamp", "actor", "role", "method", "route", "target", "request_id", "source_ip", "status", "result", "duration_ms"})
+			for _, rec := range records {
+				cw.Write([]string{
+					rec.Timestamp.Format(time.RFC3339),
+					rec.Actor,
+					rec.Role,
+					rec.Method,
+					rec.Route,
+					rec.Target,
+					rec.RequestId,
+					rec.SourceIP,
+					strconv.Itoa(rec.Status),
+					rec.Result,
+					strconv.FormatInt(rec.Duration, 10),
+				})
+			}
+			cw.Flush()
+		})
+	})
+
+	return r
+}
+
+// parseAuditQuery reads the audit filters from the query string,
+// from and to are RFC3339 timestamps and limit defaults to 1000
+func parseAuditQuery(r *http.Request) (AuditQuery, error) {
+	q := r.URL.Query()
+	query := AuditQuery{
+		Actor:  q.Get("actor"),
+		Role:   q.Get("role"),
+		Method: q.Get("method"),
+		Route:  q.Get("route"),
+		Target: q.Get("target"),
+		Result: q.Get("result"),
+		Limit:  1000,
+	}
+
+	if v := q.Get("from"); len(v) > 0 {
+		from, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return query, err
+		}
+		query.From = from.UTC()
+	}
+
+	if v := q.Get("to"); len(v) > 0 {
+		to, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return query, err
+		}
+		query.To = to.UTC()
+	}
+
+	if v := q.Get("limit"); len(v) > 0 {
+		limit, err := strconv.Atoi(v)
+		if err != nil {
+			return query, err
+		}
+		if limit > 0 {
+			query.Limit = limit
+		}
+	}
+
+	return query, nil
+}
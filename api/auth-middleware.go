@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/go-chi/jwtauth"
+	"github.com/go-chi/render"
+)
+
+const (
+	roleAdmin = "admin"
+	roleAudit = "audit"
+)
+
+type Credential struct {
+	Username string
+	Password string
+	Role     string
+}
+
+// ParseCredentials splits the Credentials config into user@password[@role] entries,
+// if the role is missing the username is used as role
+func ParseCredentials(config string) []Credential {
+	result := make([]Credential, 0)
+	for _, entry := range strings.Split(config, ",") {
+		parts := strings.Split(strings.TrimSpace(entry), "@")
+		if len(parts) < 2 {
+			continue
+		}
+		cred := Credential{
+			Username: parts[0],
+			Password: parts[1],
+			Role:     parts[0],
+		}
+		if len(parts) > 2 && len(parts[2]) > 0 {
+			cred.Role = parts[2]
+		}
+		result = append(result, cred)
+	}
+
+	return result
+}
+
+// RequireRole allows the request only if the JWT role claim matches one of the roles,
+// must be used after the jwtauth Verifier and Authenticator
+func RequireRole(roles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, claims, err := jwtauth.FromContext(r.Context())
+			if err != nil {
+				render.Status(r, http.StatusUnauthorized)
+				render.PlainText(w, r, err.Error())
+				return
+			}
+
+			role, _ := claims["role"].(string)
+			for _, allowed := range roles {
+				if role == allowed {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+
+			render.Status(r, http.StatusForbidden)
+			render.PlainText(w, r, "Role "+role+" is not allowed")
+		})
+	}
+}
+
+// windowOverride reads the override query param and checks if the role
+// is allowed to deploy outside the maintenance windows
+func (s *HttpServer) windowOverride(r *http.Request, role string) (bool, error) {
+	if r.URL.Query().Get("override") != "true" {
+		return false, nil
+	}
+
+	for _, allowed := range s.overrideRoles() {
+		if len(role) > 0 && role == allowed {
+			return true, nil
+		}
+	}
+
+	return false, fmt.Errorf("Role %v is not allowed to override maintenance windows", role)
+}
+
+// overrideRoles returns the roles allowed to override and manage the maintenance windows
+func (s *HttpServer) overrideRoles() []string {
+	roles := make([]string, 0)
+	for _, role := range strings.Split(s.Config.WindowOverride, ",") {
+		if role = strings.TrimSpace(role); len(role) > 0 {
+			roles = append(roles, role)
+		}
+	}
+	return roles
+}
+
+// requestIdentity decodes the bearer token without enforcing it,
+// returns the user and role claims or anonymous if the token is missing or invalid
+func (s *HttpServer) requestIdentity(r *http.Request) (string, string) {
+	user, role := "anonymous", ""
+
+	bearer := r.Header.Get("Authorization")
+	if len(bearer) < 8 || strings.ToUpper(bearer[0:7]) != "BEARER " {
+		return user, role
+	}
+
+	token, err := s.TokenAuth.Decode(bearer[7:])
+	if err != nil || token == nil {
+		return user, role
+	}
+
+	if claims, ok := token.Claims.(jwt.MapClaims); ok {
+		if v, ok := claims["user"].(string); ok && len(v) > 0 {
+			user = v
+		}
+		if v, ok := claims["role"].(string); ok {
+			role = v
+			if user == "anonymous" {
+				user = v
+			}
+		}
+	}
+
+	return user, role
+}
+
+// requestUser returns the user claim of a verified JWT,
+// must be used after the jwtauth Verifier and Authenticator
+func requestUser(r *http.Request) string {
+	_, claims, err := jwtauth.FromContext(r.Context())
+	if err != nil {
+		return ""
+	}
+
+	if user, ok := claims["user"].(string); ok && len(user) > 0 {
+		return user
+	}
+	role, _ := claims["role"].(string)
+	return role
+}
+
+// requestRole returns the role claim of a verified JWT,
+// must be used after the jwtauth Verifier and Authenticator
+func requestRole(r *http.Request) string {
+	_, claims, err := jwtauth.FromContext(r.Context())
+	if err != nil {
+		return ""
+	}
+
+	role, _ := claims["role"].(string)
+	return role
+}
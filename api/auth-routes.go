@@ -2,7 +2,6 @@ package main
 
 import (
 	"net/http"
-	"strings"
 
 	"github.com/go-chi/chi"
 	"github.com/go-chi/jwtauth"
@@ -19,9 +18,15 @@ func (s *HttpServer) authRoutes() chi.Router {
 			render.PlainText(w, r, err.Error())
 			return
 		}
-		credentials := strings.Split(s.Config.Credentials, "@")
-		if data.Username == credentials[0] && data.Password == credentials[1] {
-			var claims = jwtauth.Claims{"role": data.Username}
+		var account *Credential
+		for _, cred := range ParseCredentials(s.Config.Credentials) {
+			if data.Username == cred.Username && data.Password == cred.Password {
+				account = &cred
+				break
+			}
+		}
+		if account != nil {
+			var claims = jwtauth.Claims{"role": account.Role, "user": account.Username}
 			claims = claims.SetIssuedNow()
 			// TODO: set expiry based on role
 			// claims = claims.SetExpiry(time.Now().Add(time.Hour * 48))
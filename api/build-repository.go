@@ -0,0 +1,65 @@
+package main
+
+import (
+	log "github.com/Sirupsen/logrus"
+	"github.com/stefanprodan/syros/models"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// JenkinsJobs returns the last build of each job
+func (repo *Repository) JenkinsJobs() ([]models.JenkinsBuild, error) {
+	s := repo.Session.Copy()
+	defer s.Close()
+
+	c := s.DB(repo.Config.Database).C("jenkins_builds")
+	result := []struct {
+		Build models.JenkinsBuild `bson:"build"`
+	}{}
+	pipe := c.Pipe([]bson.M{
+		{"$sort": bson.M{"timestamp": -1}},
+		{"$group": bson.M{"_id": "$job", "build": bson.M{"$first": "$$ROOT"}}},
+		{"$sort": bson.M{"_id": 1}},
+	})
+	err := pipe.All(&result)
+	if err != nil {
+		log.Errorf("Repository JenkinsJobs query failed %v", err)
+		return nil, err
+	}
+
+	builds := make([]models.JenkinsBuild, 0)
+	for _, r := range result {
+		builds = append(builds, r.Build)
+	}
+
+	return builds, nil
+}
+
+// ServiceBuilds returns the build history of a service with the deployments of each build
+func (repo *Repository) ServiceBuilds(service string, limit int) ([]models.JenkinsBuild, []models.Deployment, error) {
+	s := repo.Session.Copy()
+	defer s.Close()
+
+	c := s.DB(repo.Config.Database).C("jenkins_builds")
+	builds := []models.JenkinsBuild{}
+	err := c.Find(bson.M{"job": service}).Sort("-timestamp").Limit(limit).All(&builds)
+	if err != nil {
+		log.Errorf("Repository ServiceBuilds query failed for %v %v", service, err)
+		return nil, nil, err
+	}
+
+	ids := make([]string, 0)
+	for _, b := range builds {
+		ids = append(ids, b.Id)
+	}
+
+	d := s.DB(repo.Config.Database).C("deployments")
+	deployments := []models.Deployment{}
+	err = d.Find(bson.M{"build_id": bson.M{"$in": ids}}).
+		Select(bson.M{"log": 0, "env": 0, "labels": 0, "commits": 0}).Sort("-begin").All(&deployments)
+	if err != nil {
+		log.Errorf("Repository ServiceBuilds deployments query failed for %v %v", service, err)
+		return nil, nil, err
+	}
+
+	return builds, deployments, nil
+}
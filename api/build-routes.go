@@ -0,0 +1,74 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/jwtauth"
+	"github.com/go-chi/render"
+	"github.com/stefanprodan/syros/models"
+)
+
+func (s *HttpServer) buildRoutes() chi.Router {
+	r := chi.NewRouter()
+
+	// JWT protected
+	r.Group(func(r chi.Router) {
+		r.Use(jwtauth.Verifier(s.TokenAuth))
+		r.Use(jwtauth.Authenticator)
+
+		r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+			builds, err := s.Repository.JenkinsJobs()
+			if err != nil {
+				render.Status(r, http.StatusInternalServerError)
+				render.PlainText(w, r, err.Error())
+				return
+			}
+			render.JSON(w, r, builds)
+		})
+
+		r.Get("/{service}", func(w http.ResponseWriter, r *http.Request) {
+			limit := 100
+			if v := r.URL.Query().Get("limit"); len(v) > 0 {
+				l, err := strconv.Atoi(v)
+				if err != nil || l < 1 {
+					render.Status(r, http.StatusBadRequest)
+					render.PlainText(w, r, "Invalid limit "+v)
+					return
+				}
+				limit = l
+			}
+
+			builds, deployments, err := s.Repository.ServiceBuilds(chi.URLParam(r, "service"), limit)
+			if err != nil {
+				render.Status(r, http.StatusInternalServerError)
+				render.PlainText(w, r, err.Error())
+				return
+			}
+
+			// attach the deployments of each build
+			type buildDto struct {
+				models.JenkinsBuild
+				Deployments []models.Deployment `json:"deployments"`
+			}
+			payload := make([]buildDto, 0)
+			for _, b := range builds {
+				dto := buildDto{
+					JenkinsBuild: b,
+					Deployments:  make([]models.Deployment, 0),
+				}
+				for _, d := range deployments {
+					if d.BuildId == b.Id {
+						dto.Deployments = append(dto.Deployments, d)
+					}
+				}
+				payload = append(payload, dto)
+			}
+
+			render.JSON(w, r, payload)
+		})
+	})
+
+	return r
+}
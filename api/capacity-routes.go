@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/jwtauth"
+	"github.com/go-chi/render"
+	"github.com/stefanprodan/syros/models"
+)
+
+func (s *HttpServer) capacityRoutes() chi.Router {
+	r := chi.NewRouter()
+
+	// JWT protected
+	r.Group(func(r chi.Router) {
+		r.Use(jwtauth.Verifier(s.TokenAuth))
+		r.Use(jwtauth.Authenticator)
+
+		r.Get("/clusters", func(w http.ResponseWriter, r *http.Request) {
+			vsphere, _, err := s.Repository.AllVSphere(ListQuery{Sort: vmsListSpec.DefaultSort})
+			if err != nil {
+				render.Status(r, http.StatusInternalServerError)
+				render.PlainText(w, r, err.Error())
+				return
+			}
+
+			datacenter := r.URL.Query().Get("datacenter")
+			clusters := make([]models.ClusterCapacity, 0)
+			for _, c := range models.NewClusterCapacity(vsphere.Hosts, vsphere.VMs) {
+				if len(datacenter) < 1 || c.Datacenter == datacenter {
+					clusters = append(clusters, c)
+				}
+			}
+			render.JSON(w, r, clusters)
+		})
+
+		r.Get("/history/{entityID}", func(w http.ResponseWriter, r *http.Request) {
+			days, err := parseDays(r, 90)
+			if err != nil {
+				render.Status(r, http.StatusBadRequest)
+				render.PlainText(w, r, err.Error())
+				return
+			}
+
+			since := time.Now().UTC().Add(-time.Duration(days) * 24 * time.Hour)
+			snapshots, err := s.Repository.VSphereCapacity("", chi.URLParam(r, "entityID"), since)
+			if err != nil {
+				render.Status(r, http.StatusInternalServerError)
+				render.PlainText(w, r, err.Error())
+				return
+			}
+			render.JSON(w, r, snapshots)
+		})
+
+		r.Get("/forecast", func(w http.ResponseWriter, r *http.Request) {
+			kind := r.URL.Query().Get("type")
+			if len(kind) > 0 && kind != models.CapacityDatastore && kind != models.CapacityCluster {
+				render.Status(r, http.StatusBadRequest)
+				render.PlainText(w, r, "Invalid type "+kind)
+				return
+			}
+
+			days, err := parseDays(r, 90)
+			if err != nil {
+				render.Status(r, http.StatusBadRequest)
+				render.PlainText(w, r, err.Error())
+				return
+			}
+
+			now := time.Now().UTC()
+			snapshots, err := s.Repository.VSphereCapacity(kind, "", now.Add(-time.Duration(days)*24*time.Hour))
+			if err != nil {
+				render.Status(r, http.StatusInternalServerError)
+				render.PlainText(w, r, err.Error())
+				return
+			}
+			render.JSON(w, r, CapacityForecasts(snapshots, s.capacityLimits(), now))
+		})
+
+		r.Get("/placement", func(w http.ResponseWriter, r *http.Request) {
+			q := r.URL.Query()
+			cpu, err := strconv.ParseInt(q.Get("cpu"), 10, 64)
+			if err != nil || cpu < 1 {
+				render.Status(r, http.StatusBadRequest)
+				render.PlainText(w, r, "Invalid cpu "+q.Get("cpu"))
+				return
+			}
+			memory, err := strconv.ParseInt(q.Get("memory"), 10, 64)
+			if err != nil || memory < 1 {
+				render.Status(r, http.StatusBadRequest)
+				render.PlainText(w, r, "Invalid memory "+q.Get("memory"))
+				return
+			}
+			var storage int64
+			if v := q.Get("storage"); len(v) > 0 {
+				storage, err = strconv.ParseInt(v, 10, 64)
+				if err != nil || storage < 0 {
+					render.Status(r, http.StatusBadRequest)
+					render.PlainText(w, r, "Invalid storage "+v)
+					return
+				}
+			}
+
+			vsphere, _, err := s.Repository.AllVSphere(ListQuery{Sort: vmsListSpec.DefaultSort})
+			if err != nil {
+				render.Status(r, http.StatusInternalServerError)
+				render.PlainText(w, r, err.Error())
+				return
+			}
+
+			// memory is in MB and storage in GB like the VM specs
+			placements := PlaceVM(vsphere, cpu, memory*1024*1024, storage*1024*1024*1024, q.Get("datacenter"), s.capacityLimits())
+			render.JSON(w, r, placements)
+		})
+
+	})
+
+	return r
+}
+
+func (s *HttpServer) capacityLimits() CapacityLimits {
+	return CapacityLimits{
+		CPURatio:    s.Config.CPUOvercommit,
+		MemoryRatio: s.Config.MemoryOvercommit,
+		Reserve:     s.Config.DatastoreReserve,
+	}
+}
+
+func parseDays(r *http.Request, defaultDays int) (int, error) {
+	v := r.URL.Query().Get("days")
+	if len(v) < 1 {
+		return defaultDays, nil
+	}
+	days, err := strconv.Atoi(v)
+	if err != nil || days < 1 {
+		return 0, fmt.Errorf("Invalid days %v", v)
+	}
+	return days, nil
+}
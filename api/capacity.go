@@ -0,0 +1,221 @@
+package main
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/stefanprodan/syros/models"
+)
+
+// CapacityLimits are the max overcommit ratios of a cluster and the percentage of datastore capacity kept free
+type CapacityLimits struct {
+	CPURatio    float64
+	MemoryRatio float64
+	Reserve     int
+}
+
+func (l CapacityLimits) datastoreCapacity(capacity int64) int64 {
+	return capacity - capacity*int64(l.Reserve)/100
+}
+
+type capacityPoint struct {
+	day  float64
+	used float64
+}
+
+// CapacityForecasts fits a linear trend to the daily snapshots of each datastore and cluster,
+// the snapshots must be sorted by date, the datastores are forecasted on storage and the clusters on vCPU and vRAM.
+// The entities missing from the latest snapshot day are no longer in the inventory and are skipped.
+func CapacityForecasts(snapshots []models.VSphereCapacity, limits CapacityLimits, now time.Time) []models.CapacityForecast {
+	result := make([]models.CapacityForecast, 0)
+	var latest time.Time
+	entities := make([]string, 0)
+	series := make(map[string][]models.VSphereCapacity)
+	for _, s := range snapshots {
+		if _, ok := series[s.EntityId]; !ok {
+			entities = append(entities, s.EntityId)
+		}
+		series[s.EntityId] = append(series[s.EntityId], s)
+		if s.Date.After(latest) {
+			latest = s.Date
+		}
+	}
+
+	for _, id := range entities {
+		list := series[id]
+		last := list[len(list)-1]
+		if last.Date.Before(latest) {
+			continue
+		}
+		first := list[0].Date
+
+		switch last.Type {
+		case models.CapacityDatastore:
+			points := make([]capacityPoint, 0)
+			for _, s := range list {
+				points = append(points, capacityPoint{day: s.Date.Sub(first).Hours() / 24, used: float64(s.Used)})
+			}
+			result = append(result, newForecast(last, "storage", limits.datastoreCapacity(last.Capacity), last.Used, points, now))
+		case models.CapacityCluster:
+			cpu := make([]capacityPoint, 0)
+			mem := make([]capacityPoint, 0)
+			for _, s := range list {
+				day := s.Date.Sub(first).Hours() / 24
+				cpu = append(cpu, capacityPoint{day: day, used: float64(s.VCPU)})
+				mem = append(mem, capacityPoint{day: day, used: float64(s.VMemory)})
+			}
+			result = append(result, newForecast(last, "cpu", int64(float64(last.CPU)*limits.CPURatio), last.VCPU, cpu, now))
+			result = append(result, newForecast(last, "memory", int64(float64(last.Memory)*limits.MemoryRatio), last.VMemory, mem, now))
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return daysRank(result[i].DaysUntilFull) < daysRank(result[j].DaysUntilFull)
+	})
+
+	return result
+}
+
+// daysRank sorts the resources that will never be full last
+func daysRank(days int) int {
+	if days < 0 {
+		return math.MaxInt32
+	}
+	return days
+}
+
+func newForecast(last models.VSphereCapacity, resource string, capacity int64, used int64, points []capacityPoint, now time.Time) models.CapacityForecast {
+	f := models.CapacityForecast{
+		Type:          last.Type,
+		Resource:      resource,
+		EntityId:      last.EntityId,
+		Name:          last.Name,
+		Datacenter:    last.Datacenter,
+		Capacity:      capacity,
+		Used:          used,
+		Growth:        linearSlope(points),
+		DaysUntilFull: -1,
+		Samples:       len(points),
+	}
+
+	switch {
+	case used >= capacity:
+		f.DaysUntilFull = 0
+	case f.Growth > 0:
+		f.DaysUntilFull = int(math.Ceil(float64(capacity-used) / f.Growth))
+	}
+
+	if f.DaysUntilFull >= 0 {
+		full := now.Add(time.Duration(f.DaysUntilFull) * 24 * time.Hour)
+		f.FullDate = &full
+	}
+
+	return f
+}
+
+// linearSlope returns the least squares slope of the points in units per day, zero if there are less than two days
+func linearSlope(points []capacityPoint) float64 {
+	n := float64(len(points))
+	if n < 2 {
+		return 0
+	}
+
+	var sumX, sumY, sumXY, sumXX float64
+	for _, p := range points {
+		sumX += p.day
+		sumY += p.used
+		sumXY += p.day * p.used
+		sumXX += p.day * p.day
+	}
+
+	d := n*sumXX - sumX*sumX
+	if d == 0 {
+		return 0
+	}
+
+	return (n*sumXY - sumX*sumY) / d
+}
+
+// PlaceVM returns the clusters that can fit a VM of the specified vCPUs, memory and storage bytes
+// without exceeding the limits, ordered by the remaining headroom.
+// For each cluster the host with the lowest memory allocation and the datastore with the most free space are picked.
+func PlaceVM(inventory *models.VSpherePayload, cpu int64, memory int64, storage int64, datacenter string, limits CapacityLimits) []models.Placement {
+	result := make([]models.Placement, 0)
+
+	hostMemory := make(map[string]int64)
+	for _, vm := range inventory.VMs {
+		if vm.PowerState == "poweredOn" {
+			hostMemory[vm.HostId] += vm.Memory * 1024 * 1024
+		}
+	}
+
+	for _, c := range models.NewClusterCapacity(inventory.Hosts, inventory.VMs) {
+		if len(datacenter) > 0 && c.Datacenter != datacenter {
+			continue
+		}
+
+		cpuRatio := models.Ratio(c.VCPU+cpu, c.CPU)
+		memRatio := models.Ratio(c.VMemory+memory, c.Memory)
+		if c.CPU < 1 || c.Memory < 1 || cpuRatio > limits.CPURatio || memRatio > limits.MemoryRatio {
+			continue
+		}
+
+		var host *models.VSphereHost
+		hostRatio := 0.0
+		for i, h := range inventory.Hosts {
+			if models.ClusterId(h) != c.Id || h.PowerState != "poweredOn" || int64(h.NCPU) < cpu || h.Memory < 1 {
+				continue
+			}
+			ratio := float64(hostMemory[h.Id]+memory) / float64(h.Memory)
+			if host == nil || ratio < hostRatio {
+				host = &inventory.Hosts[i]
+				hostRatio = ratio
+			}
+		}
+		if host == nil {
+			continue
+		}
+
+		var datastore *models.VSphereDatastore
+		for i, ds := range inventory.DataStores {
+			if !contains(c.Datastores, ds.Id) {
+				continue
+			}
+			if ds.Free-storage < ds.Capacity-limits.datastoreCapacity(ds.Capacity) {
+				continue
+			}
+			if datastore == nil || ds.Free > datastore.Free {
+				datastore = &inventory.DataStores[i]
+			}
+		}
+		if datastore == nil {
+			continue
+		}
+
+		free := datastore.Free - storage
+		score := math.Min(1-cpuRatio/limits.CPURatio, 1-memRatio/limits.MemoryRatio)
+		if datastore.Capacity > 0 {
+			score = math.Min(score, float64(free)/float64(datastore.Capacity))
+		}
+
+		result = append(result, models.Placement{
+			Datacenter:    c.Datacenter,
+			Cluster:       c.Name,
+			HostId:        host.Id,
+			HostName:      host.Name,
+			DatastoreId:   datastore.Id,
+			DatastoreName: datastore.Name,
+			CPURatio:      cpuRatio,
+			MemoryRatio:   memRatio,
+			DatastoreFree: free,
+			Score:         float64(int64(score*1000+0.5)) / 1000,
+		})
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Score > result[j].Score
+	})
+
+	return result
+}
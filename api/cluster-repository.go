@@ -89,3 +89,88 @@ func (repo *Repository) ClusterHealthCheckLog(checkId string) ([]models.ClusterH
 
 	return logs, stats, nil
 }
+
+func (repo *Repository) ClusterStates() ([]models.ClusterState, error) {
+	s := repo.Session.Copy()
+	defer s.Close()
+
+	c := s.DB(repo.Config.Database).C("cluster_states")
+	states := []models.ClusterState{}
+	err := c.Find(nil).Sort("environment", "service_name").All(&states)
+	if err != nil {
+		log.Errorf("Repository ClusterStates query failed %v", err)
+		return nil, err
+	}
+
+	return states, nil
+}
+
+// ClusterStateLog returns the current state, the members checks and the transitions log of a clustered service
+func (repo *Repository) ClusterStateLog(stateId string) (models.ClusterState, []models.ClusterHealthCheck, []models.ClusterStateLog, error) {
+	s := repo.Session.Copy()
+	defer s.Close()
+
+	state := models.ClusterState{}
+	k := s.DB(repo.Config.Database).C("cluster_states")
+	err := k.FindId(stateId).One(&state)
+	if err != nil {
+		log.Errorf("Repository ClusterStateLog states query failed %v", err)
+		return state, nil, nil, err
+	}
+
+	m := s.DB(repo.Config.Database).C("cluster_checks")
+	members := []models.ClusterHealthCheck{}
+	err = m.Find(bson.M{"environment": state.Environment, "service_name": state.ServiceName}).Sort("host_name").All(&members)
+	if err != nil {
+		log.Errorf("Repository ClusterStateLog checks query failed %v", err)
+		return state, nil, nil, err
+	}
+
+	c := s.DB(repo.Config.Database).C("cluster_state_log")
+	logs := []models.ClusterStateLog{}
+	err = c.Find(bson.M{"state_id": stateId}).Sort("-begin").Limit(500).All(&logs)
+	if err != nil {
+		log.Errorf("Repository ClusterStateLog state_log query failed %v", err)
+		return state, nil, nil, err
+	}
+
+	// add current state to logs
+	logs = append([]models.ClusterStateLog{models.NewClusterStateLog(state, state.Since, time.Now().UTC())}, logs...)
+
+	return state, members, logs, nil
+}
+
+// ClusterIncidents returns the unhealthy periods of all clustered services since the specified time,
+// including the ones that are still open
+func (repo *Repository) ClusterIncidents(since time.Time) ([]models.ClusterStateLog, error) {
+	s := repo.Session.Copy()
+	defer s.Close()
+
+	k := s.DB(repo.Config.Database).C("cluster_states")
+	states := []models.ClusterState{}
+	err := k.Find(bson.M{"state": bson.M{"$ne": models.ClusterHealthy}}).All(&states)
+	if err != nil {
+		log.Errorf("Repository ClusterIncidents states query failed %v", err)
+		return nil, err
+	}
+
+	c := s.DB(repo.Config.Database).C("cluster_state_log")
+	logs := []models.ClusterStateLog{}
+	err = c.Find(bson.M{
+		"state": bson.M{"$ne": models.ClusterHealthy},
+		"end":   bson.M{"$gt": since},
+	}).Sort("-begin").Limit(500).All(&logs)
+	if err != nil {
+		log.Errorf("Repository ClusterIncidents state_log query failed %v", err)
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	incidents := []models.ClusterStateLog{}
+	for _, state := range states {
+		incidents = append(incidents, models.NewClusterStateLog(state, state.Since, now))
+	}
+	incidents = append(incidents, logs...)
+
+	return incidents, nil
+}
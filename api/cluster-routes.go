@@ -2,6 +2,8 @@ package main
 
 import (
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/go-chi/chi"
 	"github.com/go-chi/jwtauth"
@@ -47,6 +49,60 @@ func (s *HttpServer) clusterRoutes() chi.Router {
 			render.JSON(w, r, data)
 		})
 
+		r.Get("/states", func(w http.ResponseWriter, r *http.Request) {
+			states, err := s.Repository.ClusterStates()
+			if err != nil {
+				render.Status(r, http.StatusInternalServerError)
+				render.PlainText(w, r, err.Error())
+				return
+			}
+			render.JSON(w, r, states)
+		})
+
+		r.Get("/states/{stateID}", func(w http.ResponseWriter, r *http.Request) {
+			stateID := chi.URLParam(r, "stateID")
+			state, members, logs, err := s.Repository.ClusterStateLog(stateID)
+			if err != nil {
+				render.Status(r, http.StatusInternalServerError)
+				render.PlainText(w, r, err.Error())
+				return
+			}
+
+			data := struct {
+				State   models.ClusterState         `json:"state"`
+				Members []models.ClusterHealthCheck `json:"members"`
+				Log     []models.ClusterStateLog    `json:"log"`
+			}{
+				State:   state,
+				Members: members,
+				Log:     logs,
+			}
+
+			render.JSON(w, r, data)
+		})
+
+		r.Get("/incidents", func(w http.ResponseWriter, r *http.Request) {
+			days := 30
+			if v := r.URL.Query().Get("days"); len(v) > 0 {
+				d, err := strconv.Atoi(v)
+				if err != nil || d < 1 {
+					render.Status(r, http.StatusBadRequest)
+					render.PlainText(w, r, "Invalid days "+v)
+					return
+				}
+				days = d
+			}
+
+			since := time.Now().UTC().Add(-time.Duration(days) * 24 * time.Hour)
+			incidents, err := s.Repository.ClusterIncidents(since)
+			if err != nil {
+				render.Status(r, http.StatusInternalServerError)
+				render.PlainText(w, r, err.Error())
+				return
+			}
+			render.JSON(w, r, incidents)
+		})
+
 	})
 
 	return r
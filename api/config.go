@@ -2,12 +2,36 @@ package main
 
 // Config holds global configuration, defaults are provided in main.
 type Config struct {
-	LogLevel    string `m:"LogLevel"`
-	Port        int    `m:"Port"`
-	MongoDB     string `m:"MongoDB"`
-	Database    string `m:"Database"`
-	JwtSecret   string `m:"JwtSecret"`
-	Credentials string `m:"Credentials"`
-	AppPath     string `m:"AppPath"`
-	Nats        string `m:"Nats"`
+	LogLevel                 string  `m:"LogLevel"`
+	Port                     int     `m:"Port"`
+	MongoDB                  string  `m:"MongoDB"`
+	Database                 string  `m:"Database"`
+	JwtSecret                string  `m:"JwtSecret"`
+	Credentials              string  `m:"Credentials"`
+	AppPath                  string  `m:"AppPath"`
+	Nats                     string  `m:"Nats"`
+	PageSize                 int     `m:"PageSize"`
+	SshUser                  string  `m:"SshUser"`
+	SshPort                  int     `m:"SshPort"`
+	SshKey                   string  `m:"SshKey"`
+	SshKeyPass               string  `m:"SshKeyPass"`
+	SshTimeout               string  `m:"SshTimeout"`
+	ApprovalPolicy           string  `m:"ApprovalPolicy"`
+	WindowOverride           string  `m:"WindowOverride"`
+	DeliveryWindows          string  `m:"DeliveryWindows"`
+	GitPath                  string  `m:"GitPath"`
+	SmtpAddress              string  `m:"SmtpAddress"`
+	SmtpUser                 string  `m:"SmtpUser"`
+	SmtpPassword             string  `m:"SmtpPassword"`
+	SmtpFrom                 string  `m:"SmtpFrom"`
+	OnCallMail               string  `m:"OnCallMail"`
+	KibanaUrl                string  `m:"KibanaUrl"`
+	KibanaIndex              string  `m:"KibanaIndex"`
+	LogStatsRange            string  `m:"LogStatsRange"`
+	PrometheusUrl            string  `m:"PrometheusUrl"`
+	PrometheusHostLabel      string  `m:"PrometheusHostLabel"`
+	PrometheusContainerLabel string  `m:"PrometheusContainerLabel"`
+	CPUOvercommit            float64 `m:"CPUOvercommit"`
+	MemoryOvercommit         float64 `m:"MemoryOvercommit"`
+	DatastoreReserve         int     `m:"DatastoreReserve"`
 }
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/stefanprodan/syros/models"
+)
+
+// ConsulServiceStats counts the instances of each service per environment
+func ConsulServiceStats(services []models.ConsulService) []models.ConsulServiceStats {
+	index := make(map[string]*models.ConsulServiceStats)
+	keys := make([]string, 0)
+	for _, svc := range services {
+		key := svc.Environment + "/" + svc.Name
+		stat, found := index[key]
+		if !found {
+			stat = &models.ConsulServiceStats{
+				Environment: svc.Environment,
+				Name:        svc.Name,
+				Nodes:       make([]string, 0),
+				Tags:        make([]string, 0),
+			}
+			index[key] = stat
+			keys = append(keys, key)
+		}
+		stat.Instances++
+		if !contains(stat.Nodes, svc.Node) {
+			stat.Nodes = append(stat.Nodes, svc.Node)
+		}
+		for _, tag := range svc.Tags {
+			if !contains(stat.Tags, tag) {
+				stat.Tags = append(stat.Tags, tag)
+			}
+		}
+	}
+
+	sort.Strings(keys)
+	result := make([]models.ConsulServiceStats, 0, len(keys))
+	for _, key := range keys {
+		sort.Strings(index[key].Nodes)
+		sort.Strings(index[key].Tags)
+		result = append(result, *index[key])
+	}
+	return result
+}
+
+// ConsulMismatches compares the registrator registrations with the running containers,
+// containers are checked only in environments where registrator is used and only if they publish ports
+func ConsulMismatches(services []models.ConsulService, containers []models.DockerContainer) []models.ConsulMismatch {
+	result := make([]models.ConsulMismatch, 0)
+	registrator := make([]string, 0)
+
+	for _, svc := range services {
+		if len(svc.ContainerName) < 1 {
+			continue
+		}
+		if !contains(registrator, svc.Environment) {
+			registrator = append(registrator, svc.Environment)
+		}
+
+		found := false
+		for _, c := range containers {
+			if registeredContainer(svc, c) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			result = append(result, models.ConsulMismatch{
+				Type:          "orphan_registration",
+				Environment:   svc.Environment,
+				HostName:      svc.HostName,
+				ContainerName: svc.ContainerName,
+				ServiceID:     svc.ServiceID,
+				ServiceName:   svc.Name,
+			})
+		}
+	}
+
+	for _, c := range containers {
+		if len(c.PortBindings) < 1 || !contains(registrator, c.Environment) {
+			continue
+		}
+
+		found := false
+		for _, svc := range services {
+			if registeredContainer(svc, c) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			result = append(result, models.ConsulMismatch{
+				Type:          "unregistered_container",
+				Environment:   c.Environment,
+				HostName:      c.HostName,
+				ContainerName: strings.TrimPrefix(c.Name, "/"),
+				ContainerId:   c.Id,
+			})
+		}
+	}
+
+	return result
+}
+
+func registeredContainer(svc models.ConsulService, c models.DockerContainer) bool {
+	return svc.Environment == c.Environment &&
+		svc.ContainerName == strings.TrimPrefix(c.Name, "/") &&
+		hostMatch(svc.HostName, c.HostName)
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/stefanprodan/syros/models"
+)
+
+// statusRank orders the Consul statuses from the healthiest to the worst
+var statusRank = map[string]int{
+	"passing":     0,
+	"maintenance": 1,
+	"warning":     2,
+	"critical":    3,
+}
+
+// hostMatch compares the node and host names ignoring case and the domain
+func hostMatch(node string, host string) bool {
+	if strings.EqualFold(node, host) {
+		return true
+	}
+	return strings.EqualFold(strings.Split(node, ".")[0], strings.Split(host, ".")[0])
+}
+
+// JoinConsulNodes groups the node checks by node, the node status is the worst check status
+func JoinConsulNodes(checks []models.ConsulNodeCheck, docker []models.DockerHost, vsphere []models.VSphereHost) []models.ConsulNode {
+	index := make(map[string]*models.ConsulNode)
+	keys := make([]string, 0)
+	for _, check := range checks {
+		key := check.Environment + "/" + check.Node
+		node, found := index[key]
+		if !found {
+			node = &models.ConsulNode{
+				Node:        check.Node,
+				Environment: check.Environment,
+				Status:      check.Status,
+				Checks:      make([]models.ConsulNodeCheck, 0),
+			}
+			for _, h := range docker {
+				if h.Environment == check.Environment && hostMatch(check.Node, h.Name) {
+					node.DockerHostId = h.Id
+					break
+				}
+			}
+			for _, h := range vsphere {
+				if h.Environment == check.Environment && hostMatch(check.Node, h.Name) {
+					node.VSphereHostId = h.Id
+					break
+				}
+			}
+			index[key] = node
+			keys = append(keys, key)
+		}
+		if statusRank[check.Status] > statusRank[node.Status] {
+			node.Status = check.Status
+		}
+		node.Checks = append(node.Checks, check)
+	}
+
+	sort.Strings(keys)
+	result := make([]models.ConsulNode, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, *index[key])
+	}
+	return result
+}
+
+// NodeHealthStats counts the transitions and sums the seconds spent in each status
+func NodeHealthStats(logs []models.ConsulNodeCheckLog) []models.HealthCheckStats {
+	index := make(map[string]*models.HealthCheckStats)
+	for _, l := range logs {
+		stat, found := index[l.Status]
+		if !found {
+			stat = &models.HealthCheckStats{Status: l.Status}
+			index[l.Status] = stat
+		}
+		stat.Count++
+		stat.Duration += l.Duration
+	}
+
+	stats := make([]models.HealthCheckStats, 0, len(index))
+	for _, stat := range index {
+		stats = append(stats, *stat)
+	}
+	sort.Slice(stats, func(i, j int) bool { return statusRank[stats[i].Status] < statusRank[stats[j].Status] })
+	return stats
+}
+
+// NodeHealthChart splits the checks intervals by day and sums the seconds spent in each status,
+// maintenance is counted as warning
+func NodeHealthChart(logs []models.ConsulNodeCheckLog, from time.Time, to time.Time) models.HealthChart {
+	from = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	days := int(to.Sub(from)/(24*time.Hour)) + 1
+	chart := models.HealthChart{
+		Labels:   make([]string, days),
+		Passing:  make([]int64, days),
+		Warning:  make([]int64, days),
+		Critical: make([]int64, days),
+	}
+	for i := 0; i < days; i++ {
+		chart.Labels[i] = from.AddDate(0, 0, i).Format("06-01-02")
+	}
+
+	for _, l := range logs {
+		begin, end := l.Begin, l.End
+		if begin.Before(from) {
+			begin = from
+		}
+		if end.After(to) {
+			end = to
+		}
+		for begin.Before(end) {
+			day := int(begin.Sub(from) / (24 * time.Hour))
+			next := from.AddDate(0, 0, day+1)
+			if next.After(end) {
+				next = end
+			}
+			seconds := int64(next.Sub(begin) / time.Second)
+			switch l.Status {
+			case "passing":
+				chart.Passing[day] += seconds
+			case "warning", "maintenance":
+				chart.Warning[day] += seconds
+			case "critical":
+				chart.Critical[day] += seconds
+			}
+			begin = next
+		}
+	}
+
+	return chart
+}
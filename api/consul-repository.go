@@ -1,6 +1,7 @@
 package main
 
 import (
+	"regexp"
 	"time"
 
 	log "github.com/Sirupsen/logrus"
@@ -8,19 +9,15 @@ import (
 	"gopkg.in/mgo.v2/bson"
 )
 
-func (repo *Repository) AllHealthChecks() ([]models.ConsulHealthCheck, error) {
-	s := repo.Session.Copy()
-	defer s.Close()
-
-	c := s.DB(repo.Config.Database).C("checks")
+func (repo *Repository) AllHealthChecks(query ListQuery) ([]models.ConsulHealthCheck, int, error) {
 	checks := []models.ConsulHealthCheck{}
-	err := c.Find(nil).Sort("-collected").All(&checks)
+	total, err := repo.list("checks", query, &checks)
 	if err != nil {
 		log.Errorf("Repository AllHealthChecks query failed %v", err)
-		return nil, err
+		return nil, 0, err
 	}
 
-	return checks, nil
+	return checks, total, nil
 }
 
 func (repo *Repository) HealthCheckLog(checkId string) ([]models.ConsulHealthCheckLog, []models.HealthCheckStats, error) {
@@ -89,3 +86,132 @@ func (repo *Repository) HealthCheckLog(checkId string) ([]models.ConsulHealthChe
 
 	return logs, stats, nil
 }
+
+func (repo *Repository) NodeChecks(env string) ([]models.ConsulNodeCheck, error) {
+	s := repo.Session.Copy()
+	defer s.Close()
+
+	query := bson.M{}
+	if len(env) > 0 {
+		query["environment"] = env
+	}
+
+	c := s.DB(repo.Config.Database).C("node_checks")
+	checks := []models.ConsulNodeCheck{}
+	err := c.Find(query).Sort("node", "check_id").All(&checks)
+	if err != nil {
+		log.Errorf("Repository NodeChecks query failed %v", err)
+		return nil, err
+	}
+
+	return checks, nil
+}
+
+// NodeCheckLog returns the node checks transitions that ended after since,
+// the current status of each check is appended as an open interval ending now
+func (repo *Repository) NodeCheckLog(query bson.M, since time.Time) ([]models.ConsulNodeCheckLog, error) {
+	s := repo.Session.Copy()
+	defer s.Close()
+
+	logQuery := bson.M{"end": bson.M{"$gt": since}}
+	for k, v := range query {
+		logQuery[k] = v
+	}
+
+	l := s.DB(repo.Config.Database).C("node_checks_log")
+	logs := []models.ConsulNodeCheckLog{}
+	err := l.Find(logQuery).Sort("-begin").Limit(5000).All(&logs)
+	if err != nil {
+		log.Errorf("Repository NodeCheckLog node_checks_log query failed %v", err)
+		return nil, err
+	}
+
+	c := s.DB(repo.Config.Database).C("node_checks")
+	current := []models.ConsulNodeCheck{}
+	err = c.Find(query).All(&current)
+	if err != nil {
+		log.Errorf("Repository NodeCheckLog node_checks query failed %v", err)
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	for _, check := range current {
+		logs = append(logs, models.NewConsulNodeCheckLog(check, check.Since, now))
+	}
+
+	return logs, nil
+}
+
+// NodeHosts returns the Docker and vSphere hosts of the environment
+func (repo *Repository) NodeHosts(env string) ([]models.DockerHost, []models.VSphereHost, error) {
+	s := repo.Session.Copy()
+	defer s.Close()
+
+	query := bson.M{}
+	if len(env) > 0 {
+		query["environment"] = env
+	}
+	fields := bson.M{"name": 1, "environment": 1}
+
+	docker := []models.DockerHost{}
+	err := s.DB(repo.Config.Database).C("hosts").Find(query).Select(fields).All(&docker)
+	if err != nil {
+		log.Errorf("Repository NodeHosts hosts query failed %v", err)
+		return nil, nil, err
+	}
+
+	vsphere := []models.VSphereHost{}
+	err = s.DB(repo.Config.Database).C("vsphere_hosts").Find(query).Select(fields).All(&vsphere)
+	if err != nil {
+		log.Errorf("Repository NodeHosts vsphere_hosts query failed %v", err)
+		return nil, nil, err
+	}
+
+	return docker, vsphere, nil
+}
+
+func (repo *Repository) ConsulServices(env string, name string) ([]models.ConsulService, error) {
+	s := repo.Session.Copy()
+	defer s.Close()
+
+	query := bson.M{}
+	if len(env) > 0 {
+		query["environment"] = env
+	}
+	if len(name) > 0 {
+		query["name"] = name
+	}
+
+	c := s.DB(repo.Config.Database).C("consul_services")
+	services := []models.ConsulService{}
+	err := c.Find(query).Sort("name", "node").All(&services)
+	if err != nil {
+		log.Errorf("Repository ConsulServices query failed %v", err)
+		return nil, err
+	}
+
+	return services, nil
+}
+
+func (repo *Repository) ConsulKV(env string, prefix string) ([]models.ConsulKV, error) {
+	s := repo.Session.Copy()
+	defer s.Close()
+
+	query := bson.M{}
+	if len(env) > 0 {
+		query["environment"] = env
+	}
+	if len(prefix) > 0 {
+		query["key"] = bson.RegEx{Pattern: "^" + regexp.QuoteMeta(prefix)}
+	}
+
+	c := s.DB(repo.Config.Database).C("consul_kv")
+	pairs := []models.ConsulKV{}
+	err := c.Find(query).Sort("environment", "key").All(&pairs)
+	if err != nil {
+		log.Errorf("Repository ConsulKV query failed %v", err)
+		return nil, err
+	}
+
+	return pairs, nil
+}
@@ -2,11 +2,13 @@ package main
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/go-chi/chi"
 	"github.com/go-chi/jwtauth"
 	"github.com/go-chi/render"
 	"github.com/stefanprodan/syros/models"
+	"gopkg.in/mgo.v2/bson"
 )
 
 func (s *HttpServer) consulRoutes() chi.Router {
@@ -18,12 +20,20 @@ func (s *HttpServer) consulRoutes() chi.Router {
 		r.Use(jwtauth.Authenticator)
 
 		r.Get("/healthchecks", func(w http.ResponseWriter, r *http.Request) {
-			checks, err := s.Repository.AllHealthChecks()
+			query, err := ParseListQuery(r, checksListSpec, s.Config.PageSize)
+			if err != nil {
+				render.Status(r, http.StatusBadRequest)
+				render.PlainText(w, r, err.Error())
+				return
+			}
+
+			checks, total, err := s.Repository.AllHealthChecks(query)
 			if err != nil {
 				render.Status(r, http.StatusInternalServerError)
 				render.PlainText(w, r, err.Error())
 				return
 			}
+			setPageHeaders(w, r, query, total)
 			render.JSON(w, r, checks)
 		})
 
@@ -47,6 +57,122 @@ func (s *HttpServer) consulRoutes() chi.Router {
 			render.JSON(w, r, data)
 		})
 
+		r.Get("/nodes", func(w http.ResponseWriter, r *http.Request) {
+			env := r.URL.Query().Get("environment")
+			checks, err := s.Repository.NodeChecks(env)
+			if err != nil {
+				render.Status(r, http.StatusInternalServerError)
+				render.PlainText(w, r, err.Error())
+				return
+			}
+
+			docker, vsphere, err := s.Repository.NodeHosts(env)
+			if err != nil {
+				render.Status(r, http.StatusInternalServerError)
+				render.PlainText(w, r, err.Error())
+				return
+			}
+
+			render.JSON(w, r, JoinConsulNodes(checks, docker, vsphere))
+		})
+
+		// the host id is the Docker host id, node checks of hosts without Docker use the same hash of the node name
+		r.Get("/nodes/{hostID}", func(w http.ResponseWriter, r *http.Request) {
+			hostID := chi.URLParam(r, "hostID")
+			to := time.Now().UTC()
+			from := to.Add((-30 * 24) * time.Hour)
+			logs, err := s.Repository.NodeCheckLog(bson.M{"host_id": hostID}, from)
+			if err != nil {
+				render.Status(r, http.StatusInternalServerError)
+				render.PlainText(w, r, err.Error())
+				return
+			}
+
+			data := struct {
+				Checks []models.ConsulNodeCheckLog `json:"checks"`
+				Stats  []models.HealthCheckStats   `json:"stats"`
+				Chart  models.HealthChart          `json:"chart"`
+			}{
+				Checks: logs,
+				Stats:  NodeHealthStats(logs),
+				Chart:  NodeHealthChart(logs, from, to),
+			}
+
+			render.JSON(w, r, data)
+		})
+
+		r.Get("/services", func(w http.ResponseWriter, r *http.Request) {
+			services, err := s.Repository.ConsulServices(r.URL.Query().Get("environment"), r.URL.Query().Get("name"))
+			if err != nil {
+				render.Status(r, http.StatusInternalServerError)
+				render.PlainText(w, r, err.Error())
+				return
+			}
+			render.JSON(w, r, services)
+		})
+
+		r.Get("/services/stats", func(w http.ResponseWriter, r *http.Request) {
+			services, err := s.Repository.ConsulServices(r.URL.Query().Get("environment"), "")
+			if err != nil {
+				render.Status(r, http.StatusInternalServerError)
+				render.PlainText(w, r, err.Error())
+				return
+			}
+			render.JSON(w, r, ConsulServiceStats(services))
+		})
+
+		r.Get("/services/mismatches", func(w http.ResponseWriter, r *http.Request) {
+			env := r.URL.Query().Get("environment")
+			services, err := s.Repository.ConsulServices(env, "")
+			if err != nil {
+				render.Status(r, http.StatusInternalServerError)
+				render.PlainText(w, r, err.Error())
+				return
+			}
+
+			containers, err := s.Repository.RunningContainers(env)
+			if err != nil {
+				render.Status(r, http.StatusInternalServerError)
+				render.PlainText(w, r, err.Error())
+				return
+			}
+			render.JSON(w, r, ConsulMismatches(services, containers))
+		})
+
+		// the values of the watched prefixes are returned as is, by design the prefixes
+		// hold operational state like the pgha leader and must not contain secrets
+		r.Get("/kv", func(w http.ResponseWriter, r *http.Request) {
+			pairs, err := s.Repository.ConsulKV(r.URL.Query().Get("environment"), r.URL.Query().Get("prefix"))
+			if err != nil {
+				render.Status(r, http.StatusInternalServerError)
+				render.PlainText(w, r, err.Error())
+				return
+			}
+			render.JSON(w, r, pairs)
+		})
+
+		r.Get("/environments/{env}/health", func(w http.ResponseWriter, r *http.Request) {
+			env := chi.URLParam(r, "env")
+			to := time.Now().UTC()
+			from := to.Add((-30 * 24) * time.Hour)
+			logs, err := s.Repository.NodeCheckLog(bson.M{"environment": env}, from)
+			if err != nil {
+				render.Status(r, http.StatusInternalServerError)
+				render.PlainText(w, r, err.Error())
+				return
+			}
+
+			data := struct {
+				Stats []models.HealthCheckStats `json:"stats"`
+				Chart models.HealthChart        `json:"chart"`
+			}{
+				Stats: NodeHealthStats(logs),
+				Chart: NodeHealthChart(logs, from, to),
+			}
+
+			render.JSON(w, r, data)
+		})
+
 	})
 
 	return r
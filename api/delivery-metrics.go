@@ -0,0 +1,117 @@
+package main
+
+import (
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/robfig/cron"
+)
+
+// DeliveryCollector exposes the DORA metrics per service and environment as Prometheus gauges,
+// the environment totals are exposed with the service label set to all
+type DeliveryCollector struct {
+	Repository          *Repository
+	Windows             []string
+	deployments         *SnapshotGauge
+	deploymentFrequency *SnapshotGauge
+	changeFailureRate   *SnapshotGauge
+	timeToRestore       *SnapshotGauge
+}
+
+// deliveryValues holds the series of a collect before they are swapped in
+type deliveryValues struct {
+	deployments         GaugeValues
+	deploymentFrequency GaugeValues
+	changeFailureRate   GaugeValues
+	timeToRestore       GaugeValues
+}
+
+func NewDeliveryCollector(repo *Repository, windows []string) *DeliveryCollector {
+	labels := []string{"service", "environment", "window"}
+	dc := &DeliveryCollector{
+		Repository: repo,
+		Windows:    windows,
+		deployments: NewSnapshotGauge(prometheus.GaugeOpts{
+			Namespace: "syros",
+			Subsystem: "delivery",
+			Name:      "deployments",
+			Help:      "The number of finished deployments in the window.",
+		}, labels),
+		deploymentFrequency: NewSnapshotGauge(prometheus.GaugeOpts{
+			Namespace: "syros",
+			Subsystem: "delivery",
+			Name:      "deployment_frequency",
+			Help:      "The average number of deployments per day in the window.",
+		}, labels),
+		changeFailureRate: NewSnapshotGauge(prometheus.GaugeOpts{
+			Namespace: "syros",
+			Subsystem: "delivery",
+			Name:      "change_failure_rate",
+			Help:      "The percentage of failed or rolled back deployments in the window.",
+		}, labels),
+		timeToRestore: NewSnapshotGauge(prometheus.GaugeOpts{
+			Namespace: "syros",
+			Subsystem: "delivery",
+			Name:      "time_to_restore_seconds",
+			Help:      "The mean time to restore a failed deployment in the window.",
+		}, labels),
+	}
+
+	prometheus.MustRegister(dc.deployments)
+	prometheus.MustRegister(dc.deploymentFrequency)
+	prometheus.MustRegister(dc.changeFailureRate)
+	prometheus.MustRegister(dc.timeToRestore)
+
+	return dc
+}
+
+func (dc *DeliveryCollector) Start(cron *cron.Cron) {
+	dc.Collect()
+	cron.AddFunc("@every 5m", func() {
+		dc.Collect()
+	})
+}
+
+func (dc *DeliveryCollector) Collect() {
+	values := deliveryValues{
+		deployments:         make(GaugeValues),
+		deploymentFrequency: make(GaugeValues),
+		changeFailureRate:   make(GaugeValues),
+		timeToRestore:       make(GaugeValues),
+	}
+
+	for _, window := range dc.Windows {
+		duration, err := ParseDeliveryWindow(window)
+		if err != nil {
+			log.Errorf("Delivery metrics %v", err)
+			continue
+		}
+
+		to := time.Now().UTC()
+		from := to.Add(-duration)
+		deployments, err := dc.Repository.FinishedDeployments(from, "", "")
+		if err != nil {
+			continue
+		}
+
+		for _, m := range DeliveryMetrics(deployments, "", from, to).Metrics {
+			values.set(m.Service, m.Environment, window, m.Deployments, m.DeploymentFrequency, m.ChangeFailureRate, m.TimeToRestore)
+		}
+		for _, m := range DeliveryMetrics(deployments, "environment", from, to).Metrics {
+			values.set("all", m.Environment, window, m.Deployments, m.DeploymentFrequency, m.ChangeFailureRate, m.TimeToRestore)
+		}
+	}
+
+	dc.deployments.Swap(values.deployments)
+	dc.deploymentFrequency.Swap(values.deploymentFrequency)
+	dc.changeFailureRate.Swap(values.changeFailureRate)
+	dc.timeToRestore.Swap(values.timeToRestore)
+}
+
+func (v deliveryValues) set(service string, env string, window string, deployments int, frequency float64, failureRate float64, restore int64) {
+	v.deployments.Set(float64(deployments), service, env, window)
+	v.deploymentFrequency.Set(frequency, service, env, window)
+	v.changeFailureRate.Set(failureRate, service, env, window)
+	v.timeToRestore.Set(float64(restore), service, env, window)
+}
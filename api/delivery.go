@@ -0,0 +1,160 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stefanprodan/syros/models"
+)
+
+// DeliveryMetrics computes the DORA metrics of the deployments finished between from and to,
+// group can be service, environment or empty for a service and environment breakdown.
+// Rollbacks are not counted as deployments, failed and rolled back deployments are failures
+// and a failure is restored by the next successful deployment of the service on the environment.
+func DeliveryMetrics(list []models.Deployment, group string, from time.Time, to time.Time) models.DeliveryMetricsDto {
+	// sort a copy, the caller's slice is reused for other groups
+	deployments := make([]models.Deployment, len(list))
+	copy(deployments, list)
+	sort.Slice(deployments, func(i, j int) bool {
+		return deployments[i].Begin.Before(deployments[j].Begin)
+	})
+
+	days := to.Sub(from).Hours() / 24
+	if days < 1 {
+		days = 1
+	}
+
+	payload := models.DeliveryMetricsDto{
+		Metrics: make([]models.DeliveryMetrics, 0),
+		Total:   models.DeliveryMetrics{From: from, To: to},
+		Chart: models.ChartDto{
+			Labels: make([]string, 0),
+			Values: make([]int64, 0),
+		},
+	}
+	index := make(map[string]int)
+	var totalRestore int64
+	groupRestore := make(map[string]int64)
+
+	for i, dep := range deployments {
+		if dep.Status == models.DeploymentRunning || dep.Action == models.ActionRollback {
+			continue
+		}
+		if dep.End.Before(from) || dep.End.After(to) {
+			continue
+		}
+
+		key, service, env := deliveryKey(dep, group)
+		idx, found := index[key]
+		if !found {
+			payload.Metrics = append(payload.Metrics, models.DeliveryMetrics{
+				Service:     service,
+				Environment: env,
+				From:        from,
+				To:          to,
+			})
+			idx = len(payload.Metrics) - 1
+			index[key] = idx
+		}
+
+		m := &payload.Metrics[idx]
+		m.Deployments++
+		payload.Total.Deployments++
+		chartAdd(&payload.Chart, dep.End.Format("06-01-02"), 1)
+
+		if dep.Status != models.DeploymentFailed && dep.Status != models.DeploymentRolledBack {
+			continue
+		}
+		m.Failures++
+		payload.Total.Failures++
+
+		if restore, ok := restoreTime(deployments[i+1:], dep); ok {
+			m.Restored++
+			payload.Total.Restored++
+			groupRestore[key] += restore
+			totalRestore += restore
+		}
+	}
+
+	for key, idx := range index {
+		deliveryRates(&payload.Metrics[idx], days, groupRestore[key])
+	}
+	deliveryRates(&payload.Total, days, totalRestore)
+
+	sort.Slice(payload.Metrics, func(i, j int) bool {
+		if payload.Metrics[i].Service == payload.Metrics[j].Service {
+			return payload.Metrics[i].Environment < payload.Metrics[j].Environment
+		}
+		return payload.Metrics[i].Service < payload.Metrics[j].Service
+	})
+
+	return payload
+}
+
+// ParseDeliveryWindow parses a window like 30d or 12h
+func ParseDeliveryWindow(window string) (time.Duration, error) {
+	if strings.HasSuffix(window, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(window, "d"))
+		if err != nil || days < 1 {
+			return 0, fmt.Errorf("Invalid window %v", window)
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+
+	d, err := time.ParseDuration(window)
+	if err != nil || d < time.Hour {
+		return 0, fmt.Errorf("Invalid window %v", window)
+	}
+	return d, nil
+}
+
+// chartAdd increments the value of a label or appends the label to the chart
+func chartAdd(chart *models.ChartDto, label string, value int64) {
+	for i, l := range chart.Labels {
+		if l == label {
+			chart.Values[i] += value
+			return
+		}
+	}
+	chart.Labels = append(chart.Labels, label)
+	chart.Values = append(chart.Values, value)
+}
+
+func deliveryKey(dep models.Deployment, group string) (string, string, string) {
+	switch group {
+	case "service":
+		return dep.ServiceName, dep.ServiceName, ""
+	case "environment":
+		return dep.Environment, "", dep.Environment
+	default:
+		return dep.ServiceName + "/" + dep.Environment, dep.ServiceName, dep.Environment
+	}
+}
+
+// restoreTime returns the seconds between the failure and the next successful
+// deployment of the same service on the same environment
+func restoreTime(next []models.Deployment, failure models.Deployment) (int64, bool) {
+	for _, dep := range next {
+		if dep.ServiceName != failure.ServiceName || dep.Environment != failure.Environment {
+			continue
+		}
+		succeeded := dep.Status == models.DeploymentSucceeded || dep.Status == models.DeploymentFinished
+		if succeeded && dep.End.After(failure.End) {
+			return int64(dep.End.Sub(failure.End) / time.Second), true
+		}
+	}
+	return 0, false
+}
+
+func deliveryRates(m *models.DeliveryMetrics, days float64, restore int64) {
+	m.DeploymentFrequency = float64(m.Deployments) / days
+	if m.Deployments > 0 {
+		m.ChangeFailureRate = float64(m.Failures) * 100 / float64(m.Deployments)
+	}
+	if m.Restored > 0 {
+		m.TimeToRestore = restore / int64(m.Restored)
+	}
+}
@@ -2,78 +2,182 @@ package main
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
 	"time"
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/stefanprodan/syros/models"
+	"gopkg.in/mgo.v2"
 	"gopkg.in/mgo.v2/bson"
 )
 
-func (repo *Repository) DeploymentUpsert(dep models.Deployment) error {
+// DeploymentUpsert marks a running deployment as finished,
+// if no running deployment is found for the ticket, service and host a finished record is created
+func (repo *Repository) DeploymentUpsert(dep models.Deployment) (*models.Deployment, error) {
 	s := repo.Session.Copy()
 	defer s.Close()
 
-	// search for a release, update or insert
-	r := s.DB(repo.Config.Database).C("releases")
-	rel := models.Release{}
-	rels := []models.Release{}
-	err := r.Find(bson.M{"ticket_id": dep.TicketId}).All(&rels)
-	if err != nil {
-		log.Errorf("Repository DeploymentUpsert releases query failed %v", err)
-		return err
+	now := time.Now().UTC()
+	d := s.DB(repo.Config.Database).C("deployments")
+	running := models.Deployment{}
+	query := bson.M{
+		"ticket_id":    dep.TicketId,
+		"service_name": dep.ServiceName,
+		"host_name":    dep.HostName,
+		"environment":  dep.Environment,
+		"status":       models.DeploymentRunning,
+	}
+	if len(dep.Id) > 0 {
+		query = bson.M{"_id": dep.Id}
+	}
+	err := d.Find(query).Sort("-begin").One(&running)
+	if err != nil && err != mgo.ErrNotFound {
+		log.Errorf("Repository DeploymentUpsert deployments query failed %v", err)
+		return nil, err
 	}
 
-	if len(rels) < 1 {
-		rel = models.Release{
-			Id:       models.Hash(dep.TicketId),
-			Begin:    time.Now().UTC(),
-			End:      time.Now().UTC().Add(1 * time.Second),
-			Name:     dep.TicketId,
-			TicketId: dep.TicketId,
+	rel, uerr := repo.releaseUpsert(s, dep, fmt.Sprintf("%v deployed on %v at %v env %v \n", dep.ServiceName, dep.HostName, now, dep.Environment))
+	if uerr != nil {
+		return nil, uerr
+	}
+
+	if err == mgo.ErrNotFound {
+		running = dep
+		running.Id, _ = models.NewUUID()
+		running.ReleaseId = rel.Id
+		running.Begin = now
+		if len(running.PreviousTag) < 1 {
+			running.PreviousTag = repo.previousTag(s, dep)
 		}
 	} else {
-		rel = rels[0]
-		rel.End = time.Now().UTC()
+		running.ReleaseId = rel.Id
+		running.Log = dep.Log
+		if len(dep.Tag) > 0 {
+			running.Tag = dep.Tag
+		}
+		if len(dep.Image) > 0 {
+			running.Image = dep.Image
+		}
+		if len(dep.Initiator) > 0 && len(running.Initiator) < 1 {
+			running.Initiator = dep.Initiator
+		}
+	}
+	if len(running.BuildId) < 1 {
+		repo.linkBuild(s, &running)
 	}
 
-	dlog := fmt.Sprintf("%v deployed on %v at %v env %v \n", dep.ServiceName, dep.HostName, time.Now().UTC(), dep.Environment)
-	rel.Log += dlog
-	rel.Deployments++
+	running.Status = dep.Status
+	if len(running.Status) < 1 || running.Status == models.DeploymentRunning {
+		running.Status = models.DeploymentSucceeded
+	}
+	running.End = now
+	running.Timestamp = now
+	if !running.Begin.IsZero() {
+		running.Duration = int64(running.End.Sub(running.Begin) / time.Second)
+	}
 
-	_, err = r.UpsertId(rel.Id, &rel)
+	_, err = d.UpsertId(running.Id, &running)
 	if err != nil {
-		log.Errorf("Repository DeploymentUpsert releases upsert failed %v", err)
-		return err
+		log.Errorf("Repository DeploymentUpsert deployments upsert failed %v", err)
+		return nil, err
 	}
 
-	dep.ReleaseId = rel.Id
-	dep.Timestamp = time.Now().UTC()
-	dep.Status = "Finished"
-	dep.Id = models.Hash(fmt.Sprintf("%v%v%v", dep.TicketId, dep.ServiceName, dep.HostName))
+	// a successful rollback marks the last successful deployment of the service on the host as rolled back
+	if running.Action == models.ActionRollback && running.Status == models.DeploymentSucceeded {
+		prev := models.Deployment{}
+		err = d.Find(bson.M{
+			"service_name": running.ServiceName,
+			"host_name":    running.HostName,
+			"environment":  running.Environment,
+			"status":       bson.M{"$in": []string{models.DeploymentSucceeded, models.DeploymentFinished}},
+			"action":       bson.M{"$ne": models.ActionRollback},
+			"begin":        bson.M{"$lt": running.Begin},
+		}).Sort("-begin").One(&prev)
+		if err == nil {
+			err = d.UpdateId(prev.Id, bson.M{"$set": bson.M{"status": models.DeploymentRolledBack}})
+			if err != nil {
+				log.Errorf("Repository DeploymentUpsert rollback update failed for %v %v", prev.Id, err)
+			} else if prev.ReleaseId != running.ReleaseId {
+				repo.releaseStats(s, prev.ReleaseId)
+			}
+		}
+	}
 
-	d := s.DB(repo.Config.Database).C("deployments")
-	_, err = d.UpsertId(dep.Id, &dep)
+	if err := repo.releaseStats(s, running.ReleaseId); err != nil {
+		return nil, err
+	}
+
+	return &running, nil
+}
+
+// DeploymentStarted checks if the deployment was registered as running by the start call
+func (repo *Repository) DeploymentStarted(dep models.Deployment) bool {
+	s := repo.Session.Copy()
+	defer s.Close()
+
+	c := s.DB(repo.Config.Database).C("deployments")
+	query := bson.M{
+		"ticket_id":    dep.TicketId,
+		"service_name": dep.ServiceName,
+		"host_name":    dep.HostName,
+		"environment":  dep.Environment,
+		"status":       models.DeploymentRunning,
+	}
+	if len(dep.Id) > 0 {
+		query = bson.M{"_id": dep.Id, "status": models.DeploymentRunning}
+	}
+	count, err := c.Find(query).Count()
 	if err != nil {
-		log.Errorf("Repository DeploymentUpsert deployments upsert failed %v", err)
-		return err
+		log.Errorf("Repository DeploymentStarted query failed %v", err)
+		return false
 	}
 
-	return nil
+	return count > 0
 }
 
-func (repo *Repository) DeploymentStartUpsert(dep models.Deployment) error {
+// DeploymentStartUpsert records a running deployment and appends it to the ticket release
+func (repo *Repository) DeploymentStartUpsert(dep models.Deployment) (*models.Deployment, error) {
 	s := repo.Session.Copy()
 	defer s.Close()
 
-	// search for a release, update or insert
+	now := time.Now().UTC()
+	rel, err := repo.releaseUpsert(s, dep, fmt.Sprintf("%v deploying on %v at %v env %v \n", dep.ServiceName, dep.HostName, now, dep.Environment))
+	if err != nil {
+		return nil, err
+	}
+
+	dep.Id, _ = models.NewUUID()
+	dep.ReleaseId = rel.Id
+	dep.Status = models.DeploymentRunning
+	dep.Begin = now
+	dep.End = time.Time{}
+	dep.Duration = 0
+	dep.Timestamp = now
+	if len(dep.PreviousTag) < 1 {
+		dep.PreviousTag = repo.previousTag(s, dep)
+	}
+	repo.linkBuild(s, &dep)
+
+	d := s.DB(repo.Config.Database).C("deployments")
+	err = d.Insert(&dep)
+	if err != nil {
+		log.Errorf("Repository DeploymentStartUpsert deployments insert failed %v", err)
+		return nil, err
+	}
+
+	return &dep, nil
+}
+
+// releaseUpsert searches for the ticket release, updates or inserts it and appends the log line
+func (repo *Repository) releaseUpsert(s *mgo.Session, dep models.Deployment, dlog string) (*models.Release, error) {
 	r := s.DB(repo.Config.Database).C("releases")
 	rel := models.Release{}
 	rels := []models.Release{}
 	err := r.Find(bson.M{"ticket_id": dep.TicketId}).All(&rels)
 	if err != nil {
-		log.Errorf("Repository DeploymentStartUpsert releases query failed %v", err)
-		return err
+		log.Errorf("Repository releaseUpsert releases query failed %v", err)
+		return nil, err
 	}
 
 	if len(rels) < 1 {
@@ -93,14 +197,114 @@ func (repo *Repository) DeploymentStartUpsert(dep models.Deployment) error {
 		}
 	}
 
-	dlog := fmt.Sprintf("%v deploying on %v at %v env %v \n", dep.ServiceName, dep.HostName, time.Now().UTC(), dep.Environment)
 	rel.Log += dlog
 
 	_, err = r.UpsertId(rel.Id, &rel)
 	if err != nil {
-		log.Errorf("Repository DeploymentStartUpsert releases upsert failed %v", err)
+		log.Errorf("Repository releaseUpsert releases upsert failed %v", err)
+		return nil, err
+	}
+
+	return &rel, nil
+}
+
+// releaseStats counts the finished deployments of a release by status,
+// the lead time is the number of seconds between the first deployment start and the last finish
+func (repo *Repository) releaseStats(s *mgo.Session, releaseId string) error {
+	deployments := []models.Deployment{}
+	d := s.DB(repo.Config.Database).C("deployments")
+	err := d.Find(bson.M{"release_id": releaseId}).Select(bson.M{"status": 1, "begin": 1, "end": 1}).All(&deployments)
+	if err != nil {
+		log.Errorf("Repository releaseStats deployments query failed for release %v %v", releaseId, err)
+		return err
+	}
+
+	set := bson.M{}
+	total, succeeded, failed, rolledBack := 0, 0, 0, 0
+	var begin, end time.Time
+	for _, dep := range deployments {
+		if dep.Status == models.DeploymentRunning {
+			continue
+		}
+		total++
+		switch dep.Status {
+		case models.DeploymentSucceeded, models.DeploymentFinished:
+			succeeded++
+		case models.DeploymentFailed:
+			failed++
+		case models.DeploymentRolledBack:
+			rolledBack++
+		}
+		// the legacy deployments have no begin and end, they count but have no lead time
+		if dep.Begin.IsZero() || dep.End.IsZero() {
+			continue
+		}
+		if begin.IsZero() || dep.Begin.Before(begin) {
+			begin = dep.Begin
+		}
+		if dep.End.After(end) {
+			end = dep.End
+		}
+	}
+
+	set["deployments"] = total
+	set["succeeded"] = succeeded
+	set["failed"] = failed
+	set["rolled_back"] = rolledBack
+	if total > 0 {
+		set["success_rate"] = float64(succeeded) * 100 / float64(total)
+	}
+	if !begin.IsZero() {
+		set["lead_time"] = int64(end.Sub(begin) / time.Second)
+	}
+
+	r := s.DB(repo.Config.Database).C("releases")
+	err = r.UpdateId(releaseId, bson.M{"$set": set})
+	if err != nil {
+		log.Errorf("Repository releaseStats releases update failed for release %v %v", releaseId, err)
 		return err
 	}
 
 	return nil
 }
+
+// previousTag returns the tag of the last successful deployment of the service on the host
+func (repo *Repository) previousTag(s *mgo.Session, dep models.Deployment) string {
+	prev := models.Deployment{}
+	d := s.DB(repo.Config.Database).C("deployments")
+	err := d.Find(bson.M{
+		"service_name": dep.ServiceName,
+		"host_name":    dep.HostName,
+		"environment":  dep.Environment,
+		"status":       bson.M{"$in": []string{models.DeploymentSucceeded, models.DeploymentFinished}},
+		"tag":          bson.M{"$ne": ""},
+	}).Sort("-begin").One(&prev)
+	if err != nil {
+		return ""
+	}
+
+	return prev.Tag
+}
+
+// linkBuild sets the Jenkins build that produced the deployed tag,
+// the build job or one of its folders must be named after the service
+func (repo *Repository) linkBuild(s *mgo.Session, dep *models.Deployment) {
+	if len(dep.Tag) < 1 {
+		return
+	}
+
+	build := models.JenkinsBuild{}
+	c := s.DB(repo.Config.Database).C("jenkins_builds")
+	err := c.Find(bson.M{
+		"job":    bson.RegEx{Pattern: "^(.*/)?" + regexp.QuoteMeta(dep.ServiceName) + "(/.*)?$"},
+		"tag":    dep.Tag,
+		"result": "success",
+	}).Sort("-timestamp").One(&build)
+	if err != nil {
+		return
+	}
+
+	dep.BuildId = build.Id
+	dep.BuildNumber = build.Number
+	dep.BuildUrl = build.Url
+}
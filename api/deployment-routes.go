@@ -1,8 +1,10 @@
 package main
 
 import (
+	"fmt"
 	"net/http"
 
+	log "github.com/Sirupsen/logrus"
 	"github.com/go-chi/chi"
 	"github.com/go-chi/render"
 	"github.com/stefanprodan/syros/models"
@@ -14,50 +16,135 @@ func (s *HttpServer) deploymentApiRoutes() chi.Router {
 	r.Post("/start", func(w http.ResponseWriter, r *http.Request) {
 		d := Deployment{}
 		if err := render.Bind(r, &d); err != nil {
-			render.Status(r, http.StatusInternalServerError)
+			render.Status(r, http.StatusBadRequest)
 			render.PlainText(w, r, err.Error())
 			return
 		}
 
 		if len(d.TicketId) < 1 {
-			render.Status(r, http.StatusInternalServerError)
+			render.Status(r, http.StatusBadRequest)
 			render.PlainText(w, r, "ticket_id is required")
+			return
 		}
 
-		if err := s.Repository.DeploymentStartUpsert(d.Deployment); err != nil {
+		// the caller can override the maintenance windows with a bearer token of an allowed role
+		user, role := s.requestIdentity(r)
+		override, err := s.windowOverride(r, role)
+		if err != nil {
+			render.Status(r, http.StatusForbidden)
+			render.PlainText(w, r, err.Error())
+			return
+		}
+		if !override {
+			if err := s.Repository.DeploymentAllowed(d.Environment); err != nil {
+				render.Status(r, http.StatusForbidden)
+				render.PlainText(w, r, err.Error())
+				return
+			}
+		}
+
+		d.setInitiator(user)
+		dep, err := s.Repository.DeploymentStartUpsert(d.Deployment)
+		if err != nil {
 			render.Status(r, http.StatusInternalServerError)
 			render.PlainText(w, r, err.Error())
 			return
 		}
+		render.JSON(w, r, dep)
 	})
 
 	r.Post("/finish", func(w http.ResponseWriter, r *http.Request) {
 		d := Deployment{}
 		if err := render.Bind(r, &d); err != nil {
-			render.Status(r, http.StatusInternalServerError)
+			render.Status(r, http.StatusBadRequest)
 			render.PlainText(w, r, err.Error())
 			return
 		}
 
 		if len(d.TicketId) < 1 {
-			render.Status(r, http.StatusInternalServerError)
+			render.Status(r, http.StatusBadRequest)
 			render.PlainText(w, r, "ticket_id is required")
+			return
 		}
 
-		if err := s.Repository.DeploymentUpsert(d.Deployment); err != nil {
+		// deployments registered by the start call already passed the maintenance windows check,
+		// failures are recorded regardless of the windows
+		user, role := s.requestIdentity(r)
+		if d.Status != models.DeploymentFailed && !s.Repository.DeploymentStarted(d.Deployment) {
+			override, err := s.windowOverride(r, role)
+			if err != nil {
+				render.Status(r, http.StatusForbidden)
+				render.PlainText(w, r, err.Error())
+				return
+			}
+			if !override {
+				if err := s.Repository.DeploymentAllowed(d.Environment); err != nil {
+					render.Status(r, http.StatusForbidden)
+					render.PlainText(w, r, err.Error())
+					return
+				}
+			}
+		}
+
+		d.setInitiator(user)
+		dep, err := s.Repository.DeploymentUpsert(d.Deployment)
+		if err != nil {
 			render.Status(r, http.StatusInternalServerError)
 			render.PlainText(w, r, err.Error())
 			return
 		}
+		s.linkCommits(dep)
+		render.JSON(w, r, dep)
 	})
 	return r
 }
 
+// linkCommits stores the git commits between the previous and the deployed tag,
+// errors are logged since the deployment is already recorded
+func (s *HttpServer) linkCommits(dep *models.Deployment) {
+	if s.Git == nil || dep.Status != models.DeploymentSucceeded || dep.Action == models.ActionRollback {
+		return
+	}
+	if len(dep.Tag) < 1 || len(dep.PreviousTag) < 1 || dep.Tag == dep.PreviousTag {
+		return
+	}
+
+	commits, err := s.Git.Commits(dep.ServiceName, dep.PreviousTag, dep.Tag)
+	if err != nil {
+		log.Warnf("Git commits lookup failed for deployment %v %v", dep.Id, err)
+		return
+	}
+
+	if err := s.Repository.DeploymentCommitsUpdate(dep.Id, commits); err == nil {
+		dep.Commits = commits
+		dep.CommitCount = len(commits)
+		dep.Authors = CommitAuthors(commits)
+	}
+}
+
 type Deployment struct {
 	models.Deployment
 }
 
 func (l *Deployment) Bind(r *http.Request) error {
-	// just a post-process after a decode..
+	switch l.Action {
+	case "", models.ActionPromote, models.ActionReload, models.ActionRollback, models.ActionMigrate:
+	default:
+		return fmt.Errorf("Invalid action %v", l.Action)
+	}
+
+	switch l.Status {
+	case "", models.DeploymentRunning, models.DeploymentSucceeded, models.DeploymentFailed:
+	default:
+		return fmt.Errorf("Invalid status %v", l.Status)
+	}
+
 	return nil
 }
+
+// setInitiator prefers the user of the bearer token over the initiator sent by the client
+func (l *Deployment) setInitiator(user string) {
+	if user != "anonymous" {
+		l.Initiator = user
+	}
+}
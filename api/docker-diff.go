@@ -0,0 +1,224 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/stefanprodan/syros/models"
+)
+
+// DiffEnvironments matches the running containers of two environments by service name,
+// the deployctl archived containers (-previous and -purge) are ignored
+func DiffEnvironments(from string, to string, fromContainers []models.DockerContainer, toContainers []models.DockerContainer) models.EnvironmentDiff {
+	diff := models.EnvironmentDiff{
+		From:      from,
+		To:        to,
+		Services:  make([]models.ServiceDiff, 0),
+		Generated: time.Now().UTC(),
+	}
+
+	fromServices := groupByService(fromContainers)
+	toServices := groupByService(toContainers)
+
+	names := make([]string, 0)
+	for name := range fromServices {
+		names = append(names, name)
+	}
+	for name := range toServices {
+		if _, ok := fromServices[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		sd := models.ServiceDiff{
+			Service: name,
+			Status:  "same",
+		}
+
+		fc, inFrom := fromServices[name]
+		tc, inTo := toServices[name]
+
+		if inFrom {
+			sd.FromImage, sd.FromTags, sd.FromHosts = replicasInfo(fc)
+			sd.FromRestartPolicy = fc[0].RestartPolicy
+		}
+		if inTo {
+			sd.ToImage, sd.ToTags, sd.ToHosts = replicasInfo(tc)
+			sd.ToRestartPolicy = tc[0].RestartPolicy
+		}
+
+		switch {
+		case !inTo:
+			sd.Status = "missing"
+			diff.Missing++
+		case !inFrom:
+			sd.Status = "extra"
+			diff.Extra++
+		default:
+			// a key or port counts as drift if any replica on the other side lacks it
+			fromEnv, fromEnvCommon := replicaSets(fc, func(c models.DockerContainer) []string { return models.EnvKeys(c.Env) })
+			toEnv, toEnvCommon := replicaSets(tc, func(c models.DockerContainer) []string { return models.EnvKeys(c.Env) })
+			sd.EnvOnlyInFrom = difference(fromEnv, toEnvCommon)
+			sd.EnvOnlyInTo = difference(toEnv, fromEnvCommon)
+
+			fromPorts, fromPortsCommon := replicaSets(fc, portBindings)
+			toPorts, toPortsCommon := replicaSets(tc, portBindings)
+			sd.PortsOnlyInFrom = difference(fromPorts, toPortsCommon)
+			sd.PortsOnlyInTo = difference(toPorts, fromPortsCommon)
+
+			if sd.FromImage != sd.ToImage || strings.Join(sd.FromTags, ",") != strings.Join(sd.ToTags, ",") ||
+				len(sd.EnvOnlyInFrom) > 0 || len(sd.EnvOnlyInTo) > 0 ||
+				len(sd.PortsOnlyInFrom) > 0 || len(sd.PortsOnlyInTo) > 0 ||
+				sd.FromRestartPolicy != sd.ToRestartPolicy {
+				sd.Status = "changed"
+				diff.Changed++
+			}
+		}
+
+		diff.Services = append(diff.Services, sd)
+	}
+
+	return diff
+}
+
+// DiffChecklist renders the environment diff as a markdown release checklist
+func DiffChecklist(diff models.EnvironmentDiff) string {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "# Release checklist %v -> %v\n\n", diff.From, diff.To)
+	fmt.Fprintf(&b, "Generated at %v: %v changed, %v missing in %v, %v only in %v\n\n",
+		diff.Generated.Format(time.RFC3339), diff.Changed, diff.Missing, diff.To, diff.Extra, diff.To)
+
+	for _, sd := range diff.Services {
+		switch sd.Status {
+		case "missing":
+			fmt.Fprintf(&b, "- [ ] %v: deploy %v to %v (not running in %v)\n", sd.Service, strings.Join(sd.FromTags, ", "), diff.To, diff.To)
+		case "extra":
+			fmt.Fprintf(&b, "- [ ] %v: running only in %v with %v, confirm it should stay\n", sd.Service, diff.To, strings.Join(sd.ToTags, ", "))
+		case "changed":
+			fmt.Fprintf(&b, "- [ ] %v\n", sd.Service)
+			if sd.FromImage != sd.ToImage {
+				fmt.Fprintf(&b, "  - [ ] image %v -> %v\n", sd.ToImage, sd.FromImage)
+			}
+			if strings.Join(sd.FromTags, ",") != strings.Join(sd.ToTags, ",") {
+				fmt.Fprintf(&b, "  - [ ] promote tag %v -> %v\n", strings.Join(sd.ToTags, ", "), strings.Join(sd.FromTags, ", "))
+			}
+			for _, key := range sd.EnvOnlyInFrom {
+				fmt.Fprintf(&b, "  - [ ] add env %v in %v\n", key, diff.To)
+			}
+			for _, key := range sd.EnvOnlyInTo {
+				fmt.Fprintf(&b, "  - [ ] review env %v present only in %v\n", key, diff.To)
+			}
+			if sd.FromRestartPolicy != sd.ToRestartPolicy {
+				fmt.Fprintf(&b, "  - [ ] restart policy %v -> %v\n", sd.ToRestartPolicy, sd.FromRestartPolicy)
+			}
+			for _, port := range sd.PortsOnlyInFrom {
+				fmt.Fprintf(&b, "  - [ ] bind port %v in %v\n", port, diff.To)
+			}
+			for _, port := range sd.PortsOnlyInTo {
+				fmt.Fprintf(&b, "  - [ ] review port %v bound only in %v\n", port, diff.To)
+			}
+		}
+	}
+
+	return b.String()
+}
+
+func groupByService(containers []models.DockerContainer) map[string][]models.DockerContainer {
+	result := make(map[string][]models.DockerContainer)
+	for _, c := range containers {
+		if c.State != "running" {
+			continue
+		}
+		if strings.HasSuffix(c.Name, "-previous") || strings.HasSuffix(c.Name, "-purge") {
+			continue
+		}
+		result[c.Name] = append(result[c.Name], c)
+	}
+	return result
+}
+
+// replicasInfo returns the distinct image repositories, the distinct tags and the hosts of a service replicas
+func replicasInfo(containers []models.DockerContainer) (string, []string, []string) {
+	images := make([]string, 0)
+	tags := make([]string, 0)
+	hosts := make([]string, 0)
+	for _, c := range containers {
+		ref := models.ParseImageRef(c.Image)
+		if !contains(images, ref.Name()) {
+			images = append(images, ref.Name())
+		}
+		if !contains(tags, ref.Version()) {
+			tags = append(tags, ref.Version())
+		}
+		hosts = append(hosts, c.HostName)
+	}
+	sort.Strings(images)
+	sort.Strings(tags)
+	sort.Strings(hosts)
+	return strings.Join(images, ", "), tags, hosts
+}
+
+// replicaSets returns the values found on any replica and the values found on every replica
+func replicaSets(containers []models.DockerContainer, values func(models.DockerContainer) []string) ([]string, []string) {
+	all := make([]string, 0)
+	common := make([]string, 0)
+	for i, c := range containers {
+		v := values(c)
+		if i == 0 {
+			common = append(common, v...)
+		} else {
+			common = intersection(common, v)
+		}
+		for _, item := range v {
+			if !contains(all, item) {
+				all = append(all, item)
+			}
+		}
+	}
+	return all, common
+}
+
+func portBindings(c models.DockerContainer) []string {
+	result := make([]string, 0, len(c.PortBindings))
+	for port, hostPort := range c.PortBindings {
+		result = append(result, port+"->"+hostPort)
+	}
+	return result
+}
+
+// difference returns the sorted items of a missing from b
+func difference(a []string, b []string) []string {
+	result := make([]string, 0)
+	for _, v := range a {
+		if !contains(b, v) && !contains(result, v) {
+			result = append(result, v)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// intersection returns the items of a also found in b
+func intersection(a []string, b []string) []string {
+	result := make([]string, 0)
+	for _, v := range a {
+		if contains(b, v) {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
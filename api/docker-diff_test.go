@@ -0,0 +1,56 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stefanprodan/syros/models"
+)
+
+func TestDiffEnvironments(t *testing.T) {
+	container := func(name string, host string, image string, env ...string) models.DockerContainer {
+		return models.DockerContainer{Name: name, HostName: host, Image: image, State: "running", Env: env,
+			PortBindings: map[string]string{"80/tcp": "8080"}}
+	}
+
+	from := []models.DockerContainer{
+		container("api", "prod-1", "acme/api:1.2.0", "DB=a", "CACHE=b"),
+		container("api", "prod-2", "acme/api:1.2.0", "DB=a", "CACHE=b"),
+		container("web", "prod-1", "acme/web:2.0.0"),
+		container("worker", "prod-1", "acme/worker:1.0.0"),
+	}
+	to := []models.DockerContainer{
+		container("api", "stg-1", "acme/api:1.2.0", "DB=a", "CACHE=b"),
+		container("api", "stg-2", "acme/api:1.2.0", "DB=a"),
+		container("web", "stg-1", "registry.local/acme/web:2.0.0"),
+		container("worker", "stg-1", "acme/worker:1.0.0"),
+		container("worker", "stg-2", "acme/worker:1.0.0"),
+	}
+	to[4].PortBindings = map[string]string{"80/tcp": "9090"}
+
+	diff := DiffEnvironments("prod", "staging", from, to)
+	if diff.Changed != 3 || len(diff.Services) != 3 {
+		t.Fatalf("Diff %+v, want the 3 services changed", diff)
+	}
+
+	api := diff.Services[0]
+	if api.Status != "changed" || strings.Join(api.EnvOnlyInFrom, ",") != "CACHE" || len(api.EnvOnlyInTo) != 0 {
+		t.Errorf("Service %+v, want CACHE missing on the second replica", api)
+	}
+
+	web := diff.Services[1]
+	if web.Status != "changed" || web.FromImage != "acme/web" || web.ToImage != "registry.local/acme/web" {
+		t.Errorf("Service %+v, want the image repository change with the same tag", web)
+	}
+
+	worker := diff.Services[2]
+	if worker.Status != "changed" || strings.Join(worker.PortsOnlyInFrom, ",") != "80/tcp->8080" ||
+		strings.Join(worker.PortsOnlyInTo, ",") != "80/tcp->9090" {
+		t.Errorf("Service %+v, want the port drift on the second replica", worker)
+	}
+
+	checklist := DiffChecklist(diff)
+	if !strings.Contains(checklist, "image registry.local/acme/web -> acme/web") {
+		t.Errorf("Checklist missing the image change\n%v", checklist)
+	}
+}
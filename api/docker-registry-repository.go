@@ -0,0 +1,80 @@
+package main
+
+import (
+	log "github.com/Sirupsen/logrus"
+	"github.com/stefanprodan/syros/models"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// RegistryImages returns the images of a registry or of all registries if the registry is empty
+func (repo *Repository) RegistryImages(registry string) ([]models.RegistryImage, error) {
+	s := repo.Session.Copy()
+	defer s.Close()
+
+	query := bson.M{}
+	if len(registry) > 0 {
+		query["registry"] = registry
+	}
+
+	c := s.DB(repo.Config.Database).C("registry_images")
+	images := []models.RegistryImage{}
+	err := c.Find(query).Sort("registry", "repository", "-pushed").All(&images)
+	if err != nil {
+		log.Errorf("Repository RegistryImages query failed %v", err)
+		return nil, err
+	}
+
+	return images, nil
+}
+
+// RegistrySummaries returns the collected registries and the registries configured on the Docker hosts
+func (repo *Repository) RegistrySummaries() ([]models.RegistrySummary, error) {
+	images, err := repo.RegistryImages("")
+	if err != nil {
+		return nil, err
+	}
+
+	s := repo.Session.Copy()
+	defer s.Close()
+
+	hosts := []models.DockerHost{}
+	c := s.DB(repo.Config.Database).C("hosts")
+	err = c.Find(nil).Select(bson.M{"registries": 1}).All(&hosts)
+	if err != nil {
+		log.Errorf("Repository RegistrySummaries hosts query failed %v", err)
+		return nil, err
+	}
+
+	index := make(map[string]int)
+	result := make([]models.RegistrySummary, 0)
+	summary := func(registry string) *models.RegistrySummary {
+		if i, found := index[registry]; found {
+			return &result[i]
+		}
+		result = append(result, models.RegistrySummary{Registry: registry})
+		index[registry] = len(result) - 1
+		return &result[len(result)-1]
+	}
+
+	repos := make(map[string]bool)
+	for _, image := range images {
+		sum := summary(image.Registry)
+		sum.Tags++
+		sum.Size += image.Size
+		if !repos[image.Registry+"/"+image.Repository] {
+			repos[image.Registry+"/"+image.Repository] = true
+			sum.Repositories++
+		}
+		if image.Collected.After(sum.Collected) {
+			sum.Collected = image.Collected
+		}
+	}
+
+	for _, host := range hosts {
+		for _, registry := range host.Registries {
+			summary(registry).Hosts++
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/jwtauth"
+	"github.com/go-chi/render"
+	"github.com/stefanprodan/syros/models"
+)
+
+func (s *HttpServer) registryRoutes() chi.Router {
+	r := chi.NewRouter()
+
+	// JWT protected
+	r.Group(func(r chi.Router) {
+		r.Use(jwtauth.Verifier(s.TokenAuth))
+		r.Use(jwtauth.Authenticator)
+
+		r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+			registries, err := s.Repository.RegistrySummaries()
+			if err != nil {
+				render.Status(r, http.StatusInternalServerError)
+				render.PlainText(w, r, err.Error())
+				return
+			}
+			render.JSON(w, r, registries)
+		})
+
+		r.Get("/images", func(w http.ResponseWriter, r *http.Request) {
+			usage, err := s.registryUsage(r)
+			if err != nil {
+				render.Status(r, http.StatusInternalServerError)
+				render.PlainText(w, r, err.Error())
+				return
+			}
+
+			status := r.URL.Query().Get("status")
+			if status != "used" && status != "unused" {
+				render.JSON(w, r, usage)
+				return
+			}
+
+			result := make([]models.RegistryImageUsage, 0)
+			for _, u := range usage {
+				if u.InUse == (status == "used") {
+					result = append(result, u)
+				}
+			}
+			render.JSON(w, r, result)
+		})
+
+		r.Get("/cleanup", func(w http.ResponseWriter, r *http.Request) {
+			candidates, err := s.registryCleanup(r)
+			if err != nil {
+				render.Status(r, http.StatusBadRequest)
+				render.PlainText(w, r, err.Error())
+				return
+			}
+			render.JSON(w, r, candidates)
+		})
+
+		r.Get("/cleanup/export", func(w http.ResponseWriter, r *http.Request) {
+			candidates, err := s.registryCleanup(r)
+			if err != nil {
+				render.Status(r, http.StatusBadRequest)
+				render.PlainText(w, r, err.Error())
+				return
+			}
+
+			w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+			w.Header().Set("Content-Disposition", "attachment; filename=registry_cleanup.csv")
+			cw := csv.NewWriter(w)
+			cw.Write([]string{"registry", "repository", "tag", "digest", "size", "pushed", "reason"})
+			for _, c := range candidates {
+				cw.Write([]string{
+					c.Registry,
+					c.Repository,
+					c.Tag,
+					c.Digest,
+					strconv.FormatInt(c.Size, 10),
+					c.Pushed.Format(time.RFC3339),
+					c.Reason,
+				})
+			}
+			cw.Flush()
+		})
+	})
+
+	return r
+}
+
+func (s *HttpServer) registryUsage(r *http.Request) ([]models.RegistryImageUsage, error) {
+	images, err := s.Repository.RegistryImages(r.URL.Query().Get("registry"))
+	if err != nil {
+		return nil, err
+	}
+
+	containers, _, err := s.Repository.AllContainers(ListQuery{Sort: "name"})
+	if err != nil {
+		return nil, err
+	}
+
+	return RegistryUsage(images, containers), nil
+}
+
+// registryCleanup reads keep (default 3) and age (default 30d) from the query string
+func (s *HttpServer) registryCleanup(r *http.Request) ([]models.RegistryCleanupCandidate, error) {
+	q := r.URL.Query()
+	keep := 3
+	if v := q.Get("keep"); len(v) > 0 {
+		k, err := strconv.Atoi(v)
+		if err != nil || k < 0 {
+			return nil, fmt.Errorf("Invalid keep %v", v)
+		}
+		keep = k
+	}
+
+	age := "30d"
+	if v := q.Get("age"); len(v) > 0 {
+		age = v
+	}
+	minAge, err := ParseDeliveryWindow(age)
+	if err != nil {
+		return nil, err
+	}
+
+	usage, err := s.registryUsage(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return RegistryCleanup(usage, keep, minAge, time.Now().UTC()), nil
+}
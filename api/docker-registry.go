@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/stefanprodan/syros/models"
+)
+
+// RegistryUsage cross-references the registry images with the containers,
+// a tag is in use if a container was created from it by tag or by digest
+func RegistryUsage(images []models.RegistryImage, containers []models.DockerContainer) []models.RegistryImageUsage {
+	byTag := make(map[string][]models.DockerContainer)
+	byDigest := make(map[string][]models.DockerContainer)
+	for _, c := range containers {
+		ref := models.ParseImageRef(c.Image)
+		if len(ref.Digest) > 0 {
+			byDigest[ref.Digest] = append(byDigest[ref.Digest], c)
+		}
+		if len(ref.Tag) > 0 {
+			key := ref.Registry + "/" + ref.Repository + ":" + ref.Tag
+			byTag[key] = append(byTag[key], c)
+		}
+	}
+
+	result := make([]models.RegistryImageUsage, 0, len(images))
+	for _, image := range images {
+		usage := models.RegistryImageUsage{
+			RegistryImage: image,
+			Environments:  make([]string, 0),
+		}
+
+		used := byTag[image.Registry+"/"+image.Repository+":"+image.Tag]
+		if len(image.Digest) > 0 {
+			used = append(used, byDigest[image.Digest]...)
+		}
+		for _, c := range used {
+			usage.Containers++
+			if !contains(usage.Environments, c.Environment) {
+				usage.Environments = append(usage.Environments, c.Environment)
+			}
+			if c.Collected.After(usage.LastUsed) {
+				usage.LastUsed = c.Collected
+			}
+		}
+		usage.InUse = usage.Containers > 0
+		sort.Strings(usage.Environments)
+
+		result = append(result, usage)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Registry+result[i].Repository == result[j].Registry+result[j].Repository {
+			return result[i].Pushed.After(result[j].Pushed)
+		}
+		return result[i].Registry+result[i].Repository < result[j].Registry+result[j].Repository
+	})
+
+	return result
+}
+
+// RegistryCleanup returns the tags that are safe to delete, a tag is kept if it's in use,
+// shares its digest with a tag in use, is the latest tag, is one of the newest tags
+// of its repository or was pushed recently or at an unknown date
+func RegistryCleanup(usage []models.RegistryImageUsage, keep int, minAge time.Duration, now time.Time) []models.RegistryCleanupCandidate {
+	usedDigests := make(map[string]bool)
+	for _, u := range usage {
+		if u.InUse && len(u.Digest) > 0 {
+			usedDigests[u.Digest] = true
+		}
+	}
+
+	// usage is sorted by repository and push date desc
+	newer := make(map[string]int)
+	result := make([]models.RegistryCleanupCandidate, 0)
+	for _, u := range usage {
+		repo := u.Registry + "/" + u.Repository
+		rank := newer[repo]
+		newer[repo]++
+
+		if u.InUse || usedDigests[u.Digest] || u.Tag == "latest" || rank < keep {
+			continue
+		}
+		if u.Pushed.IsZero() || now.Sub(u.Pushed) < minAge {
+			continue
+		}
+
+		result = append(result, models.RegistryCleanupCandidate{
+			RegistryImage: u.RegistryImage,
+			Reason: fmt.Sprintf("unused, pushed %v days ago, %v newer tags",
+				int(now.Sub(u.Pushed).Hours()/24), rank),
+		})
+	}
+
+	return result
+}
@@ -55,19 +55,15 @@ func (repo *Repository) EnvironmentHostContainerSum() ([]models.EnvironmentStats
 	return environments, nil
 }
 
-func (repo *Repository) AllHosts() ([]models.DockerHost, error) {
-	s := repo.Session.Copy()
-	defer s.Close()
-
-	c := s.DB(repo.Config.Database).C("hosts")
+func (repo *Repository) AllHosts(query ListQuery) ([]models.DockerHost, int, error) {
 	hosts := []models.DockerHost{}
-	err := c.Find(nil).Sort("-collected").All(&hosts)
+	total, err := repo.list("hosts", query, &hosts)
 	if err != nil {
 		log.Errorf("Repository AllHosts cursor failed %v", err)
-		return nil, err
+		return nil, 0, err
 	}
 
-	return hosts, nil
+	return hosts, total, nil
 }
 
 func (repo *Repository) HostContainers(hostID string) (*models.DockerPayload, error) {
@@ -135,21 +131,38 @@ func (repo *Repository) EnvironmentContainers(env string) (*models.DockerPayload
 	return payload, nil
 }
 
-func (repo *Repository) AllContainers() ([]models.DockerContainer, error) {
+// RunningContainers returns the running containers of an environment or of all environments if env is empty
+func (repo *Repository) RunningContainers(env string) ([]models.DockerContainer, error) {
 	s := repo.Session.Copy()
 	defer s.Close()
 
+	query := bson.M{"state": "running"}
+	if len(env) > 0 {
+		query["environment"] = env
+	}
+
 	c := s.DB(repo.Config.Database).C("containers")
 	containers := []models.DockerContainer{}
-	err := c.Find(nil).Sort("-collected").All(&containers)
+	err := c.Find(query).Sort("name", "host_name").All(&containers)
 	if err != nil {
-		log.Errorf("Repository AllContainers query failed %v", err)
+		log.Errorf("Repository RunningContainers query for env %v failed %v", env, err)
 		return nil, err
 	}
 
 	return containers, nil
 }
 
+func (repo *Repository) AllContainers(query ListQuery) ([]models.DockerContainer, int, error) {
+	containers := []models.DockerContainer{}
+	total, err := repo.list("containers", query, &containers)
+	if err != nil {
+		log.Errorf("Repository AllContainers query failed %v", err)
+		return nil, 0, err
+	}
+
+	return containers, total, nil
+}
+
 func (repo *Repository) Container(containerID string) (*models.DockerPayload, error) {
 	s := repo.Session.Copy()
 	defer s.Close()
@@ -180,3 +193,23 @@ func (repo *Repository) Container(containerID string) (*models.DockerPayload, er
 
 	return payload, nil
 }
+
+// DockerNameExists checks if the host or container name was collected by the Docker collector
+func (repo *Repository) DockerNameExists(target string, name string) (bool, error) {
+	s := repo.Session.Copy()
+	defer s.Close()
+
+	col, query := "hosts", bson.M{"name": name}
+	if target == "container" {
+		col = "containers"
+		query = bson.M{"name": bson.M{"$in": []string{name, "/" + name}}}
+	}
+
+	count, err := s.DB(repo.Config.Database).C(col).Find(query).Count()
+	if err != nil {
+		log.Errorf("Repository DockerNameExists %v query failed for %v %v", col, name, err)
+		return false, err
+	}
+
+	return count > 0, nil
+}
@@ -1,7 +1,9 @@
 package main
 
 import (
+	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/go-chi/chi"
 	"github.com/go-chi/jwtauth"
@@ -18,12 +20,20 @@ func (s *HttpServer) dockerRoutes() chi.Router {
 		r.Use(jwtauth.Authenticator)
 
 		r.Get("/hosts", func(w http.ResponseWriter, r *http.Request) {
-			hosts, err := s.Repository.AllHosts()
+			query, err := ParseListQuery(r, hostsListSpec, s.Config.PageSize)
+			if err != nil {
+				render.Status(r, http.StatusBadRequest)
+				render.PlainText(w, r, err.Error())
+				return
+			}
+
+			hosts, total, err := s.Repository.AllHosts(query)
 			if err != nil {
 				render.Status(r, http.StatusInternalServerError)
 				render.PlainText(w, r, err.Error())
 				return
 			}
+			setPageHeaders(w, r, query, total)
 			render.JSON(w, r, hosts)
 		})
 
@@ -39,6 +49,40 @@ func (s *HttpServer) dockerRoutes() chi.Router {
 			render.JSON(w, r, payload)
 		})
 
+		r.Get("/environments/diff", func(w http.ResponseWriter, r *http.Request) {
+			from := r.URL.Query().Get("from")
+			to := r.URL.Query().Get("to")
+			if len(from) < 1 || len(to) < 1 {
+				render.Status(r, http.StatusBadRequest)
+				render.PlainText(w, r, "from and to environments are required")
+				return
+			}
+
+			fromContainers, err := s.Repository.RunningContainers(from)
+			if err != nil {
+				render.Status(r, http.StatusInternalServerError)
+				render.PlainText(w, r, err.Error())
+				return
+			}
+			toContainers, err := s.Repository.RunningContainers(to)
+			if err != nil {
+				render.Status(r, http.StatusInternalServerError)
+				render.PlainText(w, r, err.Error())
+				return
+			}
+
+			diff := DiffEnvironments(from, to, fromContainers, toContainers)
+
+			switch r.URL.Query().Get("format") {
+			case "checklist":
+				w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+				w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=checklist-%v-%v.md", from, to))
+				w.Write([]byte(DiffChecklist(diff)))
+			default:
+				render.JSON(w, r, diff)
+			}
+		})
+
 		r.Get("/environments/{env}", func(w http.ResponseWriter, r *http.Request) {
 			env := chi.URLParam(r, "env")
 
@@ -75,21 +119,38 @@ func (s *HttpServer) dockerRoutes() chi.Router {
 				}
 			}
 
+			stats, err := s.Repository.LogStats(env, time.Now().UTC().Add(-s.Kibana.Range))
+			if err != nil {
+				render.Status(r, http.StatusInternalServerError)
+				render.PlainText(w, r, err.Error())
+				return
+			}
+
 			result := models.EnvironmentDto{
 				Host:        payload.Host,
 				Containers:  payload.Containers,
 				Deployments: deployments,
+				Logs:        JoinLogStats(payload.Containers, stats, s.Kibana),
+				LogLevels:   LogLevelsChart(stats),
 			}
 			render.JSON(w, r, result)
 		})
 
 		r.Get("/containers", func(w http.ResponseWriter, r *http.Request) {
-			containers, err := s.Repository.AllContainers()
+			query, err := ParseListQuery(r, containersListSpec, s.Config.PageSize)
+			if err != nil {
+				render.Status(r, http.StatusBadRequest)
+				render.PlainText(w, r, err.Error())
+				return
+			}
+
+			containers, total, err := s.Repository.AllContainers(query)
 			if err != nil {
 				render.Status(r, http.StatusInternalServerError)
 				render.PlainText(w, r, err.Error())
 				return
 			}
+			setPageHeaders(w, r, query, total)
 			render.JSON(w, r, containers)
 		})
 
@@ -102,7 +163,20 @@ func (s *HttpServer) dockerRoutes() chi.Router {
 				render.PlainText(w, r, err.Error())
 				return
 			}
-			render.JSON(w, r, payload)
+
+			stats, err := s.Repository.LogStats(payload.Host.Environment, time.Now().UTC().Add(-s.Kibana.Range))
+			if err != nil {
+				render.Status(r, http.StatusInternalServerError)
+				render.PlainText(w, r, err.Error())
+				return
+			}
+
+			result := models.ContainerDto{
+				Host:       payload.Host,
+				Containers: payload.Containers,
+				Logs:       JoinLogStats(payload.Containers, stats, s.Kibana),
+			}
+			render.JSON(w, r, result)
 		})
 
 	})
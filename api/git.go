@@ -0,0 +1,224 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stefanprodan/syros/models"
+)
+
+// maxCommits caps the number of commits stored on a deployment
+const maxCommits = 500
+
+// gitTimeout caps each git command, the lookups run while the deployment finish call waits
+const gitTimeout = 10 * time.Second
+
+// GitLog reads the commit history of a service from a local bare clone or mirror,
+// the repository of a service is <path>/<service>.git or <path>/<service>
+type GitLog struct {
+	Path    string
+	Timeout time.Duration
+}
+
+func NewGitLog(path string) *GitLog {
+	return &GitLog{
+		Path:    path,
+		Timeout: gitTimeout,
+	}
+}
+
+// Commits returns the commits reachable from the new tag and not from the previous tag,
+// image tags are matched with git tags as is or with the v prefix
+func (g *GitLog) Commits(service string, previousTag string, tag string) ([]models.GitCommit, error) {
+	repo, err := g.repository(service)
+	if err != nil {
+		return nil, err
+	}
+
+	from, err := g.resolve(repo, previousTag)
+	if err != nil {
+		return nil, err
+	}
+	to, err := g.resolve(repo, tag)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := g.git(repo, "log", "--max-count="+strconv.Itoa(maxCommits),
+		"--format=%H%x1f%an%x1f%ae%x1f%at%x1f%s", from+".."+to)
+	if err != nil {
+		return nil, err
+	}
+
+	commits := make([]models.GitCommit, 0)
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Split(line, "\x1f")
+		if len(fields) < 5 {
+			continue
+		}
+		ts, _ := strconv.ParseInt(fields[3], 10, 64)
+		commits = append(commits, models.GitCommit{
+			Hash:    fields[0],
+			Author:  fields[1],
+			Email:   fields[2],
+			Date:    time.Unix(ts, 0).UTC(),
+			Subject: fields[4],
+		})
+	}
+
+	return commits, nil
+}
+
+// CommitAuthors returns the distinct authors of the commits
+func CommitAuthors(commits []models.GitCommit) []string {
+	authors := make([]string, 0)
+	for _, c := range commits {
+		if !contains(authors, c.Author) {
+			authors = append(authors, c.Author)
+		}
+	}
+	return authors
+}
+
+// BuildChangelog merges the commits of the release deployments per service and environment,
+// the range goes from the first previous tag to the last deployed tag
+func BuildChangelog(release models.Release, list []models.Deployment) models.ReleaseChangelog {
+	changelog := models.ReleaseChangelog{
+		ReleaseId: release.Id,
+		TicketId:  release.TicketId,
+		Services:  make([]models.ServiceChangelog, 0),
+	}
+
+	// sort a copy, the caller owns the slice
+	deployments := make([]models.Deployment, len(list))
+	copy(deployments, list)
+	sort.Slice(deployments, func(i, j int) bool {
+		return deployments[i].Begin.Before(deployments[j].Begin)
+	})
+
+	index := make(map[string]int)
+	hashes := make(map[string][]string)
+	for _, dep := range deployments {
+		if dep.Action == models.ActionRollback || dep.Status == models.DeploymentFailed {
+			continue
+		}
+
+		key := dep.ServiceName + "/" + dep.Environment
+		idx, found := index[key]
+		if !found {
+			changelog.Services = append(changelog.Services, models.ServiceChangelog{
+				Service:     dep.ServiceName,
+				Environment: dep.Environment,
+				FromTag:     dep.PreviousTag,
+				Authors:     make([]string, 0),
+				Commits:     make([]models.GitCommit, 0),
+			})
+			idx = len(changelog.Services) - 1
+			index[key] = idx
+		}
+
+		svc := &changelog.Services[idx]
+		if len(dep.Tag) > 0 {
+			svc.ToTag = dep.Tag
+		}
+		for _, c := range dep.Commits {
+			if contains(hashes[key], c.Hash) {
+				continue
+			}
+			hashes[key] = append(hashes[key], c.Hash)
+			svc.Commits = append(svc.Commits, c)
+			if !contains(svc.Authors, c.Author) {
+				svc.Authors = append(svc.Authors, c.Author)
+			}
+		}
+	}
+
+	for _, svc := range changelog.Services {
+		changelog.Commits += len(svc.Commits)
+	}
+
+	return changelog
+}
+
+func (g *GitLog) repository(service string) (string, error) {
+	// the service name comes from the deployment payload and must not leave the git dir
+	if len(service) < 1 || strings.ContainsAny(service, "/\\") || strings.Contains(service, "..") {
+		return "", fmt.Errorf("Invalid service name %v", service)
+	}
+
+	// bare clones and mirrors first then the .git dir of a working copy
+	for _, dir := range []string{service + ".git", service, filepath.Join(service, ".git")} {
+		path := filepath.Join(g.Path, dir)
+		if _, err := os.Stat(filepath.Join(path, "HEAD")); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("Git repository not found for %v in %v", service, g.Path)
+}
+
+func (g *GitLog) resolve(repo string, tag string) (string, error) {
+	if len(tag) < 1 {
+		return "", fmt.Errorf("Git tag is empty")
+	}
+	if strings.HasPrefix(tag, "-") {
+		return "", fmt.Errorf("Invalid git tag %v", tag)
+	}
+
+	for _, ref := range []string{"refs/tags/" + tag, "refs/tags/v" + tag, tag} {
+		hash, err := g.git(repo, "rev-parse", "--verify", "--quiet", ref+"^{commit}")
+		if err == nil && len(hash) > 0 {
+			return hash, nil
+		}
+	}
+
+	return "", fmt.Errorf("Git tag %v not found in %v", tag, repo)
+}
+
+func (g *GitLog) git(repo string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), g.Timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", append([]string{"--git-dir=" + repo}, args...)...)
+	out, err := cmd.Output()
+	if ctx.Err() == context.DeadlineExceeded {
+		return "", fmt.Errorf("git %v timed out after %v in %v", args[0], g.Timeout, repo)
+	}
+	if err != nil {
+		return "", errors.Wrapf(err, "git %v failed in %v", args[0], repo)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// ChangelogMarkdown renders the changelog as a markdown list per service
+func ChangelogMarkdown(changelog models.ReleaseChangelog) string {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "# Changelog %v\n\n", changelog.TicketId)
+	for _, svc := range changelog.Services {
+		fmt.Fprintf(&b, "## %v on %v (%v -> %v)\n\n", svc.Service, svc.Environment, svc.FromTag, svc.ToTag)
+		if len(svc.Commits) < 1 {
+			b.WriteString("No commits linked\n\n")
+			continue
+		}
+		for _, c := range svc.Commits {
+			fmt.Fprintf(&b, "- %v %v (%v)\n", shortHash(c.Hash), c.Subject, c.Author)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func shortHash(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
+}
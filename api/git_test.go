@@ -0,0 +1,143 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stefanprodan/syros/models"
+)
+
+// newTestGitRepo creates the bare clone <dir>/api.git with the tags v1.0.0 and 1.1.0,
+// the three commits after v1.0.0 are made by two authors
+func newTestGitRepo(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "syros-git")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	work := filepath.Join(dir, "work")
+	run := func(author string, args ...string) {
+		cmd := exec.Command("git", append([]string{"-c", "commit.gpgsign=false", "-c", "tag.gpgsign=false"}, args...)...)
+		cmd.Dir = work
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME="+author, "GIT_AUTHOR_EMAIL="+author+"@example.com",
+			"GIT_COMMITTER_NAME="+author, "GIT_COMMITTER_EMAIL="+author+"@example.com")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed %v %s", args, err, out)
+		}
+	}
+
+	if err := os.MkdirAll(work, 0755); err != nil {
+		t.Fatal(err)
+	}
+	run("alice", "init", "-q")
+	run("alice", "commit", "-q", "--allow-empty", "-m", "initial import")
+	run("alice", "tag", "v1.0.0")
+	run("bob", "commit", "-q", "--allow-empty", "-m", "add health check")
+	run("alice", "commit", "-q", "--allow-empty", "-m", "fix timeout")
+	run("bob", "commit", "-q", "--allow-empty", "-m", "bump version")
+	run("bob", "tag", "1.1.0")
+	run("alice", "clone", "-q", "--bare", work, filepath.Join(dir, "api.git"))
+
+	return dir
+}
+
+func TestGitLogCommits(t *testing.T) {
+	dir := newTestGitRepo(t)
+	defer os.RemoveAll(dir)
+
+	g := NewGitLog(dir)
+	commits, err := g.Commits("api", "1.0.0", "1.1.0")
+	if err != nil {
+		t.Fatalf("Commits failed %v", err)
+	}
+
+	if len(commits) != 3 {
+		t.Fatalf("Commits %+v, want the 3 commits between the tags", commits)
+	}
+	subjects := []string{"bump version", "fix timeout", "add health check"}
+	for i, c := range commits {
+		if c.Subject != subjects[i] || len(c.Hash) != 40 || c.Date.IsZero() {
+			t.Errorf("Commit %v %+v, want %v", i, c, subjects[i])
+		}
+	}
+	if commits[0].Author != "bob" || commits[0].Email != "bob@example.com" {
+		t.Errorf("Commit author %v %v, want bob", commits[0].Author, commits[0].Email)
+	}
+
+	authors := CommitAuthors(commits)
+	if len(authors) != 2 || authors[0] != "bob" || authors[1] != "alice" {
+		t.Errorf("Authors %v, want bob and alice", authors)
+	}
+
+	if _, err := g.Commits("api", "1.0.0", "2.0.0"); err == nil {
+		t.Error("Commits should fail for a missing tag")
+	}
+	if _, err := g.Commits("api", "1.0.0", "--all"); err == nil {
+		t.Error("Commits should reject tags starting with a dash")
+	}
+}
+
+func TestGitLogRepository(t *testing.T) {
+	dir := newTestGitRepo(t)
+	defer os.RemoveAll(dir)
+
+	// ../api would resolve to the bare clone next to the git dir
+	g := NewGitLog(filepath.Join(dir, "work"))
+
+	for _, service := range []string{"", "../api", "..", "a/b", `a\b`, "/etc"} {
+		if _, err := g.Commits(service, "1.0.0", "1.1.0"); err == nil || !strings.Contains(err.Error(), "Invalid service name") {
+			t.Errorf("Service %q error %v, want invalid service name", service, err)
+		}
+	}
+
+	if _, err := NewGitLog(dir).Commits("web", "1.0.0", "1.1.0"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("Missing repository error %v", err)
+	}
+}
+
+func TestGitLogTimeout(t *testing.T) {
+	dir := newTestGitRepo(t)
+	defer os.RemoveAll(dir)
+
+	g := NewGitLog(dir)
+	g.Timeout = time.Nanosecond
+	if _, err := g.git(filepath.Join(dir, "api.git"), "log"); err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("Git error %v, want timed out", err)
+	}
+}
+
+func TestBuildChangelog(t *testing.T) {
+	now := time.Now()
+	commit := func(hash string, author string) models.GitCommit {
+		return models.GitCommit{Hash: hash, Author: author}
+	}
+	deployments := []models.Deployment{
+		{ServiceName: "api", Environment: "prod", Tag: "1.2.0", PreviousTag: "1.1.0", Begin: now.Add(time.Hour),
+			Status: models.DeploymentSucceeded, Commits: []models.GitCommit{commit("c3", "bob"), commit("c2", "alice")}},
+		{ServiceName: "api", Environment: "prod", Tag: "1.1.0", PreviousTag: "1.0.0", Begin: now,
+			Status: models.DeploymentSucceeded, Commits: []models.GitCommit{commit("c2", "alice"), commit("c1", "alice")}},
+		{ServiceName: "api", Environment: "prod", Tag: "1.3.0", PreviousTag: "1.2.0", Begin: now.Add(2 * time.Hour),
+			Status: models.DeploymentFailed, Commits: []models.GitCommit{commit("c4", "bob")}},
+	}
+
+	changelog := BuildChangelog(models.Release{Id: "r1", TicketId: "T-1"}, deployments)
+	if deployments[0].Tag != "1.2.0" {
+		t.Error("BuildChangelog shouldn't reorder the caller's slice")
+	}
+	if len(changelog.Services) != 1 || changelog.Commits != 3 {
+		t.Fatalf("Changelog %+v, want one service with 3 commits", changelog)
+	}
+	svc := changelog.Services[0]
+	if svc.FromTag != "1.0.0" || svc.ToTag != "1.2.0" {
+		t.Errorf("Range %v -> %v, want 1.0.0 -> 1.2.0", svc.FromTag, svc.ToTag)
+	}
+	if len(svc.Authors) != 2 {
+		t.Errorf("Authors %v, want alice and bob", svc.Authors)
+	}
+}
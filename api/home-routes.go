@@ -25,7 +25,7 @@ func (s *HttpServer) homeRoutes() chi.Router {
 				render.PlainText(w, r, err.Error())
 				return
 			}
-			vsphere, err := s.Repository.AllVSphere()
+			vsphere, _, err := s.Repository.AllVSphere(ListQuery{Sort: vmsListSpec.DefaultSort})
 			if err != nil {
 				render.Status(r, http.StatusInternalServerError)
 				render.PlainText(w, r, err.Error())
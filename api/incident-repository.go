@@ -0,0 +1,123 @@
+package main
+
+import (
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/stefanprodan/syros/models"
+	"gopkg.in/mgo.v2/bson"
+)
+
+type IncidentQuery struct {
+	Status  string
+	Service string
+	Urgency string
+	From    time.Time
+	To      time.Time
+	Limit   int
+}
+
+func (q IncidentQuery) selector() bson.M {
+	sel := bson.M{}
+	if len(q.Status) > 0 {
+		sel["status"] = q.Status
+	}
+	if len(q.Service) > 0 {
+		sel["service"] = q.Service
+	}
+	if len(q.Urgency) > 0 {
+		sel["urgency"] = q.Urgency
+	}
+
+	ts := bson.M{}
+	if !q.From.IsZero() {
+		ts["$gte"] = q.From
+	}
+	if !q.To.IsZero() {
+		ts["$lt"] = q.To
+	}
+	if len(ts) > 0 {
+		sel["created"] = ts
+	}
+
+	return sel
+}
+
+func (repo *Repository) Incidents(query IncidentQuery) ([]models.Incident, error) {
+	s := repo.Session.Copy()
+	defer s.Close()
+
+	c := s.DB(repo.Config.Database).C("incidents")
+	incidents := []models.Incident{}
+	err := c.Find(query.selector()).Sort("-created").Limit(query.Limit).All(&incidents)
+	if err != nil {
+		log.Errorf("Repository Incidents query failed %v", err)
+		return nil, err
+	}
+
+	return incidents, nil
+}
+
+// ActiveIncidents returns the open incidents and the ones opened or resolved after since
+func (repo *Repository) ActiveIncidents(since time.Time) ([]models.Incident, error) {
+	s := repo.Session.Copy()
+	defer s.Close()
+
+	c := s.DB(repo.Config.Database).C("incidents")
+	incidents := []models.Incident{}
+	err := c.Find(bson.M{"$or": []bson.M{
+		{"status": bson.M{"$ne": models.IncidentResolved}},
+		{"created": bson.M{"$gte": since}},
+		{"resolved": bson.M{"$gte": since}},
+	}}).Sort("-created").All(&incidents)
+	if err != nil {
+		log.Errorf("Repository ActiveIncidents query failed %v", err)
+		return nil, err
+	}
+
+	return incidents, nil
+}
+
+func (repo *Repository) OnCalls() ([]models.OnCall, error) {
+	s := repo.Session.Copy()
+	defer s.Close()
+
+	c := s.DB(repo.Config.Database).C("oncalls")
+	oncalls := []models.OnCall{}
+	err := c.Find(nil).Sort("escalation_policy", "level", "user").All(&oncalls)
+	if err != nil {
+		log.Errorf("Repository OnCalls query failed %v", err)
+		return nil, err
+	}
+
+	return oncalls, nil
+}
+
+func (repo *Repository) HandoverUpsert(handover models.Handover) error {
+	s := repo.Session.Copy()
+	defer s.Close()
+
+	c := s.DB(repo.Config.Database).C("handovers")
+	_, err := c.UpsertId(handover.Id, &handover)
+	if err != nil {
+		log.Errorf("Repository HandoverUpsert failed for %v %v", handover.Id, err)
+		return err
+	}
+
+	return nil
+}
+
+func (repo *Repository) Handovers(limit int) ([]models.Handover, error) {
+	s := repo.Session.Copy()
+	defer s.Close()
+
+	c := s.DB(repo.Config.Database).C("handovers")
+	handovers := []models.Handover{}
+	err := c.Find(nil).Sort("-timestamp").Limit(limit).All(&handovers)
+	if err != nil {
+		log.Errorf("Repository Handovers query failed %v", err)
+		return nil, err
+	}
+
+	return handovers, nil
+}
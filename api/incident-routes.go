@@ -0,0 +1,224 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/go-chi/chi"
+	"github.com/go-chi/jwtauth"
+	"github.com/go-chi/render"
+	"github.com/stefanprodan/syros/models"
+)
+
+func (s *HttpServer) incidentRoutes() chi.Router {
+	r := chi.NewRouter()
+
+	// JWT protected
+	r.Group(func(r chi.Router) {
+		r.Use(jwtauth.Verifier(s.TokenAuth))
+		r.Use(jwtauth.Authenticator)
+
+		r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+			query, err := parseIncidentQuery(r)
+			if err != nil {
+				render.Status(r, http.StatusBadRequest)
+				render.PlainText(w, r, err.Error())
+				return
+			}
+
+			incidents, err := s.Repository.Incidents(query)
+			if err != nil {
+				render.Status(r, http.StatusInternalServerError)
+				render.PlainText(w, r, err.Error())
+				return
+			}
+			render.JSON(w, r, incidents)
+		})
+
+		r.Get("/stats", func(w http.ResponseWriter, r *http.Request) {
+			now := time.Now().UTC()
+			incidents, err := s.Repository.ActiveIncidents(now.Add(-30 * 24 * time.Hour))
+			if err != nil {
+				render.Status(r, http.StatusInternalServerError)
+				render.PlainText(w, r, err.Error())
+				return
+			}
+			render.JSON(w, r, IncidentStats(incidents, now))
+		})
+
+		r.Get("/oncall", func(w http.ResponseWriter, r *http.Request) {
+			oncalls, err := s.Repository.OnCalls()
+			if err != nil {
+				render.Status(r, http.StatusInternalServerError)
+				render.PlainText(w, r, err.Error())
+				return
+			}
+			render.JSON(w, r, oncalls)
+		})
+
+		r.Get("/report/{month}", func(w http.ResponseWriter, r *http.Request) {
+			month := chi.URLParam(r, "month")
+			from, to, err := ParseReportMonth(month)
+			if err != nil {
+				render.Status(r, http.StatusBadRequest)
+				render.PlainText(w, r, err.Error())
+				return
+			}
+
+			incidents, err := s.Repository.Incidents(IncidentQuery{From: from, To: to})
+			if err != nil {
+				render.Status(r, http.StatusInternalServerError)
+				render.PlainText(w, r, err.Error())
+				return
+			}
+
+			report := MonthlyReport(month, from, to, incidents)
+			if r.URL.Query().Get("format") == "markdown" {
+				w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+				w.Write([]byte(IncidentReportMarkdown(report)))
+				return
+			}
+			render.JSON(w, r, report)
+		})
+
+		r.Get("/handover", func(w http.ResponseWriter, r *http.Request) {
+			limit := 100
+			if v := r.URL.Query().Get("limit"); len(v) > 0 {
+				l, err := strconv.Atoi(v)
+				if err != nil || l < 1 {
+					render.Status(r, http.StatusBadRequest)
+					render.PlainText(w, r, "Invalid limit "+v)
+					return
+				}
+				limit = l
+			}
+
+			handovers, err := s.Repository.Handovers(limit)
+			if err != nil {
+				render.Status(r, http.StatusInternalServerError)
+				render.PlainText(w, r, err.Error())
+				return
+			}
+			render.JSON(w, r, handovers)
+		})
+
+		r.Post("/handover", func(w http.ResponseWriter, r *http.Request) {
+			data := HandoverForm{}
+			if err := render.Bind(r, &data); err != nil {
+				render.Status(r, http.StatusBadRequest)
+				render.PlainText(w, r, err.Error())
+				return
+			}
+
+			handover := data.Handover
+			handover.Id, _ = models.NewUUID()
+			handover.CreatedBy = requestUser(r)
+			handover.Timestamp = time.Now().UTC()
+			// the handover goes only to the on-call team, recipients from the request are ignored
+			handover.To = s.Config.OnCallMail
+
+			// the open incidents are attached if the form doesn't list them
+			if len(handover.OpenIncidents) < 1 {
+				open, err := s.Repository.Incidents(IncidentQuery{Status: models.IncidentTriggered})
+				if err == nil {
+					ack, _ := s.Repository.Incidents(IncidentQuery{Status: models.IncidentAcknowledged})
+					handover.OpenIncidents = make([]string, 0)
+					for _, i := range append(open, ack...) {
+						handover.OpenIncidents = append(handover.OpenIncidents, "#"+strconv.Itoa(i.Number)+" "+i.Service+" "+i.Title)
+					}
+				}
+			}
+
+			if err := s.Repository.HandoverUpsert(handover); err != nil {
+				render.Status(r, http.StatusInternalServerError)
+				render.PlainText(w, r, err.Error())
+				return
+			}
+
+			// the handover is kept even if the mail fails, the error is saved for the history view
+			if err := s.sendHandover(handover); err != nil {
+				log.Errorf("Handover %v mail failed %v", handover.Id, err)
+				handover.MailError = err.Error()
+			} else {
+				handover.Mailed = true
+			}
+			s.Repository.HandoverUpsert(handover)
+
+			render.Status(r, http.StatusCreated)
+			render.JSON(w, r, handover)
+		})
+	})
+
+	return r
+}
+
+func (s *HttpServer) sendHandover(handover models.Handover) error {
+	if s.Mailer == nil {
+		return errors.New("SMTP is not configured")
+	}
+
+	to := splitAddresses(handover.To)
+	if len(to) < 1 {
+		return errors.New("Handover has no recipients")
+	}
+
+	subject, body := HandoverMail(handover)
+	return s.Mailer.Send(to, subject, body)
+}
+
+// parseIncidentQuery reads the incident filters from the query string,
+// from and to are RFC3339 timestamps and limit defaults to 1000
+func parseIncidentQuery(r *http.Request) (IncidentQuery, error) {
+	q := r.URL.Query()
+	query := IncidentQuery{
+		Status:  q.Get("status"),
+		Service: q.Get("service"),
+		Urgency: q.Get("urgency"),
+		Limit:   1000,
+	}
+
+	if v := q.Get("from"); len(v) > 0 {
+		from, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return query, err
+		}
+		query.From = from.UTC()
+	}
+
+	if v := q.Get("to"); len(v) > 0 {
+		to, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return query, err
+		}
+		query.To = to.UTC()
+	}
+
+	if v := q.Get("limit"); len(v) > 0 {
+		limit, err := strconv.Atoi(v)
+		if err != nil {
+			return query, err
+		}
+		if limit > 0 {
+			query.Limit = limit
+		}
+	}
+
+	return query, nil
+}
+
+type HandoverForm struct {
+	models.Handover
+}
+
+func (p *HandoverForm) Bind(r *http.Request) error {
+	if len(p.From) < 1 {
+		return errors.New("Handover from is required")
+	}
+	if len(p.Summary) < 1 {
+		return errors.New("Handover summary is required")
+	}
+	return nil
+}
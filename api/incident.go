@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/stefanprodan/syros/models"
+)
+
+// IncidentStats counts the incidents opened and resolved in the last 24h and 30 days,
+// MTTA and MTTR are the mean seconds to acknowledge and resolve over the last 30 days
+func IncidentStats(incidents []models.Incident, now time.Time) models.IncidentStats {
+	stats := models.IncidentStats{}
+	day := now.Add(-24 * time.Hour)
+	month := now.Add(-30 * 24 * time.Hour)
+
+	var ackTotal, resTotal time.Duration
+	ackCount, resCount := 0, 0
+	for _, i := range incidents {
+		if i.Status != models.IncidentResolved {
+			stats.Open++
+		}
+		if i.Created.After(day) {
+			stats.Opened24h++
+		}
+		if i.Created.After(month) {
+			stats.Opened30d++
+			if !i.Acknowledged.IsZero() {
+				ackTotal += i.Acknowledged.Sub(i.Created)
+				ackCount++
+			}
+		}
+		if i.Status == models.IncidentResolved && !i.Resolved.IsZero() {
+			if i.Resolved.After(day) {
+				stats.Resolved24h++
+			}
+			if i.Resolved.After(month) {
+				stats.Resolved30d++
+				resTotal += i.Resolved.Sub(i.Created)
+				resCount++
+			}
+		}
+	}
+
+	if ackCount > 0 {
+		stats.MTTA30d = int64(ackTotal/time.Duration(ackCount)) / int64(time.Second)
+	}
+	if resCount > 0 {
+		stats.MTTR30d = int64(resTotal/time.Duration(resCount)) / int64(time.Second)
+	}
+
+	return stats
+}
+
+// ParseReportMonth returns the first day of the month and the first day of the next month,
+// the month format is YYYY-MM
+func ParseReportMonth(month string) (time.Time, time.Time, error) {
+	from, err := time.Parse("2006-01", month)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("Invalid month %v format YYYY-MM", month)
+	}
+	return from.UTC(), from.AddDate(0, 1, 0).UTC(), nil
+}
+
+// MonthlyReport aggregates the incidents opened during the month by service, urgency and assignee
+func MonthlyReport(month string, from time.Time, to time.Time, incidents []models.Incident) models.IncidentReport {
+	report := models.IncidentReport{
+		Month:     month,
+		From:      from,
+		To:        to,
+		Services:  make(map[string]int),
+		Urgencies: make(map[string]int),
+		Assignees: make(map[string]int),
+		Incidents: make([]models.Incident, 0),
+	}
+
+	var ackTotal, resTotal time.Duration
+	ackCount := 0
+	for _, i := range incidents {
+		if i.Created.Before(from) || !i.Created.Before(to) {
+			continue
+		}
+		report.Total++
+		report.Services[i.Service]++
+		report.Urgencies[i.Urgency]++
+		for _, a := range i.Assignees {
+			report.Assignees[a]++
+		}
+		if !i.Acknowledged.IsZero() {
+			ackTotal += i.Acknowledged.Sub(i.Created)
+			ackCount++
+		}
+		if i.Status == models.IncidentResolved && !i.Resolved.IsZero() {
+			resTotal += i.Resolved.Sub(i.Created)
+			report.Resolved++
+		}
+		report.Incidents = append(report.Incidents, i)
+	}
+
+	if ackCount > 0 {
+		report.MTTA = int64(ackTotal/time.Duration(ackCount)) / int64(time.Second)
+	}
+	if report.Resolved > 0 {
+		report.MTTR = int64(resTotal/time.Duration(report.Resolved)) / int64(time.Second)
+	}
+
+	sort.Slice(report.Incidents, func(i, j int) bool {
+		return report.Incidents[i].Created.Before(report.Incidents[j].Created)
+	})
+
+	return report
+}
+
+func IncidentReportMarkdown(report models.IncidentReport) string {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "# Incident report %v\n\n", report.Month)
+	fmt.Fprintf(&b, "- Incidents: %v\n", report.Total)
+	fmt.Fprintf(&b, "- Resolved: %v\n", report.Resolved)
+	fmt.Fprintf(&b, "- Mean time to acknowledge: %v\n", time.Duration(report.MTTA)*time.Second)
+	fmt.Fprintf(&b, "- Mean time to resolve: %v\n\n", time.Duration(report.MTTR)*time.Second)
+
+	writeCounts(&b, "Services", report.Services)
+	writeCounts(&b, "Urgency", report.Urgencies)
+	writeCounts(&b, "Assignees", report.Assignees)
+
+	b.WriteString("## Incidents\n\n")
+	for _, i := range report.Incidents {
+		fmt.Fprintf(&b, "- #%v %v %v [%v] %v (%v)\n", i.Number, i.Created.Format(time.RFC3339), i.Service, i.Urgency, i.Title, i.Status)
+	}
+	return b.String()
+}
+
+// writeCounts writes the map as a list sorted by count descending
+func writeCounts(b *bytes.Buffer, title string, counts map[string]int) {
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] == counts[keys[j]] {
+			return keys[i] < keys[j]
+		}
+		return counts[keys[i]] > counts[keys[j]]
+	})
+
+	fmt.Fprintf(b, "## %v\n\n", title)
+	for _, k := range keys {
+		fmt.Fprintf(b, "- %v: %v\n", k, counts[k])
+	}
+	b.WriteString("\n")
+}
+
+// HandoverMail returns the subject and the plain text body of the handover notification
+func HandoverMail(handover models.Handover) (string, string) {
+	subject := fmt.Sprintf("On-Call handover from %v", handover.From)
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "On-Call handover submitted by %v at %v\n\n", handover.CreatedBy, handover.Timestamp.Format(time.RFC1123))
+	fmt.Fprintf(&b, "From: %v\nTo: %v\n\n", handover.From, handover.To)
+	fmt.Fprintf(&b, "Summary:\n%v\n\n", handover.Summary)
+	if len(handover.OpenIncidents) > 0 {
+		fmt.Fprintf(&b, "Open incidents:\n- %v\n\n", strings.Join(handover.OpenIncidents, "\n- "))
+	}
+	if len(handover.Notes) > 0 {
+		fmt.Fprintf(&b, "Notes:\n%v\n", handover.Notes)
+	}
+	return subject, b.String()
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/robfig/cron"
+	"github.com/stefanprodan/syros/models"
+)
+
+// inventoryForget is how long a Syros service removed by the indexer GC is still exported as last seen
+const inventoryForget = 7 * 24 * time.Hour
+
+// InventoryCollector exposes the collected inventory state as Prometheus gauges,
+// the gauges are replaced on every collect so removed hosts and containers don't linger
+type InventoryCollector struct {
+	Repository    *Repository
+	containers    *SnapshotGauge
+	consulChecks  *SnapshotGauge
+	clusterLeader *SnapshotGauge
+	clusterNodes  *SnapshotGauge
+	dstoreFree    *SnapshotGauge
+	dstoreSize    *SnapshotGauge
+	vms           *SnapshotGauge
+	agentLastSeen *SnapshotGauge
+	releases      *SnapshotGauge
+	mu            sync.Mutex
+	lastSeen      map[string]seenService
+}
+
+// seenService is the last registration of a Syros service
+type seenService struct {
+	labels    []string
+	collected time.Time
+}
+
+func NewInventoryCollector(repo *Repository) *InventoryCollector {
+	ic := &InventoryCollector{
+		Repository: repo,
+		lastSeen:   make(map[string]seenService),
+		containers: NewSnapshotGauge(prometheus.GaugeOpts{
+			Namespace: "syros",
+			Subsystem: "inventory",
+			Name:      "containers",
+			Help:      "The number of containers by state.",
+		}, []string{"environment", "host", "state"}),
+		consulChecks: NewSnapshotGauge(prometheus.GaugeOpts{
+			Namespace: "syros",
+			Subsystem: "inventory",
+			Name:      "consul_checks",
+			Help:      "The number of Consul health checks by status.",
+		}, []string{"environment", "status"}),
+		clusterLeader: NewSnapshotGauge(prometheus.GaugeOpts{
+			Namespace: "syros",
+			Subsystem: "inventory",
+			Name:      "cluster_leaders",
+			Help:      "The number of cluster members reporting as leader.",
+		}, []string{"environment", "service"}),
+		clusterNodes: NewSnapshotGauge(prometheus.GaugeOpts{
+			Namespace: "syros",
+			Subsystem: "inventory",
+			Name:      "cluster_members",
+			Help:      "The number of cluster members by status.",
+		}, []string{"environment", "service", "status"}),
+		dstoreFree: NewSnapshotGauge(prometheus.GaugeOpts{
+			Namespace: "syros",
+			Subsystem: "inventory",
+			Name:      "datastore_free_bytes",
+			Help:      "The free space of the vSphere datastore.",
+		}, []string{"environment", "datacenter", "datastore"}),
+		dstoreSize: NewSnapshotGauge(prometheus.GaugeOpts{
+			Namespace: "syros",
+			Subsystem: "inventory",
+			Name:      "datastore_capacity_bytes",
+			Help:      "The capacity of the vSphere datastore.",
+		}, []string{"environment", "datacenter", "datastore"}),
+		vms: NewSnapshotGauge(prometheus.GaugeOpts{
+			Namespace: "syros",
+			Subsystem: "inventory",
+			Name:      "vms",
+			Help:      "The number of vSphere VMs by power state.",
+		}, []string{"environment", "cluster", "power_state"}),
+		agentLastSeen: NewSnapshotGauge(prometheus.GaugeOpts{
+			Namespace: "syros",
+			Subsystem: "inventory",
+			Name:      "service_last_seen_seconds",
+			Help:      "The number of seconds since the Syros agent, indexer or app last registered, removed services are kept for 7 days.",
+		}, []string{"environment", "hostname", "type"}),
+		releases: NewSnapshotGauge(prometheus.GaugeOpts{
+			Namespace: "syros",
+			Subsystem: "inventory",
+			Name:      "releases",
+			Help:      "The number of releases deployed on the environment.",
+		}, []string{"environment"}),
+	}
+
+	prometheus.MustRegister(ic.containers)
+	prometheus.MustRegister(ic.consulChecks)
+	prometheus.MustRegister(ic.clusterLeader)
+	prometheus.MustRegister(ic.clusterNodes)
+	prometheus.MustRegister(ic.dstoreFree)
+	prometheus.MustRegister(ic.dstoreSize)
+	prometheus.MustRegister(ic.vms)
+	prometheus.MustRegister(ic.agentLastSeen)
+	prometheus.MustRegister(ic.releases)
+
+	return ic
+}
+
+func (ic *InventoryCollector) Start(cron *cron.Cron) {
+	ic.Collect()
+	cron.AddFunc("@every 1m", func() {
+		ic.Collect()
+	})
+}
+
+func (ic *InventoryCollector) Collect() {
+	start := time.Now()
+
+	// the series are built first and swapped in so a scrape never sees a partial collect,
+	// on query errors the previous series are kept
+	if counts, err := ic.Repository.InventoryGroupCount("containers", map[string]string{"environment": "environment", "host": "host_name", "state": "state"}, "", ""); err == nil {
+		values := make(GaugeValues)
+		for _, c := range counts {
+			values.Set(float64(c.Count), c.Labels["environment"], c.Labels["host"], c.Labels["state"])
+		}
+		ic.containers.Swap(values)
+	}
+
+	if counts, err := ic.Repository.InventoryGroupCount("checks", map[string]string{"environment": "environment", "status": "status"}, "", ""); err == nil {
+		values := make(GaugeValues)
+		for _, c := range counts {
+			values.Set(float64(c.Count), c.Labels["environment"], c.Labels["status"])
+		}
+		ic.consulChecks.Swap(values)
+	}
+
+	if counts, err := ic.Repository.InventoryGroupCount("cluster_checks", map[string]string{"environment": "environment", "service": "service_name", "status": "status"}, "status", "leader"); err == nil {
+		leaders, nodes := make(GaugeValues), make(GaugeValues)
+		for _, c := range counts {
+			leaders.Add(float64(c.Value), c.Labels["environment"], c.Labels["service"])
+			nodes.Set(float64(c.Count), c.Labels["environment"], c.Labels["service"], c.Labels["status"])
+		}
+		ic.clusterLeader.Swap(leaders)
+		ic.clusterNodes.Swap(nodes)
+	}
+
+	if stores, err := ic.Repository.AllDatastores(); err == nil {
+		free, size := make(GaugeValues), make(GaugeValues)
+		for _, ds := range stores {
+			free.Set(float64(ds.Free), ds.Environment, ds.Datacenter, ds.Name)
+			size.Set(float64(ds.Capacity), ds.Environment, ds.Datacenter, ds.Name)
+		}
+		ic.dstoreFree.Swap(free)
+		ic.dstoreSize.Swap(size)
+	}
+
+	if counts, err := ic.Repository.InventoryGroupCount("vsphere_vms", map[string]string{"environment": "environment", "cluster": "cluster", "power_state": "power_state"}, "", ""); err == nil {
+		values := make(GaugeValues)
+		for _, c := range counts {
+			values.Set(float64(c.Count), c.Labels["environment"], c.Labels["cluster"], c.Labels["power_state"])
+		}
+		ic.vms.Swap(values)
+	}
+
+	if services, err := ic.Repository.AllSyrosServices(); err == nil {
+		ic.agentLastSeen.Swap(ic.lastSeenValues(services, time.Now().UTC()))
+	}
+
+	if envs, err := ic.Repository.ReleaseEnvironments(); err == nil {
+		values := make(GaugeValues)
+		for _, list := range envs {
+			for _, env := range strings.Split(list, ",") {
+				if env = strings.TrimSpace(env); len(env) > 0 {
+					values.Add(1, env)
+				}
+			}
+		}
+		ic.releases.Swap(values)
+	}
+
+	log.Debugf("Inventory metrics collect duration %v", time.Since(start))
+}
+
+// lastSeenValues remembers the last registration of each service so a service
+// removed by the indexer GC keeps reporting its growing age until it's forgotten
+func (ic *InventoryCollector) lastSeenValues(services []models.SyrosService, now time.Time) GaugeValues {
+	ic.mu.Lock()
+	defer ic.mu.Unlock()
+
+	for _, svc := range services {
+		key := svc.Environment + "/" + svc.Hostname + "/" + svc.Type
+		if seen, found := ic.lastSeen[key]; !found || svc.Collected.After(seen.collected) {
+			ic.lastSeen[key] = seenService{
+				labels:    []string{svc.Environment, svc.Hostname, svc.Type},
+				collected: svc.Collected,
+			}
+		}
+	}
+
+	values := make(GaugeValues)
+	for key, seen := range ic.lastSeen {
+		if now.Sub(seen.collected) > inventoryForget {
+			delete(ic.lastSeen, key)
+			continue
+		}
+		values.Set(now.Sub(seen.collected).Seconds(), seen.labels...)
+	}
+	return values
+}
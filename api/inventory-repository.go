@@ -0,0 +1,75 @@
+package main
+
+import (
+	log "github.com/Sirupsen/logrus"
+	"github.com/stefanprodan/syros/models"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// InventoryCount holds a document count and the group by label values
+type InventoryCount struct {
+	Labels map[string]string `bson:"_id"`
+	Count  int               `bson:"count"`
+	Value  int               `bson:"value"`
+}
+
+// InventoryGroupCount counts the documents of a collection grouped by the label fields,
+// if sumField is set the Value holds the number of documents where the field equals sumValue
+func (repo *Repository) InventoryGroupCount(col string, labels map[string]string, sumField string, sumValue string) ([]InventoryCount, error) {
+	s := repo.Session.Copy()
+	defer s.Close()
+
+	id := bson.M{}
+	for label, field := range labels {
+		id[label] = "$" + field
+	}
+	group := bson.M{
+		"_id":   id,
+		"count": bson.M{"$sum": 1},
+	}
+	if len(sumField) > 0 {
+		group["value"] = bson.M{"$sum": bson.M{"$cond": []interface{}{bson.M{"$eq": []interface{}{"$" + sumField, sumValue}}, 1, 0}}}
+	}
+
+	result := []InventoryCount{}
+	err := s.DB(repo.Config.Database).C(col).Pipe([]bson.M{{"$group": group}}).All(&result)
+	if err != nil {
+		log.Errorf("Repository InventoryGroupCount %v pipeline failed %v", col, err)
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (repo *Repository) AllDatastores() ([]models.VSphereDatastore, error) {
+	s := repo.Session.Copy()
+	defer s.Close()
+
+	stores := []models.VSphereDatastore{}
+	err := s.DB(repo.Config.Database).C("vsphere_dstores").Find(nil).All(&stores)
+	if err != nil {
+		log.Errorf("Repository AllDatastores query failed %v", err)
+		return nil, err
+	}
+
+	return stores, nil
+}
+
+// ReleaseEnvironments returns the environments list of every release
+func (repo *Repository) ReleaseEnvironments() ([]string, error) {
+	s := repo.Session.Copy()
+	defer s.Close()
+
+	releases := []models.Release{}
+	err := s.DB(repo.Config.Database).C("releases").Find(nil).Select(bson.M{"environments": 1}).All(&releases)
+	if err != nil {
+		log.Errorf("Repository ReleaseEnvironments query failed %v", err)
+		return nil, err
+	}
+
+	result := make([]string, 0, len(releases))
+	for _, r := range releases {
+		result = append(result, r.Environments)
+	}
+	return result, nil
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/csv"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/jwtauth"
+	"github.com/go-chi/render"
+)
+
+func (s *HttpServer) inventoryRoutes() chi.Router {
+	r := chi.NewRouter()
+
+	// JWT protected
+	r.Group(func(r chi.Router) {
+		r.Use(jwtauth.Verifier(s.TokenAuth))
+		r.Use(jwtauth.Authenticator)
+
+		r.Get("/images", func(w http.ResponseWriter, r *http.Request) {
+			containers, err := s.Repository.RunningContainers(r.URL.Query().Get("environment"))
+			if err != nil {
+				render.Status(r, http.StatusInternalServerError)
+				render.PlainText(w, r, err.Error())
+				return
+			}
+			render.JSON(w, r, BuildImageInventory(containers))
+		})
+
+		r.Get("/images/export", func(w http.ResponseWriter, r *http.Request) {
+			containers, err := s.Repository.RunningContainers(r.URL.Query().Get("environment"))
+			if err != nil {
+				render.Status(r, http.StatusInternalServerError)
+				render.PlainText(w, r, err.Error())
+				return
+			}
+
+			w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+			w.Header().Set("Content-Disposition", "attachment; filename=images.csv")
+			cw := csv.NewWriter(w)
+			cw.Write([]string{"registry", "repository", "tag", "digest", "latest", "outdated", "containers", "hosts", "environments", "first_deployed", "last_deployed"})
+			for _, inv := range BuildImageInventory(containers) {
+				for _, v := range inv.Versions {
+					cw.Write([]string{
+						inv.Registry,
+						inv.Repository,
+						v.Tag,
+						v.Digest,
+						inv.Latest,
+						strconv.FormatBool(v.Outdated),
+						strconv.Itoa(v.Containers),
+						strings.Join(v.Hosts, " "),
+						strings.Join(v.Environments, " "),
+						v.FirstDeployed.Format(time.RFC3339),
+						v.LastDeployed.Format(time.RFC3339),
+					})
+				}
+			}
+			cw.Flush()
+		})
+
+		r.Get("/drift", func(w http.ResponseWriter, r *http.Request) {
+			containers, err := s.Repository.RunningContainers(r.URL.Query().Get("environment"))
+			if err != nil {
+				render.Status(r, http.StatusInternalServerError)
+				render.PlainText(w, r, err.Error())
+				return
+			}
+			render.JSON(w, r, FindImageDrift(containers))
+		})
+
+		r.Get("/drift/export", func(w http.ResponseWriter, r *http.Request) {
+			containers, err := s.Repository.RunningContainers(r.URL.Query().Get("environment"))
+			if err != nil {
+				render.Status(r, http.StatusInternalServerError)
+				render.PlainText(w, r, err.Error())
+				return
+			}
+
+			w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+			w.Header().Set("Content-Disposition", "attachment; filename=drift.csv")
+			cw := csv.NewWriter(w)
+			cw.Write([]string{"environment", "service", "repository", "tag", "hosts"})
+			for _, d := range FindImageDrift(containers) {
+				tags := make([]string, 0, len(d.Tags))
+				for tag := range d.Tags {
+					tags = append(tags, tag)
+				}
+				sort.Strings(tags)
+				for _, tag := range tags {
+					cw.Write([]string{d.Environment, d.Service, d.Repository, tag, strings.Join(d.Tags[tag], " ")})
+				}
+			}
+			cw.Flush()
+		})
+	})
+
+	return r
+}
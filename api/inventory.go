@@ -0,0 +1,123 @@
+package main
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/stefanprodan/syros/models"
+)
+
+// BuildImageInventory aggregates the running containers per image repository and version,
+// the latest version of a repository is the one of the most recently created container
+// and every other version still running is flagged as outdated
+func BuildImageInventory(containers []models.DockerContainer) []models.ImageInventory {
+	index := make(map[string]*models.ImageInventory)
+	versions := make(map[string]map[string]*models.ImageVersion)
+	latest := make(map[string]models.DockerContainer)
+
+	for _, c := range containers {
+		ref := models.ParseImageRef(c.Image)
+		name := ref.Name()
+
+		inv, ok := index[name]
+		if !ok {
+			inv = &models.ImageInventory{
+				Registry:   ref.Registry,
+				Repository: ref.Repository,
+			}
+			index[name] = inv
+			versions[name] = make(map[string]*models.ImageVersion)
+		}
+
+		v, ok := versions[name][ref.Version()]
+		if !ok {
+			v = &models.ImageVersion{
+				Tag:           ref.Tag,
+				Digest:        ref.Digest,
+				Hosts:         make([]string, 0),
+				Environments:  make([]string, 0),
+				FirstDeployed: c.Created,
+				LastDeployed:  c.Created,
+			}
+			versions[name][ref.Version()] = v
+		}
+
+		v.Containers++
+		if !contains(v.Hosts, c.HostName) {
+			v.Hosts = append(v.Hosts, c.HostName)
+		}
+		if !contains(v.Environments, c.Environment) {
+			v.Environments = append(v.Environments, c.Environment)
+		}
+		if c.Created.Before(v.FirstDeployed) {
+			v.FirstDeployed = c.Created
+		}
+		if c.Created.After(v.LastDeployed) {
+			v.LastDeployed = c.Created
+		}
+
+		if l, ok := latest[name]; !ok || c.Created.After(l.Created) {
+			latest[name] = c
+		}
+	}
+
+	result := make([]models.ImageInventory, 0, len(index))
+	for name, inv := range index {
+		inv.Latest = models.ParseImageRef(latest[name].Image).Version()
+		inv.Versions = make([]models.ImageVersion, 0, len(versions[name]))
+		for version, v := range versions[name] {
+			v.Outdated = version != inv.Latest
+			sort.Strings(v.Hosts)
+			sort.Strings(v.Environments)
+			inv.Versions = append(inv.Versions, *v)
+		}
+		sort.Slice(inv.Versions, func(i, j int) bool {
+			return inv.Versions[i].LastDeployed.After(inv.Versions[j].LastDeployed)
+		})
+		result = append(result, *inv)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Registry+"/"+result[i].Repository < result[j].Registry+"/"+result[j].Repository
+	})
+
+	return result
+}
+
+// FindImageDrift reports the services whose replicas in the same environment
+// run different image versions on different hosts
+func FindImageDrift(containers []models.DockerContainer) []models.ImageDrift {
+	index := make(map[string]*models.ImageDrift)
+	keys := make([]string, 0)
+
+	for _, c := range containers {
+		if strings.HasSuffix(c.Name, "-previous") || strings.HasSuffix(c.Name, "-purge") {
+			continue
+		}
+
+		ref := models.ParseImageRef(c.Image)
+		key := c.Environment + "/" + c.Name
+		d, ok := index[key]
+		if !ok {
+			d = &models.ImageDrift{
+				Service:     c.Name,
+				Environment: c.Environment,
+				Repository:  ref.Name(),
+				Tags:        make(map[string][]string),
+			}
+			index[key] = d
+			keys = append(keys, key)
+		}
+		d.Tags[ref.Version()] = append(d.Tags[ref.Version()], c.HostName)
+	}
+
+	sort.Strings(keys)
+	result := make([]models.ImageDrift, 0)
+	for _, key := range keys {
+		if len(index[key].Tags) > 1 {
+			result = append(result, *index[key])
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// maxPageSize caps the limit query param for all list endpoints
+const maxPageSize = 5000
+
+// ListFilter maps a query string param to a document field,
+// prefix filters match the start of the field value and
+// list filters match an item of a comma delimited field value
+type ListFilter struct {
+	Field  string
+	Prefix bool
+	List   bool
+}
+
+// ListSpec describes the filters and sort keys allowed on a collection,
+// every field listed here should be indexed by the indexer
+type ListSpec struct {
+	Filters     map[string]ListFilter
+	Sorts       []string
+	DefaultSort string
+}
+
+// ListQuery holds the pagination, filtering and sorting options of a list request,
+// a zero Limit returns all documents
+type ListQuery struct {
+	Limit  int
+	Skip   int
+	Sort   string
+	Filter bson.M
+}
+
+var (
+	containersListSpec = ListSpec{
+		Filters: map[string]ListFilter{
+			"environment": {Field: "environment"},
+			"host":        {Field: "host_name"},
+			"host_id":     {Field: "host_id"},
+			"state":       {Field: "state"},
+			"image":       {Field: "image", Prefix: true},
+			"name":        {Field: "name", Prefix: true},
+		},
+		Sorts:       []string{"name", "image", "state", "host_name", "environment", "created", "collected"},
+		DefaultSort: "-collected",
+	}
+	hostsListSpec = ListSpec{
+		Filters: map[string]ListFilter{
+			"environment": {Field: "environment"},
+			"host":        {Field: "name", Prefix: true},
+		},
+		Sorts:       []string{"name", "environment", "containers_running", "collected"},
+		DefaultSort: "-collected",
+	}
+	checksListSpec = ListSpec{
+		Filters: map[string]ListFilter{
+			"environment": {Field: "environment"},
+			"host":        {Field: "host_name"},
+			"status":      {Field: "status"},
+			"service":     {Field: "service_name", Prefix: true},
+		},
+		Sorts:       []string{"service_name", "host_name", "status", "environment", "since", "collected"},
+		DefaultSort: "-collected",
+	}
+	releasesListSpec = ListSpec{
+		Filters: map[string]ListFilter{
+			"environment": {Field: "environments", List: true},
+			"ticket":      {Field: "ticket_id", Prefix: true},
+		},
+		Sorts:       []string{"name", "ticket_id", "begin", "end", "deployments", "success_rate", "lead_time"},
+		DefaultSort: "end",
+	}
+	vmsListSpec = ListSpec{
+		Filters: map[string]ListFilter{
+			"environment": {Field: "environment"},
+			"host":        {Field: "host_name"},
+			"status":      {Field: "power_state"},
+			"cluster":     {Field: "cluster"},
+			"datacenter":  {Field: "datacenter"},
+			"pool":        {Field: "resource_pool"},
+			"name":        {Field: "name", Prefix: true},
+		},
+		Sorts:       []string{"name", "host_name", "power_state", "environment", "collected"},
+		DefaultSort: "name",
+	}
+	snapshotsListSpec = ListSpec{
+		Filters: map[string]ListFilter{
+			"environment": {Field: "environment"},
+			"datacenter":  {Field: "datacenter"},
+			"vm_id":       {Field: "vm_id"},
+			"vm":          {Field: "vm_name", Prefix: true},
+		},
+		Sorts:       []string{"vm_name", "created", "age", "collected"},
+		DefaultSort: "-age",
+	}
+)
+
+// ParseListQuery reads limit, cursor, sort and the spec filters from the query string
+func ParseListQuery(r *http.Request, spec ListSpec, defaultLimit int) (ListQuery, error) {
+	q := r.URL.Query()
+	query := ListQuery{
+		Limit:  defaultLimit,
+		Sort:   spec.DefaultSort,
+		Filter: bson.M{},
+	}
+
+	if v := q.Get("limit"); len(v) > 0 {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 {
+			return query, fmt.Errorf("Invalid limit %v", v)
+		}
+		query.Limit = limit
+	}
+	if query.Limit > maxPageSize {
+		query.Limit = maxPageSize
+	}
+
+	if v := q.Get("cursor"); len(v) > 0 {
+		skip, err := decodeCursor(v)
+		if err != nil {
+			return query, fmt.Errorf("Invalid cursor %v", v)
+		}
+		query.Skip = skip
+	}
+
+	if v := q.Get("sort"); len(v) > 0 {
+		field := strings.TrimPrefix(v, "-")
+		allowed := false
+		for _, s := range spec.Sorts {
+			if s == field {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return query, fmt.Errorf("Invalid sort key %v allowed keys %v", field, spec.Sorts)
+		}
+		query.Sort = v
+	}
+
+	for param, filter := range spec.Filters {
+		v := q.Get(param)
+		if len(v) < 1 {
+			continue
+		}
+		switch {
+		case filter.Prefix:
+			query.Filter[filter.Field] = bson.RegEx{Pattern: "^" + regexp.QuoteMeta(v)}
+		case filter.List:
+			query.Filter[filter.Field] = bson.RegEx{Pattern: "(^|, )" + regexp.QuoteMeta(v) + "(,|$)"}
+		default:
+			query.Filter[filter.Field] = v
+		}
+	}
+
+	return query, nil
+}
+
+// NextCursor returns the cursor of the next page or empty if this is the last page
+func (q ListQuery) NextCursor(total int) string {
+	if q.Limit < 1 || q.Skip+q.Limit >= total {
+		return ""
+	}
+	return encodeCursor(q.Skip + q.Limit)
+}
+
+// list counts the documents matching the query and loads the requested page into result
+func (repo *Repository) list(col string, q ListQuery, result interface{}) (int, error) {
+	s := repo.Session.Copy()
+	defer s.Close()
+
+	c := s.DB(repo.Config.Database).C(col)
+	total, err := c.Find(q.Filter).Count()
+	if err != nil {
+		log.Errorf("Repository list %v count failed %v", col, err)
+		return 0, err
+	}
+
+	// _id breaks the ties of non unique sort keys so skip based pages don't overlap
+	sort := []string{"_id"}
+	if len(q.Sort) > 0 && q.Sort != "_id" && q.Sort != "-_id" {
+		sort = []string{q.Sort, "_id"}
+	}
+
+	err = c.Find(q.Filter).Sort(sort...).Skip(q.Skip).Limit(q.Limit).All(result)
+	if err != nil {
+		log.Errorf("Repository list %v query failed %v", col, err)
+		return 0, err
+	}
+
+	return total, nil
+}
+
+// setPageHeaders exposes the total count and the next page link,
+// the body stays a plain array so existing clients are not affected
+func setPageHeaders(w http.ResponseWriter, r *http.Request, q ListQuery, total int) {
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+	next := q.NextCursor(total)
+	if len(next) < 1 {
+		return
+	}
+
+	u := *r.URL
+	params := u.Query()
+	params.Set("cursor", next)
+	params.Set("limit", strconv.Itoa(q.Limit))
+	u.RawQuery = params.Encode()
+	w.Header().Set("X-Next-Cursor", next)
+	w.Header().Set("Link", fmt.Sprintf("<%v>; rel=\"next\"", u.RequestURI()))
+}
+
+func encodeCursor(skip int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(skip)))
+}
+
+func decodeCursor(cursor string) (int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, err
+	}
+	skip, err := strconv.Atoi(string(data))
+	if err != nil || skip < 0 {
+		return 0, fmt.Errorf("Invalid cursor %v", cursor)
+	}
+	return skip, nil
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/stefanprodan/syros/models"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func (repo *Repository) LogStats(env string, since time.Time) ([]models.LogStat, error) {
+	s := repo.Session.Copy()
+	defer s.Close()
+
+	c := s.DB(repo.Config.Database).C("log_stats")
+	stats := []models.LogStat{}
+	err := c.Find(bson.M{"environment": env, "bucket": bson.M{"$gte": since}}).Sort("bucket").All(&stats)
+	if err != nil {
+		log.Errorf("Repository LogStats query failed for env %v %v", env, err)
+		return nil, err
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/stefanprodan/syros/models"
+)
+
+// Kibana composes the discover links of the container logs
+type Kibana struct {
+	Address string
+	Index   string
+	Range   time.Duration
+}
+
+// Link returns the discover URL filtered by the container field and optionally by the log level,
+// the app state is encoded as rison
+func (k Kibana) Link(stat models.LogStat, level string) string {
+	if len(k.Address) < 1 {
+		return ""
+	}
+
+	query := fmt.Sprintf("%v:\"%v\"", stat.Field, stat.Container)
+	if len(level) > 0 && len(stat.LevelField) > 0 {
+		query += fmt.Sprintf(" AND %v:(%v OR %v)", stat.LevelField, strings.ToUpper(level), strings.ToLower(level))
+	}
+
+	return fmt.Sprintf("%v/app/kibana#/discover?_g=(time:(from:now-%vm,mode:quick,to:now))&_a=(index:%v,query:(query_string:(analyze_wildcard:!t,query:%v)))",
+		strings.TrimSuffix(k.Address, "/"), int64(k.Range/time.Minute), url.PathEscape(rison(k.Index)), url.PathEscape(rison(query)))
+}
+
+func rison(value string) string {
+	value = strings.Replace(value, "!", "!!", -1)
+	value = strings.Replace(value, "'", "!'", -1)
+	return "'" + value + "'"
+}
+
+// logStatMatch joins a log stat to a container by name or by full or short id
+func logStatMatch(stat models.LogStat, container models.DockerContainer) bool {
+	if stat.Environment != container.Environment {
+		return false
+	}
+	if stat.GroupBy == "id" {
+		return len(stat.Container) >= 12 && strings.HasPrefix(container.Id, stat.Container)
+	}
+	return stat.Container == strings.TrimPrefix(container.Name, "/")
+}
+
+// JoinLogStats sums the log levels of each container, the result is keyed by container id
+func JoinLogStats(containers []models.DockerContainer, stats []models.LogStat, kibana Kibana) map[string]models.ContainerLogs {
+	result := make(map[string]models.ContainerLogs)
+	for _, container := range containers {
+		matched := make([]models.LogStat, 0)
+		for _, stat := range stats {
+			if logStatMatch(stat, container) {
+				matched = append(matched, stat)
+			}
+		}
+		if len(matched) < 1 {
+			continue
+		}
+
+		logs := models.ContainerLogs{
+			ContainerId:  container.Id,
+			Kibana:       kibana.Link(matched[0], ""),
+			KibanaErrors: kibana.Link(matched[0], "error"),
+			Chart:        LogLevelsChart(matched),
+		}
+		for _, stat := range matched {
+			logs.Info += stat.Info
+			logs.Warn += stat.Warn
+			logs.Error += stat.Error
+			logs.Other += stat.Other
+		}
+		result[container.Id] = logs
+	}
+
+	return result
+}
+
+// LogLevelsChart sums the log levels per time bucket
+func LogLevelsChart(stats []models.LogStat) models.LogLevelChart {
+	buckets := make(map[time.Time]*models.LogStat)
+	keys := make([]time.Time, 0)
+	for i := range stats {
+		b, found := buckets[stats[i].Bucket]
+		if !found {
+			b = &models.LogStat{}
+			buckets[stats[i].Bucket] = b
+			keys = append(keys, stats[i].Bucket)
+		}
+		b.Info += stats[i].Info
+		b.Warn += stats[i].Warn
+		b.Error += stats[i].Error
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Before(keys[j]) })
+
+	chart := models.LogLevelChart{
+		Labels: make([]string, 0, len(keys)),
+		Info:   make([]int64, 0, len(keys)),
+		Warn:   make([]int64, 0, len(keys)),
+		Error:  make([]int64, 0, len(keys)),
+	}
+	for _, k := range keys {
+		chart.Labels = append(chart.Labels, k.Format("01-02 15:04"))
+		chart.Info = append(chart.Info, buckets[k].Info)
+		chart.Warn = append(chart.Warn, buckets[k].Warn)
+		chart.Error = append(chart.Error, buckets[k].Error)
+	}
+	return chart
+}
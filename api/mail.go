@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// Mailer sends plain text mails via SMTP, auth is used only if the user is set
+type Mailer struct {
+	Address  string
+	User     string
+	Password string
+	From     string
+}
+
+func NewMailer(address string, user string, password string, from string) *Mailer {
+	return &Mailer{
+		Address:  address,
+		User:     user,
+		Password: password,
+		From:     from,
+	}
+}
+
+func (m *Mailer) Send(to []string, subject string, body string) error {
+	if len(to) < 1 {
+		return fmt.Errorf("Mail %v has no recipients", subject)
+	}
+
+	var auth smtp.Auth
+	if len(m.User) > 0 {
+		host, _, err := net.SplitHostPort(m.Address)
+		if err != nil {
+			return err
+		}
+		auth = smtp.PlainAuth("", m.User, m.Password, host)
+	}
+
+	for i := range to {
+		to[i] = headerValue(to[i])
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %v\r\n", headerValue(m.From))
+	fmt.Fprintf(&msg, "To: %v\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&msg, "Subject: %v\r\n", headerValue(subject))
+	fmt.Fprintf(&msg, "Date: %v\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(strings.Replace(body, "\n", "\r\n", -1))
+
+	return smtp.SendMail(m.Address, auth, m.From, to, msg.Bytes())
+}
+
+// headerValue strips the line breaks so a value can't add headers or start the body
+func headerValue(value string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '\r' || r == '\n' {
+			return ' '
+		}
+		return r
+	}, value)
+}
+
+// splitAddresses returns the non empty addresses of a comma delimited list
+func splitAddresses(list string) []string {
+	result := make([]string, 0)
+	for _, a := range strings.Split(list, ",") {
+		if a = strings.TrimSpace(a); len(a) > 0 {
+			result = append(result, a)
+		}
+	}
+	return result
+}
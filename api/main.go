@@ -5,7 +5,9 @@ import (
 	"os"
 	"os/signal"
 	"path/filepath"
+	"strings"
 	"syscall"
+	"time"
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/go-chi/jwtauth"
@@ -21,9 +23,33 @@ func main() {
 	flag.StringVar(&config.MongoDB, "MongoDB", "localhost:27017", "MongoDB server addresses comma delimited")
 	flag.StringVar(&config.Database, "Database", "syros", "MongoDB database name")
 	flag.StringVar(&config.JwtSecret, "JwtSecret", "syros", "JWT secret")
-	flag.StringVar(&config.Credentials, "Credentials", "admin@admin", "Credentials format user@password")
+	flag.StringVar(&config.Credentials, "Credentials", "admin@admin", "Credentials format user@password[@role] comma delimited")
 	flag.StringVar(&config.AppPath, "AppPath", "", "Path to dist dir")
 	flag.StringVar(&config.Nats, "Nats", "nats://localhost:4222", "Nats server addresses comma delimited")
+	flag.IntVar(&config.PageSize, "PageSize", 1000, "Default page size for list endpoints")
+	flag.StringVar(&config.SshUser, "SshUser", "root", "SSH user for release plan steps")
+	flag.IntVar(&config.SshPort, "SshPort", 22, "SSH port for release plan steps")
+	flag.StringVar(&config.SshKey, "SshKey", "", "SSH private key path for release plan steps, empty disables plan execution")
+	flag.StringVar(&config.SshKeyPass, "SshKeyPass", "", "SSH private key password")
+	flag.StringVar(&config.SshTimeout, "SshTimeout", "30m", "Max duration of a release plan step command, 0 disables the limit")
+	flag.StringVar(&config.ApprovalPolicy, "ApprovalPolicy", "", "Minimum release plan approval gates format environment@approvals@role1|role2 comma delimited, plans can add approvals but not remove them")
+	flag.StringVar(&config.WindowOverride, "WindowOverride", "admin", "Roles allowed to deploy outside and to edit maintenance windows comma delimited")
+	flag.StringVar(&config.DeliveryWindows, "DeliveryWindows", "7d,30d,90d", "Delivery metrics windows exposed on /metrics comma delimited")
+	flag.StringVar(&config.GitPath, "GitPath", "", "Path to the dir containing the git bare clones or mirrors of the services, empty disables commit linking")
+	flag.StringVar(&config.SmtpAddress, "SmtpAddress", "", "SMTP server host:port for On-Call handover mails, empty disables mailing")
+	flag.StringVar(&config.SmtpUser, "SmtpUser", "", "SMTP user, empty disables SMTP auth")
+	flag.StringVar(&config.SmtpPassword, "SmtpPassword", "", "SMTP password")
+	flag.StringVar(&config.SmtpFrom, "SmtpFrom", "syros@localhost", "Sender address of the On-Call handover mails")
+	flag.StringVar(&config.OnCallMail, "OnCallMail", "", "On-Call team addresses comma delimited")
+	flag.StringVar(&config.KibanaUrl, "KibanaUrl", "", "Kibana address used to compose the container logs links, empty disables the links")
+	flag.StringVar(&config.KibanaIndex, "KibanaIndex", "logstash-*", "Kibana index pattern of the container logs")
+	flag.StringVar(&config.LogStatsRange, "LogStatsRange", "24h", "Time range of the container log stats")
+	flag.StringVar(&config.PrometheusUrl, "PrometheusUrl", "", "Prometheus address for host and container graphs, empty disables the graphs")
+	flag.StringVar(&config.PrometheusHostLabel, "PrometheusHostLabel", "instance", "Prometheus label matching the host name in the node_exporter metrics")
+	flag.StringVar(&config.PrometheusContainerLabel, "PrometheusContainerLabel", "name", "Prometheus label matching the container name in the cAdvisor metrics")
+	flag.Float64Var(&config.CPUOvercommit, "CPUOvercommit", 4, "Max vCPU to physical core ratio of a cluster used for VM placement and forecasting")
+	flag.Float64Var(&config.MemoryOvercommit, "MemoryOvercommit", 1, "Max vRAM to physical RAM ratio of a cluster used for VM placement and forecasting")
+	flag.IntVar(&config.DatastoreReserve, "DatastoreReserve", 10, "Percentage of datastore capacity kept free by VM placement and forecasting")
 	flag.Parse()
 
 	setLogLevel(config.LogLevel)
@@ -61,11 +87,58 @@ func main() {
 		log.Fatalf("MongoDB connection error %v", err)
 	}
 
+	var runner CommandRunner
+	if config.SshKey != "" {
+		sshTimeout, err := time.ParseDuration(config.SshTimeout)
+		if err != nil {
+			log.Fatalf("SshTimeout %v error %v", config.SshTimeout, err)
+		}
+		sshRunner, err := NewSshRunner(config.SshUser, config.SshPort, config.SshKey, config.SshKeyPass, sshTimeout)
+		if err != nil {
+			log.Fatalf("SSH runner init error %v", err)
+		}
+		runner = sshRunner
+	}
+
+	logRange, err := time.ParseDuration(config.LogStatsRange)
+	if err != nil {
+		log.Fatalf("LogStatsRange %v error %v", config.LogStatsRange, err)
+	}
+
+	if config.CPUOvercommit <= 0 || config.MemoryOvercommit <= 0 || config.DatastoreReserve < 0 || config.DatastoreReserve > 99 {
+		log.Fatalf("Invalid capacity limits CPUOvercommit %v MemoryOvercommit %v DatastoreReserve %v",
+			config.CPUOvercommit, config.MemoryOvercommit, config.DatastoreReserve)
+	}
+
 	server := HttpServer{
 		Config:     config,
 		Repository: repo,
 		TokenAuth:  jwtauth.New("HS256", []byte(config.JwtSecret), nil),
+		Executor:   NewPlanExecutor(repo, runner, ParseApprovalPolicy(config.ApprovalPolicy)),
+		Kibana:     Kibana{Address: config.KibanaUrl, Index: config.KibanaIndex, Range: logRange},
 	}
+	if config.GitPath != "" {
+		server.Git = NewGitLog(config.GitPath)
+	}
+	if config.PrometheusUrl != "" {
+		server.Prometheus = NewPrometheusClient(config.PrometheusUrl)
+	}
+	if config.SmtpAddress != "" {
+		server.Mailer = NewMailer(config.SmtpAddress, config.SmtpUser, config.SmtpPassword, config.SmtpFrom)
+	}
+
+	if err := server.Executor.FailInterrupted(); err != nil {
+		log.Errorf("Failing interrupted plans error %v", err)
+	}
+
+	scheduler := NewPlanScheduler(server.Executor, cronJob)
+	scheduler.Start()
+
+	delivery := NewDeliveryCollector(repo, strings.Split(config.DeliveryWindows, ","))
+	delivery.Start(cronJob)
+
+	inventory := NewInventoryCollector(repo)
+	inventory.Start(cronJob)
 
 	log.Infof("Starting HTTP server on port %v", config.Port)
 	go server.Start()
@@ -0,0 +1,56 @@
+package main
+
+import (
+	log "github.com/Sirupsen/logrus"
+	"github.com/stefanprodan/syros/models"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// MaintenanceWindows returns the windows of an environment or all windows if the environment is empty
+func (repo *Repository) MaintenanceWindows(environment string) ([]models.MaintenanceWindow, error) {
+	s := repo.Session.Copy()
+	defer s.Close()
+
+	query := bson.M{}
+	if len(environment) > 0 {
+		query["environment"] = environment
+	}
+
+	c := s.DB(repo.Config.Database).C("maintenance_windows")
+	windows := []models.MaintenanceWindow{}
+	err := c.Find(query).Sort("environment", "name").All(&windows)
+	if err != nil {
+		log.Errorf("Repository MaintenanceWindows query failed for environment %v %v", environment, err)
+		return nil, err
+	}
+
+	return windows, nil
+}
+
+func (repo *Repository) MaintenanceWindowUpsert(window models.MaintenanceWindow) error {
+	s := repo.Session.Copy()
+	defer s.Close()
+
+	c := s.DB(repo.Config.Database).C("maintenance_windows")
+	_, err := c.UpsertId(window.Id, &window)
+	if err != nil {
+		log.Errorf("Repository MaintenanceWindowUpsert failed for windowId %v %v", window.Id, err)
+		return err
+	}
+
+	return nil
+}
+
+func (repo *Repository) MaintenanceWindowDelete(windowId string) error {
+	s := repo.Session.Copy()
+	defer s.Close()
+
+	c := s.DB(repo.Config.Database).C("maintenance_windows")
+	err := c.RemoveId(windowId)
+	if err != nil {
+		log.Errorf("Repository MaintenanceWindowDelete failed for windowId %v %v", windowId, err)
+		return err
+	}
+
+	return nil
+}
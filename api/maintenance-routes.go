@@ -0,0 +1,84 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/jwtauth"
+	"github.com/go-chi/render"
+	"github.com/stefanprodan/syros/models"
+)
+
+func (s *HttpServer) maintenanceRoutes() chi.Router {
+	r := chi.NewRouter()
+
+	// JWT protected
+	r.Group(func(r chi.Router) {
+		r.Use(jwtauth.Verifier(s.TokenAuth))
+		r.Use(jwtauth.Authenticator)
+
+		r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+			windows, err := s.Repository.MaintenanceWindows(r.URL.Query().Get("environment"))
+			if err != nil {
+				render.Status(r, http.StatusInternalServerError)
+				render.PlainText(w, r, err.Error())
+				return
+			}
+			render.JSON(w, r, windows)
+		})
+
+		r.Get("/status/{env}", func(w http.ResponseWriter, r *http.Request) {
+			env := chi.URLParam(r, "env")
+			windows, err := s.Repository.MaintenanceWindows(env)
+			if err != nil {
+				render.Status(r, http.StatusInternalServerError)
+				render.PlainText(w, r, err.Error())
+				return
+			}
+			render.JSON(w, r, MaintenanceStatus(env, windows, time.Now().UTC()))
+		})
+
+		// only the roles allowed to override the windows can change them
+		r.With(RequireRole(s.overrideRoles()...)).Post("/", func(w http.ResponseWriter, r *http.Request) {
+			data := MaintenanceWindowForm{}
+			if err := render.Bind(r, &data); err != nil {
+				render.Status(r, http.StatusBadRequest)
+				render.PlainText(w, r, err.Error())
+				return
+			}
+
+			window := data.MaintenanceWindow
+			window.Id, _ = models.NewUUID()
+			window.CreatedBy = requestUser(r)
+			window.Timestamp = time.Now().UTC()
+			if err := s.Repository.MaintenanceWindowUpsert(window); err != nil {
+				render.Status(r, http.StatusInternalServerError)
+				render.PlainText(w, r, err.Error())
+				return
+			}
+			render.Status(r, http.StatusCreated)
+			render.JSON(w, r, window)
+		})
+
+		r.With(RequireRole(s.overrideRoles()...)).Delete("/{windowID}", func(w http.ResponseWriter, r *http.Request) {
+			if err := s.Repository.MaintenanceWindowDelete(chi.URLParam(r, "windowID")); err != nil {
+				render.Status(r, http.StatusNotFound)
+				render.PlainText(w, r, err.Error())
+				return
+			}
+			render.Status(r, http.StatusNoContent)
+			render.PlainText(w, r, "")
+		})
+	})
+
+	return r
+}
+
+type MaintenanceWindowForm struct {
+	models.MaintenanceWindow
+}
+
+func (p *MaintenanceWindowForm) Bind(r *http.Request) error {
+	return ValidateWindow(p.MaintenanceWindow)
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron"
+	"github.com/stefanprodan/syros/models"
+)
+
+// ValidateWindow checks that a window is either recurring or one-off
+func ValidateWindow(w models.MaintenanceWindow) error {
+	if len(w.Environment) < 1 {
+		return fmt.Errorf("environment is required")
+	}
+
+	if len(w.Schedule) > 0 {
+		if _, err := cron.ParseStandard(w.Schedule); err != nil {
+			return fmt.Errorf("Invalid schedule %v %v", w.Schedule, err)
+		}
+		if w.Duration < 1 {
+			return fmt.Errorf("duration in minutes is required for recurring windows")
+		}
+		return nil
+	}
+
+	if w.Begin.IsZero() || w.End.IsZero() || !w.End.After(w.Begin) {
+		return fmt.Errorf("schedule or begin and end are required")
+	}
+
+	return nil
+}
+
+// WindowOpen tells if the window is open at the given time
+func WindowOpen(w models.MaintenanceWindow, now time.Time) bool {
+	if len(w.Schedule) < 1 {
+		return !now.Before(w.Begin) && now.Before(w.End)
+	}
+
+	sched, err := cron.ParseStandard(w.Schedule)
+	if err != nil {
+		return false
+	}
+
+	// the window is open if it was activated during the last Duration minutes
+	duration := time.Duration(w.Duration) * time.Minute
+	activation := sched.Next(now.Add(-duration - time.Second))
+	return !activation.After(now)
+}
+
+// WindowNextOpen returns the next time the window opens after now or zero if it never does
+func WindowNextOpen(w models.MaintenanceWindow, now time.Time) time.Time {
+	if len(w.Schedule) < 1 {
+		if now.Before(w.Begin) {
+			return w.Begin
+		}
+		return time.Time{}
+	}
+
+	sched, err := cron.ParseStandard(w.Schedule)
+	if err != nil {
+		return time.Time{}
+	}
+	return sched.Next(now)
+}
+
+// MaintenanceStatus evaluates the windows of an environment,
+// environments without windows are always open for deployments
+func MaintenanceStatus(environment string, windows []models.MaintenanceWindow, now time.Time) models.MaintenanceStatus {
+	status := models.MaintenanceStatus{
+		Environment: environment,
+		Open:        len(windows) < 1,
+		Windows:     windows,
+	}
+
+	for _, w := range windows {
+		if WindowOpen(w, now) {
+			status.Open = true
+		}
+		next := WindowNextOpen(w, now)
+		if !next.IsZero() && (status.NextOpen.IsZero() || next.Before(status.NextOpen)) {
+			status.NextOpen = next
+		}
+	}
+
+	return status
+}
+
+// DeploymentAllowed checks the maintenance windows of an environment at the current time
+func (repo *Repository) DeploymentAllowed(environment string) error {
+	windows, err := repo.MaintenanceWindows(environment)
+	if err != nil {
+		return err
+	}
+
+	status := MaintenanceStatus(environment, windows, time.Now().UTC())
+	if status.Open {
+		return nil
+	}
+
+	if status.NextOpen.IsZero() {
+		return fmt.Errorf("Environment %v is outside its maintenance windows", environment)
+	}
+	return fmt.Errorf("Environment %v is outside its maintenance windows, next window opens at %v",
+		environment, status.NextOpen.Format(time.RFC3339))
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/stefanprodan/syros/models"
+)
+
+// GraphQuery is a PromQL template, the Selector is the label matcher of the host or container,
+// if Legend is a label name each series is named after the label value
+type GraphQuery struct {
+	Name   string
+	Legend string
+	Query  *template.Template
+}
+
+type GraphTemplate struct {
+	Title   string
+	Unit    string
+	Queries []GraphQuery
+}
+
+func graphQuery(name string, legend string, query string) GraphQuery {
+	return GraphQuery{
+		Name:   name,
+		Legend: legend,
+		Query:  template.Must(template.New(name).Parse(query)),
+	}
+}
+
+// hostGraphs are based on the node_exporter metrics
+var hostGraphs = map[string]GraphTemplate{
+	"cpu": {Title: "CPU usage", Unit: "percent", Queries: []GraphQuery{
+		graphQuery("used", "", `100 - avg(irate(node_cpu_seconds_total{mode="idle",{{.Selector}}}[5m])) * 100`),
+	}},
+	"memory": {Title: "Memory", Unit: "bytes", Queries: []GraphQuery{
+		graphQuery("used", "", `sum(node_memory_MemTotal_bytes{ {{.Selector}} } - node_memory_MemAvailable_bytes{ {{.Selector}} })`),
+		graphQuery("total", "", `sum(node_memory_MemTotal_bytes{ {{.Selector}} })`),
+	}},
+	"io": {Title: "Disk IO", Unit: "bytes/s", Queries: []GraphQuery{
+		graphQuery("read", "", `sum(irate(node_disk_read_bytes_total{ {{.Selector}} }[5m]))`),
+		graphQuery("written", "", `sum(irate(node_disk_written_bytes_total{ {{.Selector}} }[5m]))`),
+	}},
+	"disk": {Title: "Disk usage", Unit: "percent", Queries: []GraphQuery{
+		graphQuery("used", "mountpoint", `100 - node_filesystem_avail_bytes{fstype!~"tmpfs|overlay|squashfs",{{.Selector}}} / node_filesystem_size_bytes{fstype!~"tmpfs|overlay|squashfs",{{.Selector}}} * 100`),
+	}},
+	"network": {Title: "Network", Unit: "bytes/s", Queries: []GraphQuery{
+		graphQuery("received", "", `sum(irate(node_network_receive_bytes_total{device!~"lo|veth.*|docker.*|br-.*",{{.Selector}}}[5m]))`),
+		graphQuery("transmitted", "", `sum(irate(node_network_transmit_bytes_total{device!~"lo|veth.*|docker.*|br-.*",{{.Selector}}}[5m]))`),
+	}},
+}
+
+// containerGraphs are based on the cAdvisor metrics
+var containerGraphs = map[string]GraphTemplate{
+	"cpu": {Title: "CPU usage", Unit: "percent", Queries: []GraphQuery{
+		graphQuery("used", "", `sum(irate(container_cpu_usage_seconds_total{ {{.Selector}} }[5m])) * 100`),
+	}},
+	"memory": {Title: "Memory", Unit: "bytes", Queries: []GraphQuery{
+		graphQuery("used", "", `sum(container_memory_usage_bytes{ {{.Selector}} })`),
+		graphQuery("cache", "", `sum(container_memory_cache{ {{.Selector}} })`),
+	}},
+	"io": {Title: "Disk IO", Unit: "bytes/s", Queries: []GraphQuery{
+		graphQuery("read", "", `sum(irate(container_fs_reads_bytes_total{ {{.Selector}} }[5m]))`),
+		graphQuery("written", "", `sum(irate(container_fs_writes_bytes_total{ {{.Selector}} }[5m]))`),
+	}},
+	"network": {Title: "Network", Unit: "bytes/s", Queries: []GraphQuery{
+		graphQuery("received", "", `sum(irate(container_network_receive_bytes_total{ {{.Selector}} }[5m]))`),
+		graphQuery("transmitted", "", `sum(irate(container_network_transmit_bytes_total{ {{.Selector}} }[5m]))`),
+	}},
+}
+
+// MetricsGraphs returns the graph templates of a target, host or container
+func MetricsGraphs(target string) (map[string]GraphTemplate, error) {
+	switch target {
+	case "host":
+		return hostGraphs, nil
+	case "container":
+		return containerGraphs, nil
+	default:
+		return nil, fmt.Errorf("Invalid target %v allowed host or container", target)
+	}
+}
+
+// MetricsGraphNames returns the sorted graph names of a target
+func MetricsGraphNames(target string) []string {
+	graphs, _ := MetricsGraphs(target)
+	names := make([]string, 0, len(graphs))
+	for name := range graphs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// MetricsSelector matches the host instance with any port or the exact container name
+func MetricsSelector(target string, label string, name string) string {
+	if target == "host" {
+		return fmt.Sprintf(`%v=~"%v(:[0-9]+)?"`, label, promString(regexp.QuoteMeta(name)))
+	}
+	return fmt.Sprintf(`%v="%v"`, label, promString(strings.TrimPrefix(name, "/")))
+}
+
+// promString escapes a value for a double quoted PromQL string
+func promString(value string) string {
+	value = strings.Replace(value, `\`, `\\`, -1)
+	return strings.Replace(value, `"`, `\"`, -1)
+}
+
+// MetricsStep returns a step that renders about 120 points, 15 seconds at least
+func MetricsStep(start time.Time, end time.Time) time.Duration {
+	step := end.Sub(start) / 120
+	step = step - step%time.Second
+	if step < 15*time.Second {
+		step = 15 * time.Second
+	}
+	return step
+}
+
+// RenderGraph runs the graph queries and names the series after the query or the legend label
+func RenderGraph(prom *PrometheusClient, graph GraphTemplate, selector string, start time.Time, end time.Time, step time.Duration) ([]models.MetricsSeries, error) {
+	series := make([]models.MetricsSeries, 0)
+	for _, q := range graph.Queries {
+		var b bytes.Buffer
+		if err := q.Query.Execute(&b, struct{ Selector string }{selector}); err != nil {
+			return nil, err
+		}
+		query := b.String()
+
+		samples, err := prom.QueryRange(query, start, end, step)
+		if err != nil {
+			return nil, err
+		}
+		for _, sample := range samples {
+			name := q.Name
+			if len(q.Legend) > 0 && len(sample.Metric[q.Legend]) > 0 {
+				name = q.Name + " " + sample.Metric[q.Legend]
+			}
+			series = append(series, models.MetricsSeries{
+				Name:   name,
+				Query:  query,
+				Points: sample.Points,
+			})
+		}
+	}
+	return series, nil
+}
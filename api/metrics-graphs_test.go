@@ -0,0 +1,57 @@
+package main
+
+import (
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+func TestMetricsSelector(t *testing.T) {
+	tests := []struct {
+		target string
+		label  string
+		name   string
+		want   string
+	}{
+		{"host", "instance", "web-01", `instance=~"web-01(:[0-9]+)?"`},
+		{"host", "instance", "web-01.example.com", `instance=~"web-01\\.example\\.com(:[0-9]+)?"`},
+		{"host", "instance", `db"}|up{x="`, `instance=~"db\"\\}\\|up\\{x=\"(:[0-9]+)?"`},
+		{"container", "name", "/api", `name="api"`},
+		{"container", "name", `api"} or up{x="`, `name="api\"} or up{x=\""`},
+		{"container", "name", `c:\tmp`, `name="c:\\tmp"`},
+	}
+
+	for _, tt := range tests {
+		if got := MetricsSelector(tt.target, tt.label, tt.name); got != tt.want {
+			t.Errorf("MetricsSelector(%v, %q) = %v, want %v", tt.target, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMetricsStep(t *testing.T) {
+	end := time.Now()
+	if step := MetricsStep(end.Add(-time.Hour), end); step != 30*time.Second {
+		t.Errorf("Step of one hour %v, want 30s", step)
+	}
+	if step := MetricsStep(end.Add(-10*time.Minute), end); step != 15*time.Second {
+		t.Errorf("Step of ten minutes %v, want the 15s minimum", step)
+	}
+}
+
+func TestMetricsGraphs(t *testing.T) {
+	if _, err := MetricsGraphs("vm"); err == nil {
+		t.Error("MetricsGraphs should reject unknown targets")
+	}
+
+	// every template must render with a selector
+	for _, target := range []string{"host", "container"} {
+		graphs, _ := MetricsGraphs(target)
+		for _, name := range MetricsGraphNames(target) {
+			for _, q := range graphs[name].Queries {
+				if err := q.Query.Execute(ioutil.Discard, struct{ Selector string }{`a="b"`}); err != nil {
+					t.Errorf("Graph %v %v query %v failed %v", target, name, q.Name, err)
+				}
+			}
+		}
+	}
+}
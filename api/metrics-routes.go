@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/jwtauth"
+	"github.com/go-chi/render"
+	"github.com/stefanprodan/syros/models"
+)
+
+func (s *HttpServer) metricsRoutes() chi.Router {
+	r := chi.NewRouter()
+
+	// JWT protected
+	r.Group(func(r chi.Router) {
+		r.Use(jwtauth.Verifier(s.TokenAuth))
+		r.Use(jwtauth.Authenticator)
+
+		r.Get("/graphs", func(w http.ResponseWriter, r *http.Request) {
+			render.JSON(w, r, map[string][]string{
+				"host":      MetricsGraphNames("host"),
+				"container": MetricsGraphNames("container"),
+			})
+		})
+
+		r.Get("/query_range", s.metricsQueryRange(s.Repository.DockerNameExists))
+	})
+
+	return r
+}
+
+// metricsQueryRange renders a graph of a known host or container,
+// exists checks the name against the inventory
+func (s *HttpServer) metricsQueryRange(exists func(target string, name string) (bool, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.Prometheus == nil {
+			render.Status(r, http.StatusServiceUnavailable)
+			render.PlainText(w, r, "Prometheus is not configured")
+			return
+		}
+
+		q := r.URL.Query()
+		target, name, label := "host", q.Get("host"), s.Config.PrometheusHostLabel
+		if len(name) < 1 {
+			target, name, label = "container", q.Get("container"), s.Config.PrometheusContainerLabel
+		}
+		if len(name) < 1 {
+			render.Status(r, http.StatusBadRequest)
+			render.PlainText(w, r, "Host or container name is required")
+			return
+		}
+
+		graphs, _ := MetricsGraphs(target)
+		graph, found := graphs[q.Get("graph")]
+		if !found {
+			render.Status(r, http.StatusBadRequest)
+			render.PlainText(w, r, fmt.Sprintf("Invalid graph %v allowed graphs %v", q.Get("graph"), MetricsGraphNames(target)))
+			return
+		}
+
+		start, end, step, err := parseRange(r)
+		if err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.PlainText(w, r, err.Error())
+			return
+		}
+
+		exists, err := exists(target, name)
+		if err != nil {
+			render.Status(r, http.StatusInternalServerError)
+			render.PlainText(w, r, err.Error())
+			return
+		}
+		if !exists {
+			render.Status(r, http.StatusNotFound)
+			render.PlainText(w, r, fmt.Sprintf("%v %v not found", target, name))
+			return
+		}
+
+		series, err := RenderGraph(s.Prometheus, graph, MetricsSelector(target, label, name), start, end, step)
+		if err != nil {
+			render.Status(r, http.StatusBadGateway)
+			render.PlainText(w, r, err.Error())
+			return
+		}
+
+		render.JSON(w, r, models.MetricsGraph{
+			Target: target,
+			Name:   name,
+			Graph:  q.Get("graph"),
+			Title:  graph.Title,
+			Unit:   graph.Unit,
+			Start:  start.Unix(),
+			End:    end.Unix(),
+			Step:   int64(step / time.Second),
+			Series: series,
+		})
+	}
+}
+
+// parseRange reads start, end and step from the query string,
+// start and end are RFC3339 or unix timestamps, step is a duration or seconds,
+// the default range is the last hour
+func parseRange(r *http.Request) (time.Time, time.Time, time.Duration, error) {
+	q := r.URL.Query()
+	end := time.Now().UTC()
+	if v := q.Get("end"); len(v) > 0 {
+		t, err := parseTimestamp(v)
+		if err != nil {
+			return end, end, 0, fmt.Errorf("Invalid end %v", v)
+		}
+		end = t
+	}
+
+	start := end.Add(-1 * time.Hour)
+	if v := q.Get("start"); len(v) > 0 {
+		t, err := parseTimestamp(v)
+		if err != nil {
+			return start, end, 0, fmt.Errorf("Invalid start %v", v)
+		}
+		start = t
+	}
+	if !start.Before(end) {
+		return start, end, 0, fmt.Errorf("Start %v must be before end %v", start, end)
+	}
+
+	step := MetricsStep(start, end)
+	if v := q.Get("step"); len(v) > 0 {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			sec, serr := strconv.Atoi(v)
+			if serr != nil {
+				return start, end, 0, fmt.Errorf("Invalid step %v", v)
+			}
+			d = time.Duration(sec) * time.Second
+		}
+		if d < time.Second {
+			return start, end, 0, fmt.Errorf("Invalid step %v", v)
+		}
+		// Prometheus rejects queries with more than 11000 points per series
+		if end.Sub(start)/d > 11000 {
+			return start, end, 0, fmt.Errorf("Step %v is too small for the range", v)
+		}
+		step = d
+	}
+
+	return start, end, step, nil
+}
+
+func parseTimestamp(value string) (time.Time, error) {
+	if sec, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(sec, 0).UTC(), nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return t, err
+	}
+	return t.UTC(), nil
+}
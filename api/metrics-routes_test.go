@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stefanprodan/syros/models"
+)
+
+func TestMetricsQueryRange(t *testing.T) {
+	queries := make([]string, 0)
+	ts := newPrometheusFake(t, &queries)
+	defer ts.Close()
+
+	s := &HttpServer{
+		Config: &Config{
+			PrometheusHostLabel:      "instance",
+			PrometheusContainerLabel: "name",
+		},
+		Prometheus: NewPrometheusClient(ts.URL),
+	}
+	inventory := map[string]bool{"host/web-01": true, "container/api": true}
+	exists := func(target string, name string) (bool, error) {
+		if name == "mongo" {
+			return false, errors.New("no reachable servers")
+		}
+		return inventory[target+"/"+name], nil
+	}
+	handler := s.metricsQueryRange(exists)
+
+	tests := []struct {
+		query  string
+		status int
+		body   string
+	}{
+		{"graph=cpu", http.StatusBadRequest, "name is required"},
+		{"host=web-01&graph=gpu", http.StatusBadRequest, "Invalid graph"},
+		{"container=api&graph=disk", http.StatusBadRequest, "Invalid graph"},
+		{"host=web-01&graph=cpu&start=yesterday", http.StatusBadRequest, "Invalid start"},
+		{"host=web-01&graph=cpu&start=1500003600&end=1500000000", http.StatusBadRequest, "must be before"},
+		{"host=web-01&graph=cpu&start=1500000000&end=1500086400&step=1s", http.StatusBadRequest, "too small"},
+		{"host=web-02&graph=cpu", http.StatusNotFound, "host web-02 not found"},
+		{"host=mongo&graph=cpu", http.StatusInternalServerError, "no reachable servers"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest("GET", "/api/metrics/query_range?"+tt.query, nil))
+		if w.Code != tt.status || !strings.Contains(w.Body.String(), tt.body) {
+			t.Errorf("Query %v returned %v %q, want %v %q", tt.query, w.Code, w.Body.String(), tt.status, tt.body)
+		}
+	}
+	if len(queries) > 0 {
+		t.Errorf("Prometheus queried for invalid requests %v", queries)
+	}
+
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest("GET", "/api/metrics/query_range?host=web-01&graph=disk&start=1500000000&end=1500003600&step=1m", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Disk graph returned %v %v", w.Code, w.Body.String())
+	}
+
+	graph := models.MetricsGraph{}
+	if err := json.Unmarshal(w.Body.Bytes(), &graph); err != nil {
+		t.Fatal(err)
+	}
+	if graph.Target != "host" || graph.Step != 60 || graph.Start != 1500000000 || graph.End != 1500003600 {
+		t.Errorf("Graph target %v step %v range %v-%v", graph.Target, graph.Step, graph.Start, graph.End)
+	}
+	if len(graph.Series) != 2 || graph.Series[0].Name != "used /" || graph.Series[1].Name != "used /data" {
+		t.Errorf("Series %+v, want one per mountpoint", graph.Series)
+	}
+	if len(queries) != 1 || !strings.Contains(queries[0], `instance=~"web-01(:[0-9]+)?"`) {
+		t.Errorf("Queries %v, want the host selector", queries)
+	}
+
+	w = httptest.NewRecorder()
+	handler(w, httptest.NewRequest("GET", "/api/metrics/query_range?container=api&graph=cpu", nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"points":[[1500000000,1.5],[1500000120,2.25]]`) {
+		t.Errorf("Container cpu returned %v %v", w.Code, w.Body.String())
+	}
+	if last := queries[len(queries)-1]; !strings.Contains(last, `name="api"`) {
+		t.Errorf("Query %v, want the container selector", last)
+	}
+
+	ts.Close()
+	w = httptest.NewRecorder()
+	handler(w, httptest.NewRequest("GET", "/api/metrics/query_range?container=api&graph=cpu", nil))
+	if w.Code != http.StatusBadGateway {
+		t.Errorf("Prometheus down returned %v, want 502", w.Code)
+	}
+
+	s.Prometheus = nil
+	w = httptest.NewRecorder()
+	handler(w, httptest.NewRequest("GET", "/api/metrics/query_range?container=api&graph=cpu", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Prometheus not configured returned %v, want 503", w.Code)
+	}
+}
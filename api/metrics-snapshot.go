@@ -0,0 +1,67 @@
+package main
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// SnapshotGauge is a gauge vector replaced as a whole on each collect,
+// a scrape sees either the previous or the new series but never a partial refill
+type SnapshotGauge struct {
+	desc   *prometheus.Desc
+	mu     sync.RWMutex
+	values GaugeValues
+}
+
+// GaugeValues holds the series of a SnapshotGauge by label values
+type GaugeValues map[string]gaugeSample
+
+type gaugeSample struct {
+	labels []string
+	value  float64
+}
+
+func NewSnapshotGauge(opts prometheus.GaugeOpts, labels []string) *SnapshotGauge {
+	return &SnapshotGauge{
+		desc:   prometheus.NewDesc(prometheus.BuildFQName(opts.Namespace, opts.Subsystem, opts.Name), opts.Help, labels, opts.ConstLabels),
+		values: make(GaugeValues),
+	}
+}
+
+// Set adds or overwrites the series with the given label values
+func (v GaugeValues) Set(value float64, labels ...string) {
+	v[strings.Join(labels, "\xff")] = gaugeSample{labels: labels, value: value}
+}
+
+// Add increments the series with the given label values
+func (v GaugeValues) Add(value float64, labels ...string) {
+	key := strings.Join(labels, "\xff")
+	sample, found := v[key]
+	if !found {
+		sample = gaugeSample{labels: labels}
+	}
+	sample.value += value
+	v[key] = sample
+}
+
+// Swap replaces all the series of the gauge
+func (g *SnapshotGauge) Swap(values GaugeValues) {
+	g.mu.Lock()
+	g.values = values
+	g.mu.Unlock()
+}
+
+func (g *SnapshotGauge) Describe(ch chan<- *prometheus.Desc) {
+	ch <- g.desc
+}
+
+func (g *SnapshotGauge) Collect(ch chan<- prometheus.Metric) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	for _, s := range g.values {
+		ch <- prometheus.MustNewConstMetric(g.desc, prometheus.GaugeValue, s.value, s.labels...)
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/stefanprodan/syros/models"
+)
+
+// GateStatus tells if the plan approval gates of an environment are met,
+// only the latest decision of each user counts, a single rejection blocks the gates
+// and the plan creator can't approve their own plan
+func GateStatus(plan models.ReleasePlan, environment string) (bool, string) {
+	gates := findGates(plan, environment)
+	if len(gates) < 1 {
+		return true, ""
+	}
+
+	latest := make(map[string]models.ApprovalDecision)
+	users := make([]string, 0)
+	for _, d := range plan.Decisions {
+		if d.Environment != environment {
+			continue
+		}
+		if _, found := latest[d.User]; !found {
+			users = append(users, d.User)
+		}
+		latest[d.User] = d
+	}
+
+	for _, user := range users {
+		if d := latest[user]; d.Decision == models.Rejected {
+			return false, fmt.Sprintf("%v was rejected by %v: %v", environment, d.User, d.Comment)
+		}
+	}
+
+	for _, gate := range gates {
+		approvals := 0
+		for _, user := range users {
+			d := latest[user]
+			if d.User != plan.CreatedBy && gateAllows(gate, d.Role) {
+				approvals++
+			}
+		}
+		if approvals < gate.Required {
+			return false, fmt.Sprintf("%v requires %v approvals from %v, has %v",
+				gate.Environment, gate.Required, strings.Join(gate.Roles, ", "), approvals)
+		}
+	}
+
+	return true, ""
+}
+
+// PendingGates returns the unmet gates of the environments targeted by steps,
+// the policy gates apply along with the plan gates
+func PendingGates(plan models.ReleasePlan, steps []models.ReleaseStep, policy []models.ApprovalGate) []string {
+	plan = ApplyPolicy(plan, policy)
+	result := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, step := range steps {
+		if step.Status == models.StepSucceeded || seen[step.Environment] {
+			continue
+		}
+		seen[step.Environment] = true
+		if ok, reason := GateStatus(plan, step.Environment); !ok {
+			result = append(result, reason)
+		}
+	}
+
+	return result
+}
+
+// ValidateDecision checks if the user can approve or reject the plan or policy gates of an environment
+func ValidateDecision(plan models.ReleasePlan, decision models.ApprovalDecision, policy []models.ApprovalGate) error {
+	gates := findGates(ApplyPolicy(plan, policy), decision.Environment)
+	if len(gates) < 1 {
+		return fmt.Errorf("Plan %v has no approval gate for %v", plan.Id, decision.Environment)
+	}
+	if decision.Decision != models.Approved && decision.Decision != models.Rejected {
+		return fmt.Errorf("Invalid decision %v", decision.Decision)
+	}
+	roles := make([]string, 0)
+	for _, gate := range gates {
+		roles = append(roles, gate.Roles...)
+	}
+	if !contains(roles, decision.Role) {
+		return fmt.Errorf("Role %v can't approve %v, allowed roles %v", decision.Role, decision.Environment, roles)
+	}
+	if decision.Decision == models.Approved && decision.User == plan.CreatedBy {
+		return fmt.Errorf("User %v created the plan and can't approve it", decision.User)
+	}
+
+	return nil
+}
+
+// ParseApprovalPolicy splits the ApprovalPolicy config into environment@approvals@role1|role2 entries,
+// the invalid entries are skipped
+func ParseApprovalPolicy(config string) []models.ApprovalGate {
+	result := make([]models.ApprovalGate, 0)
+	for _, entry := range strings.Split(config, ",") {
+		parts := strings.Split(strings.TrimSpace(entry), "@")
+		if len(parts) != 3 || len(parts[0]) < 1 {
+			continue
+		}
+		required, err := strconv.Atoi(parts[1])
+		if err != nil || required < 1 {
+			continue
+		}
+		roles := make([]string, 0)
+		for _, role := range strings.Split(parts[2], "|") {
+			if role = strings.TrimSpace(role); len(role) > 0 {
+				roles = append(roles, role)
+			}
+		}
+		if len(roles) < 1 {
+			continue
+		}
+		result = append(result, models.ApprovalGate{
+			Environment: parts[0],
+			Roles:       roles,
+			Required:    required,
+		})
+	}
+
+	return result
+}
+
+// ApplyPolicy returns the plan with the policy gates added to its own gates,
+// both must be met so a plan can require more approvals but never fewer than the policy
+func ApplyPolicy(plan models.ReleasePlan, policy []models.ApprovalGate) models.ReleasePlan {
+	gates := make([]models.ApprovalGate, 0, len(plan.Gates)+len(policy))
+	gates = append(gates, plan.Gates...)
+	gates = append(gates, policy...)
+	plan.Gates = gates
+	return plan
+}
+
+// ValidateGates checks that each environment has at most one gate with at least one role
+func ValidateGates(gates []models.ApprovalGate) error {
+	envs := make([]string, 0)
+	for _, gate := range gates {
+		if len(gate.Environment) < 1 {
+			return fmt.Errorf("Approval gate environment is required")
+		}
+		if contains(envs, gate.Environment) {
+			return fmt.Errorf("Duplicate approval gate for %v", gate.Environment)
+		}
+		if len(gate.Roles) < 1 {
+			return fmt.Errorf("Approval gate %v requires at least one role", gate.Environment)
+		}
+		if gate.Required < 1 {
+			return fmt.Errorf("Approval gate %v requires at least one approval", gate.Environment)
+		}
+		envs = append(envs, gate.Environment)
+	}
+
+	return nil
+}
+
+func findGates(plan models.ReleasePlan, environment string) []models.ApprovalGate {
+	result := make([]models.ApprovalGate, 0)
+	for _, gate := range plan.Gates {
+		if gate.Environment == environment {
+			result = append(result, gate)
+		}
+	}
+	return result
+}
+
+func gateAllows(gate models.ApprovalGate, role string) bool {
+	return contains(gate.Roles, role)
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stefanprodan/syros/models"
+)
+
+func TestParseApprovalPolicy(t *testing.T) {
+	policy := ParseApprovalPolicy("prod@2@admin|lead, staging@1@admin,bad@0@admin,noroles@1@,short@1")
+	if len(policy) != 2 {
+		t.Fatalf("Policy %+v, want prod and staging", policy)
+	}
+	if p := policy[0]; p.Environment != "prod" || p.Required != 2 || len(p.Roles) != 2 || p.Roles[1] != "lead" {
+		t.Errorf("Prod gate %+v", p)
+	}
+	if p := policy[1]; p.Environment != "staging" || p.Required != 1 || p.Roles[0] != "admin" {
+		t.Errorf("Staging gate %+v", p)
+	}
+}
+
+func TestPendingGatesPolicy(t *testing.T) {
+	policy := ParseApprovalPolicy("prod@2@admin")
+	steps := []models.ReleaseStep{
+		{Environment: "prod", Status: models.StepPending},
+		{Environment: "dev", Status: models.StepPending},
+	}
+	approve := func(user string, role string) models.ApprovalDecision {
+		return models.ApprovalDecision{Environment: "prod", User: user, Role: role, Decision: models.Approved}
+	}
+
+	// a plan without gates can't skip the policy
+	plan := models.ReleasePlan{Id: "p1", CreatedBy: "dev1"}
+	if pending := PendingGates(plan, steps, policy); len(pending) != 1 {
+		t.Fatalf("Pending %v, want the prod policy gate", pending)
+	}
+	if err := ValidateDecision(plan, approve("ops1", "admin"), policy); err != nil {
+		t.Errorf("Policy gate approval rejected %v", err)
+	}
+	if err := ValidateDecision(plan, approve("ops1", "dev"), policy); err == nil {
+		t.Error("Role dev shouldn't approve the prod policy gate")
+	}
+
+	plan.Decisions = []models.ApprovalDecision{approve("ops1", "admin"), approve("ops2", "admin")}
+	if pending := PendingGates(plan, steps, policy); len(pending) != 0 {
+		t.Errorf("Pending %v, want the policy met", pending)
+	}
+
+	// a plan gate adds approvals on top of the policy
+	plan.Gates = []models.ApprovalGate{{Environment: "prod", Roles: []string{"qa"}, Required: 1}}
+	if pending := PendingGates(plan, steps, policy); len(pending) != 1 {
+		t.Errorf("Pending %v, want the qa gate", pending)
+	}
+	plan.Decisions = append(plan.Decisions, approve("qa1", "qa"))
+	if pending := PendingGates(plan, steps, policy); len(pending) != 0 {
+		t.Errorf("Pending %v, want both gates met", pending)
+	}
+
+	// a weaker plan gate doesn't lower the policy
+	plan.Gates = []models.ApprovalGate{{Environment: "prod", Roles: []string{"admin"}, Required: 1}}
+	plan.Decisions = []models.ApprovalDecision{approve("ops1", "admin"), approve("dev1", "admin")}
+	if pending := PendingGates(plan, steps, policy); len(pending) != 1 {
+		t.Errorf("Pending %v, want the policy of two approvals without the creator", pending)
+	}
+}
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/pkg/errors"
+	"github.com/stefanprodan/syros/models"
+	"golang.org/x/crypto/ssh"
+)
+
+// CommandRunner runs a release step command on a host and returns the combined output
+type CommandRunner interface {
+	Run(host string, command string) (string, error)
+}
+
+// SshRunner runs commands over SSH, the host can be host or host:port,
+// commands running longer than the timeout are aborted and the step fails
+type SshRunner struct {
+	Config  *ssh.ClientConfig
+	Port    int
+	Timeout time.Duration
+}
+
+func NewSshRunner(user string, port int, privateKeyPath string, privateKeyPassword string, timeout time.Duration) (*SshRunner, error) {
+	config, err := NewSshClientConfig(user, privateKeyPath, privateKeyPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	runner := &SshRunner{
+		Config:  config,
+		Port:    port,
+		Timeout: timeout,
+	}
+
+	return runner, nil
+}
+
+func (r *SshRunner) Run(host string, command string) (string, error) {
+	port := r.Port
+	if h, p, err := net.SplitHostPort(host); err == nil {
+		host = h
+		port, _ = strconv.Atoi(p)
+	}
+
+	client := &SshClient{
+		Config:  r.Config,
+		Server:  net.JoinHostPort(host, strconv.Itoa(port)),
+		Timeout: r.Timeout,
+	}
+
+	return client.RunCommand(command)
+}
+
+// PlanStore persists the release plans state, implemented by Repository
+type PlanStore interface {
+	ReleasePlan(planId string) (*models.ReleasePlanDto, error)
+	ReleasePlanUpsert(plan models.ReleasePlan) error
+	ReleaseStepUpsert(step models.ReleaseStep) error
+	DueReleasePlans(now time.Time) ([]models.ReleasePlan, error)
+	RunningReleasePlans() ([]models.ReleasePlan, error)
+	DeploymentAllowed(environment string) error
+}
+
+// PlanExecutor runs the steps of a release plan sequentially,
+// stops at the first failed step and can resume a failed plan from that step
+type PlanExecutor struct {
+	Repository PlanStore
+	Runner     CommandRunner
+	Policy     []models.ApprovalGate
+	mu         sync.Mutex
+	running    map[string]bool
+}
+
+func NewPlanExecutor(repo PlanStore, runner CommandRunner, policy []models.ApprovalGate) *PlanExecutor {
+	executor := &PlanExecutor{
+		Repository: repo,
+		Runner:     runner,
+		Policy:     policy,
+		running:    make(map[string]bool),
+	}
+
+	return executor
+}
+
+// Start validates and marks the plan as running then executes the steps in background,
+// override skips the maintenance windows check
+func (e *PlanExecutor) Start(planId string, resume bool, override bool) error {
+	dto, err := e.prepare(planId, resume, override)
+	if err != nil {
+		return err
+	}
+
+	go e.runSteps(dto, resume)
+	return nil
+}
+
+// Run executes the plan and waits for all steps to finish
+func (e *PlanExecutor) Run(planId string, resume bool, override bool) error {
+	dto, err := e.prepare(planId, resume, override)
+	if err != nil {
+		return err
+	}
+
+	return e.runSteps(dto, resume)
+}
+
+// FailInterrupted marks as failed the plans left running by a previous API process,
+// must be called before any plan is started so the interrupted plans can be resumed
+func (e *PlanExecutor) FailInterrupted() error {
+	plans, err := e.Repository.RunningReleasePlans()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	for _, plan := range plans {
+		dto, err := e.Repository.ReleasePlan(plan.Id)
+		if err != nil {
+			return err
+		}
+
+		for _, step := range dto.Steps {
+			if step.Status != models.StepRunning {
+				continue
+			}
+			step.Status = models.StepFailed
+			step.End = now
+			step.Log += "\nInterrupted by API restart"
+			if err := e.Repository.ReleaseStepUpsert(step); err != nil {
+				return err
+			}
+		}
+
+		dto.Plan.Status = models.PlanFailed
+		dto.Plan.End = now
+		dto.Plan.Log += fmt.Sprintf("Interrupted by API restart at %v\n", now.Format(time.RFC3339))
+		if err := e.Repository.ReleasePlanUpsert(dto.Plan); err != nil {
+			return err
+		}
+		log.Warnf("Plan %v was interrupted and marked as failed", plan.Id)
+	}
+
+	return nil
+}
+
+func (e *PlanExecutor) prepare(planId string, resume bool, override bool) (*models.ReleasePlanDto, error) {
+	if e.Runner == nil {
+		return nil, errors.New("Plan executor has no command runner, SSH is not configured")
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.running[planId] {
+		return nil, errors.Errorf("Plan %v is already running", planId)
+	}
+
+	dto, err := e.Repository.ReleasePlan(planId)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Plan %v not found", planId)
+	}
+
+	if resume && dto.Plan.Status != models.PlanFailed {
+		return nil, errors.Errorf("Plan %v is %v, only failed plans can be resumed", planId, dto.Plan.Status)
+	}
+	if !resume && dto.Plan.Status != models.PlanDraft {
+		return nil, errors.Errorf("Plan %v is %v, only draft plans can be executed", planId, dto.Plan.Status)
+	}
+	if len(dto.Steps) < 1 {
+		return nil, errors.Errorf("Plan %v has no steps", planId)
+	}
+	if pending := PendingGates(dto.Plan, dto.Steps, e.Policy); len(pending) > 0 {
+		return nil, errors.Errorf("Plan %v is waiting for approvals: %v", planId, strings.Join(pending, "; "))
+	}
+	if override {
+		dto.Plan.Log += fmt.Sprintf("Maintenance windows overridden at %v\n", time.Now().UTC().Format(time.RFC3339))
+	} else {
+		envs := make([]string, 0)
+		for _, step := range dto.Steps {
+			if step.Status == models.StepSucceeded || contains(envs, step.Environment) {
+				continue
+			}
+			envs = append(envs, step.Environment)
+			if err := e.Repository.DeploymentAllowed(step.Environment); err != nil {
+				return nil, errors.Wrapf(err, "Plan %v can't start", planId)
+			}
+		}
+	}
+
+	dto.Plan.Status = models.PlanRunning
+	if !resume {
+		dto.Plan.Begin = time.Now().UTC()
+	}
+	dto.Plan.End = time.Time{}
+	err = e.Repository.ReleasePlanUpsert(dto.Plan)
+	if err != nil {
+		return nil, err
+	}
+
+	e.running[planId] = true
+	return dto, nil
+}
+
+func (e *PlanExecutor) runSteps(dto *models.ReleasePlanDto, resume bool) error {
+	defer func() {
+		e.mu.Lock()
+		delete(e.running, dto.Plan.Id)
+		e.mu.Unlock()
+	}()
+
+	plan := dto.Plan
+	for _, step := range dto.Steps {
+		// on resume skip the steps that already succeeded
+		if resume && step.Status == models.StepSucceeded {
+			continue
+		}
+
+		err := e.runStep(step)
+		if err != nil {
+			log.Errorf("Plan %v step %v failed %v", plan.Id, step.Order, err)
+			plan.Status = models.PlanFailed
+			plan.End = time.Now().UTC()
+			e.Repository.ReleasePlanUpsert(plan)
+			return err
+		}
+	}
+
+	plan.Status = models.PlanSucceeded
+	plan.End = time.Now().UTC()
+	return e.Repository.ReleasePlanUpsert(plan)
+}
+
+func (e *PlanExecutor) runStep(step models.ReleaseStep) error {
+	step.Status = models.StepRunning
+	step.Begin = time.Now().UTC()
+	step.End = time.Time{}
+	step.Log = ""
+	if err := e.Repository.ReleaseStepUpsert(step); err != nil {
+		return err
+	}
+
+	var output string
+	var err error
+	if len(step.Host) < 1 {
+		err = errors.New("step has no host")
+	} else {
+		log.Infof("Plan %v step %v running on %v", step.ReleaseId, step.Order, step.Host)
+		output, err = e.Runner.Run(step.Host, step.Command)
+	}
+
+	step.End = time.Now().UTC()
+	step.Log = output
+	step.Status = models.StepSucceeded
+	if err != nil {
+		step.Status = models.StepFailed
+		step.Log += fmt.Sprintf("\n%v", err)
+	}
+
+	if uerr := e.Repository.ReleaseStepUpsert(step); uerr != nil {
+		return uerr
+	}
+
+	return err
+}
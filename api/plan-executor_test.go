@@ -0,0 +1,418 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stefanprodan/syros/models"
+	"golang.org/x/crypto/ssh"
+)
+
+// testSshServer runs the exec requests of a single authorized key,
+// "echo <text>" prints the text, "exit <code>" exits with the code and "sleep" blocks until the client disconnects
+type testSshServer struct {
+	listener net.Listener
+	config   *ssh.ServerConfig
+	mu       sync.Mutex
+	commands []string
+}
+
+func newTestSshServer(t *testing.T, authorized ssh.PublicKey) *testSshServer {
+	hostKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hostSigner, err := ssh.NewSignerFromKey(hostKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if string(key.Marshal()) == string(authorized.Marshal()) {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("unknown key for %v", conn.User())
+		},
+	}
+	config.AddHostKey(hostSigner)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := &testSshServer{listener: listener, config: config}
+	go server.serve()
+	return server
+}
+
+func (s *testSshServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *testSshServer) handle(conn net.Conn) {
+	sconn, chans, reqs, err := ssh.NewServerConn(conn, s.config)
+	if err != nil {
+		conn.Close()
+		return
+	}
+	defer sconn.Close()
+	go ssh.DiscardRequests(reqs)
+
+	closed := make(chan struct{})
+	go func() {
+		sconn.Wait()
+		close(closed)
+	}()
+
+	for newChan := range chans {
+		if newChan.ChannelType() != "session" {
+			newChan.Reject(ssh.UnknownChannelType, "unsupported channel")
+			continue
+		}
+		ch, requests, err := newChan.Accept()
+		if err != nil {
+			continue
+		}
+		go func() {
+			for req := range requests {
+				if req.Type != "exec" {
+					req.Reply(false, nil)
+					continue
+				}
+				var payload struct{ Command string }
+				ssh.Unmarshal(req.Payload, &payload)
+				req.Reply(true, nil)
+				s.exec(ch, payload.Command, closed)
+				return
+			}
+		}()
+	}
+}
+
+func (s *testSshServer) exec(ch ssh.Channel, command string, closed chan struct{}) {
+	s.mu.Lock()
+	s.commands = append(s.commands, command)
+	s.mu.Unlock()
+
+	status := 0
+	switch {
+	case strings.HasPrefix(command, "echo "):
+		fmt.Fprintln(ch, strings.TrimPrefix(command, "echo "))
+	case strings.HasPrefix(command, "exit "):
+		status, _ = strconv.Atoi(strings.TrimPrefix(command, "exit "))
+		fmt.Fprintln(ch.Stderr(), "exiting")
+	case command == "sleep":
+		<-closed
+		return
+	default:
+		status = 127
+	}
+
+	ch.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{uint32(status)}))
+	ch.Close()
+}
+
+func (s *testSshServer) Commands() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string{}, s.commands...)
+}
+
+func newTestSshRunner(t *testing.T, timeout time.Duration) (*SshRunner, *testSshServer) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := newTestSshServer(t, signer.PublicKey())
+	runner := &SshRunner{
+		Config: &ssh.ClientConfig{
+			User:            "deploy",
+			Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+			HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+			Timeout:         2 * time.Second,
+		},
+		Port:    22,
+		Timeout: timeout,
+	}
+
+	return runner, server
+}
+
+// memPlanStore keeps the plans and steps in memory
+type memPlanStore struct {
+	mu    sync.Mutex
+	plans map[string]models.ReleasePlan
+	steps map[string]models.ReleaseStep
+}
+
+func newMemPlanStore(plan models.ReleasePlan, steps ...models.ReleaseStep) *memPlanStore {
+	store := &memPlanStore{
+		plans: map[string]models.ReleasePlan{plan.Id: plan},
+		steps: make(map[string]models.ReleaseStep),
+	}
+	for _, step := range steps {
+		step.ReleaseId = plan.Id
+		store.steps[step.Id] = step
+	}
+	return store
+}
+
+func (m *memPlanStore) ReleasePlan(planId string) (*models.ReleasePlanDto, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	plan, ok := m.plans[planId]
+	if !ok {
+		return nil, fmt.Errorf("not found")
+	}
+	steps := make([]models.ReleaseStep, 0)
+	for _, step := range m.steps {
+		if step.ReleaseId == planId {
+			steps = append(steps, step)
+		}
+	}
+	sort.Slice(steps, func(i, j int) bool { return steps[i].Order < steps[j].Order })
+
+	return &models.ReleasePlanDto{Plan: plan, Steps: steps}, nil
+}
+
+func (m *memPlanStore) ReleasePlanUpsert(plan models.ReleasePlan) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.plans[plan.Id] = plan
+	return nil
+}
+
+func (m *memPlanStore) ReleaseStepUpsert(step models.ReleaseStep) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.steps[step.Id] = step
+	return nil
+}
+
+func (m *memPlanStore) DueReleasePlans(now time.Time) ([]models.ReleasePlan, error) {
+	return nil, nil
+}
+
+func (m *memPlanStore) RunningReleasePlans() ([]models.ReleasePlan, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	plans := make([]models.ReleasePlan, 0)
+	for _, plan := range m.plans {
+		if plan.Status == models.PlanRunning {
+			plans = append(plans, plan)
+		}
+	}
+	return plans, nil
+}
+
+func (m *memPlanStore) DeploymentAllowed(environment string) error {
+	return nil
+}
+
+func (m *memPlanStore) step(id string) models.ReleaseStep {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.steps[id]
+}
+
+func (m *memPlanStore) plan(id string) models.ReleasePlan {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.plans[id]
+}
+
+func testStep(id string, order int, host string, command string) models.ReleaseStep {
+	return models.ReleaseStep{
+		Id:          id,
+		Order:       order,
+		Environment: "staging",
+		Host:        host,
+		Command:     command,
+		Status:      models.StepPending,
+	}
+}
+
+func TestPlanExecutorRun(t *testing.T) {
+	runner, server := newTestSshRunner(t, 0)
+	defer server.listener.Close()
+	host := server.listener.Addr().String()
+
+	store := newMemPlanStore(models.ReleasePlan{Id: "p1", Status: models.PlanDraft},
+		testStep("s1", 1, host, "echo one"),
+		testStep("s2", 2, host, "echo two"))
+	executor := NewPlanExecutor(store, runner, nil)
+
+	if err := executor.Run("p1", false, false); err != nil {
+		t.Fatalf("Run failed %v", err)
+	}
+
+	if plan := store.plan("p1"); plan.Status != models.PlanSucceeded || plan.End.IsZero() {
+		t.Errorf("Plan status %v end %v, want succeeded", plan.Status, plan.End)
+	}
+	for id, want := range map[string]string{"s1": "one\n", "s2": "two\n"} {
+		step := store.step(id)
+		if step.Status != models.StepSucceeded || step.Log != want {
+			t.Errorf("Step %v status %v log %q, want succeeded %q", id, step.Status, step.Log, want)
+		}
+	}
+}
+
+func TestPlanExecutorResume(t *testing.T) {
+	runner, server := newTestSshRunner(t, 0)
+	defer server.listener.Close()
+	host := server.listener.Addr().String()
+
+	store := newMemPlanStore(models.ReleasePlan{Id: "p1", Status: models.PlanDraft},
+		testStep("s1", 1, host, "echo one"),
+		testStep("s2", 2, host, "exit 3"),
+		testStep("s3", 3, host, "echo three"))
+	executor := NewPlanExecutor(store, runner, nil)
+
+	if err := executor.Run("p1", false, false); err == nil {
+		t.Fatal("Run should fail on the second step")
+	}
+	if plan := store.plan("p1"); plan.Status != models.PlanFailed {
+		t.Fatalf("Plan status %v, want failed", plan.Status)
+	}
+	if step := store.step("s2"); step.Status != models.StepFailed || !strings.Contains(step.Log, "exiting") {
+		t.Errorf("Step s2 status %v log %q, want failed with output", step.Status, step.Log)
+	}
+	if step := store.step("s3"); step.Status != models.StepPending {
+		t.Errorf("Step s3 status %v, want pending", step.Status)
+	}
+
+	if err := executor.Run("p1", false, false); err == nil {
+		t.Error("A failed plan can't be executed again without resume")
+	}
+
+	fixed := store.step("s2")
+	fixed.Command = "echo fixed"
+	store.ReleaseStepUpsert(fixed)
+
+	if err := executor.Run("p1", true, false); err != nil {
+		t.Fatalf("Resume failed %v", err)
+	}
+	if plan := store.plan("p1"); plan.Status != models.PlanSucceeded {
+		t.Errorf("Plan status %v, want succeeded", plan.Status)
+	}
+
+	want := []string{"echo one", "exit 3", "echo fixed", "echo three"}
+	if got := server.Commands(); strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("Commands %v, want %v", got, want)
+	}
+}
+
+func TestPlanExecutorStepTimeout(t *testing.T) {
+	runner, server := newTestSshRunner(t, 200*time.Millisecond)
+	defer server.listener.Close()
+	host := server.listener.Addr().String()
+
+	store := newMemPlanStore(models.ReleasePlan{Id: "p1", Status: models.PlanDraft},
+		testStep("s1", 1, host, "sleep"))
+	executor := NewPlanExecutor(store, runner, nil)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- executor.Run("p1", false, false)
+	}()
+
+	select {
+	case err := <-done:
+		if err == nil || !strings.Contains(err.Error(), "timed out") {
+			t.Errorf("Run error %v, want timeout", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run didn't return after the command timeout")
+	}
+
+	if plan := store.plan("p1"); plan.Status != models.PlanFailed {
+		t.Errorf("Plan status %v, want failed", plan.Status)
+	}
+}
+
+func TestSshRunnerHandshakeTimeout(t *testing.T) {
+	runner, server := newTestSshRunner(t, 0)
+	defer server.listener.Close()
+	runner.Config.Timeout = 200 * time.Millisecond
+
+	// accepts the TCP connection but never completes the SSH handshake
+	silent, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer silent.Close()
+	go func() {
+		for {
+			conn, err := silent.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	start := time.Now()
+	if _, err := runner.Run(silent.Addr().String(), "echo one"); err == nil {
+		t.Error("Run should fail when the handshake doesn't complete")
+	}
+	if d := time.Since(start); d > 3*time.Second {
+		t.Errorf("Run returned after %v, want the dial timeout", d)
+	}
+}
+
+func TestPlanExecutorFailInterrupted(t *testing.T) {
+	runner, server := newTestSshRunner(t, 0)
+	defer server.listener.Close()
+	host := server.listener.Addr().String()
+
+	s1 := testStep("s1", 1, host, "echo one")
+	s1.Status = models.StepSucceeded
+	s2 := testStep("s2", 2, host, "echo two")
+	s2.Status = models.StepRunning
+	store := newMemPlanStore(models.ReleasePlan{Id: "p1", Status: models.PlanRunning}, s1, s2)
+	executor := NewPlanExecutor(store, runner, nil)
+
+	if err := executor.Run("p1", true, false); err == nil {
+		t.Error("A running plan can't be resumed")
+	}
+
+	if err := executor.FailInterrupted(); err != nil {
+		t.Fatalf("FailInterrupted failed %v", err)
+	}
+	if plan := store.plan("p1"); plan.Status != models.PlanFailed || !strings.Contains(plan.Log, "Interrupted") {
+		t.Errorf("Plan status %v log %q, want failed and interrupted", plan.Status, plan.Log)
+	}
+	if step := store.step("s2"); step.Status != models.StepFailed {
+		t.Errorf("Step s2 status %v, want failed", step.Status)
+	}
+
+	if err := executor.Run("p1", true, false); err != nil {
+		t.Fatalf("Resume failed %v", err)
+	}
+	if got := server.Commands(); strings.Join(got, "|") != "echo two" {
+		t.Errorf("Commands %v, want only the interrupted step", got)
+	}
+}
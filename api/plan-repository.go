@@ -0,0 +1,166 @@
+package main
+
+import (
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/stefanprodan/syros/models"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func (repo *Repository) AllReleasePlans() ([]models.ReleasePlan, error) {
+	s := repo.Session.Copy()
+	defer s.Close()
+
+	c := s.DB(repo.Config.Database).C("release_plans")
+	plans := []models.ReleasePlan{}
+	err := c.Find(nil).Sort("-timestamp").Limit(1000).All(&plans)
+	if err != nil {
+		log.Errorf("Repository AllReleasePlans query failed %v", err)
+		return nil, err
+	}
+
+	return plans, nil
+}
+
+func (repo *Repository) ReleasePlan(planId string) (*models.ReleasePlanDto, error) {
+	s := repo.Session.Copy()
+	defer s.Close()
+
+	c := s.DB(repo.Config.Database).C("release_plans")
+	plan := models.ReleasePlan{}
+	err := c.FindId(planId).One(&plan)
+	if err != nil {
+		log.Errorf("Repository ReleasePlan query failed for planId %v %v", planId, err)
+		return nil, err
+	}
+
+	k := s.DB(repo.Config.Database).C("release_steps")
+	steps := []models.ReleaseStep{}
+	err = k.Find(bson.M{"releaseplan_id": planId}).Sort("order").All(&steps)
+	if err != nil {
+		log.Errorf("Repository ReleasePlan steps query failed for planId %v %v", planId, err)
+		return nil, err
+	}
+
+	payload := &models.ReleasePlanDto{
+		Plan:  plan,
+		Steps: steps,
+	}
+
+	return payload, nil
+}
+
+// DueReleasePlans returns the draft plans scheduled to start before the given time
+func (repo *Repository) DueReleasePlans(now time.Time) ([]models.ReleasePlan, error) {
+	s := repo.Session.Copy()
+	defer s.Close()
+
+	c := s.DB(repo.Config.Database).C("release_plans")
+	plans := []models.ReleasePlan{}
+	query := bson.M{
+		"status":       models.PlanDraft,
+		"scheduled_at": bson.M{"$gt": time.Time{}, "$lte": now},
+	}
+	err := c.Find(query).Sort("scheduled_at").All(&plans)
+	if err != nil {
+		log.Errorf("Repository DueReleasePlans query failed %v", err)
+		return nil, err
+	}
+
+	return plans, nil
+}
+
+// RunningReleasePlans returns the plans marked as running
+func (repo *Repository) RunningReleasePlans() ([]models.ReleasePlan, error) {
+	s := repo.Session.Copy()
+	defer s.Close()
+
+	c := s.DB(repo.Config.Database).C("release_plans")
+	plans := []models.ReleasePlan{}
+	err := c.Find(bson.M{"status": models.PlanRunning}).All(&plans)
+	if err != nil {
+		log.Errorf("Repository RunningReleasePlans query failed %v", err)
+		return nil, err
+	}
+
+	return plans, nil
+}
+
+func (repo *Repository) ReleasePlanUpsert(plan models.ReleasePlan) error {
+	s := repo.Session.Copy()
+	defer s.Close()
+
+	c := s.DB(repo.Config.Database).C("release_plans")
+	_, err := c.UpsertId(plan.Id, &plan)
+	if err != nil {
+		log.Errorf("Repository ReleasePlanUpsert failed for planId %v %v", plan.Id, err)
+		return err
+	}
+
+	return nil
+}
+
+func (repo *Repository) ReleasePlanDelete(planId string) error {
+	s := repo.Session.Copy()
+	defer s.Close()
+
+	k := s.DB(repo.Config.Database).C("release_steps")
+	_, err := k.RemoveAll(bson.M{"releaseplan_id": planId})
+	if err != nil {
+		log.Errorf("Repository ReleasePlanDelete steps remove failed for planId %v %v", planId, err)
+		return err
+	}
+
+	c := s.DB(repo.Config.Database).C("release_plans")
+	err = c.RemoveId(planId)
+	if err != nil {
+		log.Errorf("Repository ReleasePlanDelete failed for planId %v %v", planId, err)
+		return err
+	}
+
+	return nil
+}
+
+// ReleasePlanDecision appends an approval decision to the plan
+func (repo *Repository) ReleasePlanDecision(planId string, decision models.ApprovalDecision) error {
+	s := repo.Session.Copy()
+	defer s.Close()
+
+	c := s.DB(repo.Config.Database).C("release_plans")
+	err := c.UpdateId(planId, bson.M{"$push": bson.M{"decisions": decision}})
+	if err != nil {
+		log.Errorf("Repository ReleasePlanDecision failed for planId %v %v", planId, err)
+		return err
+	}
+
+	return nil
+}
+
+func (repo *Repository) ReleaseStepUpsert(step models.ReleaseStep) error {
+	s := repo.Session.Copy()
+	defer s.Close()
+
+	c := s.DB(repo.Config.Database).C("release_steps")
+	_, err := c.UpsertId(step.Id, &step)
+	if err != nil {
+		log.Errorf("Repository ReleaseStepUpsert failed for stepId %v %v", step.Id, err)
+		return err
+	}
+
+	return nil
+}
+
+func (repo *Repository) ReleaseStepDelete(planId string, stepId string) error {
+	s := repo.Session.Copy()
+	defer s.Close()
+
+	c := s.DB(repo.Config.Database).C("release_steps")
+	err := c.Remove(bson.M{"_id": stepId, "releaseplan_id": planId})
+	if err != nil {
+		log.Errorf("Repository ReleaseStepDelete failed for stepId %v %v", stepId, err)
+		return err
+	}
+
+	return nil
+}
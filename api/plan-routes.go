@@ -0,0 +1,374 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/jwtauth"
+	"github.com/go-chi/render"
+	"github.com/stefanprodan/syros/models"
+)
+
+func (s *HttpServer) planRoutes() chi.Router {
+	r := chi.NewRouter()
+
+	// JWT protected
+	r.Group(func(r chi.Router) {
+		r.Use(jwtauth.Verifier(s.TokenAuth))
+		r.Use(jwtauth.Authenticator)
+
+		r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+			plans, err := s.Repository.AllReleasePlans()
+			if err != nil {
+				render.Status(r, http.StatusInternalServerError)
+				render.PlainText(w, r, err.Error())
+				return
+			}
+			render.JSON(w, r, plans)
+		})
+
+		r.Post("/", func(w http.ResponseWriter, r *http.Request) {
+			data := ReleasePlanForm{}
+			if err := render.Bind(r, &data); err != nil {
+				render.Status(r, http.StatusBadRequest)
+				render.PlainText(w, r, err.Error())
+				return
+			}
+
+			plan := data.ReleasePlan
+			plan.Id, _ = models.NewUUID()
+			plan.Decisions = []models.ApprovalDecision{}
+			plan.Status = models.PlanDraft
+			plan.CreatedBy = requestUser(r)
+			plan.Timestamp = time.Now().UTC()
+			plan.Begin = time.Time{}
+			plan.End = time.Time{}
+			plan.Log = ""
+
+			if err := s.Repository.ReleasePlanUpsert(plan); err != nil {
+				render.Status(r, http.StatusInternalServerError)
+				render.PlainText(w, r, err.Error())
+				return
+			}
+			render.Status(r, http.StatusCreated)
+			render.JSON(w, r, plan)
+		})
+
+		r.Route("/{planID}", func(r chi.Router) {
+			r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+				payload, err := s.Repository.ReleasePlan(chi.URLParam(r, "planID"))
+				if err != nil {
+					render.Status(r, http.StatusNotFound)
+					render.PlainText(w, r, err.Error())
+					return
+				}
+				render.JSON(w, r, payload)
+			})
+
+			r.Put("/", func(w http.ResponseWriter, r *http.Request) {
+				payload, ok := s.editablePlan(w, r)
+				if !ok {
+					return
+				}
+
+				data := ReleasePlanForm{}
+				if err := render.Bind(r, &data); err != nil {
+					render.Status(r, http.StatusBadRequest)
+					render.PlainText(w, r, err.Error())
+					return
+				}
+
+				plan := payload.Plan
+				plan.Name = data.Name
+				plan.TicketId = data.TicketId
+				plan.Gates = data.Gates
+				plan.Scheduled = data.Scheduled
+				plan.Decisions = []models.ApprovalDecision{}
+				if err := s.Repository.ReleasePlanUpsert(plan); err != nil {
+					render.Status(r, http.StatusInternalServerError)
+					render.PlainText(w, r, err.Error())
+					return
+				}
+				render.JSON(w, r, plan)
+			})
+
+			r.Delete("/", func(w http.ResponseWriter, r *http.Request) {
+				payload, ok := s.editablePlan(w, r)
+				if !ok {
+					return
+				}
+
+				if err := s.Repository.ReleasePlanDelete(payload.Plan.Id); err != nil {
+					render.Status(r, http.StatusInternalServerError)
+					render.PlainText(w, r, err.Error())
+					return
+				}
+				render.Status(r, http.StatusNoContent)
+				render.PlainText(w, r, "")
+			})
+
+			r.Post("/steps", func(w http.ResponseWriter, r *http.Request) {
+				payload, ok := s.editablePlan(w, r)
+				if !ok {
+					return
+				}
+
+				data := ReleaseStepForm{}
+				if err := render.Bind(r, &data); err != nil {
+					render.Status(r, http.StatusBadRequest)
+					render.PlainText(w, r, err.Error())
+					return
+				}
+
+				step := data.ReleaseStep
+				step.Id, _ = models.NewUUID()
+				step.ReleaseId = payload.Plan.Id
+				step.TicketId = payload.Plan.TicketId
+				step.Status = models.StepPending
+				step.Log = ""
+				if step.Order < 1 {
+					step.Order = len(payload.Steps) + 1
+				}
+
+				if err := s.Repository.ReleaseStepUpsert(step); err != nil {
+					render.Status(r, http.StatusInternalServerError)
+					render.PlainText(w, r, err.Error())
+					return
+				}
+				if err := s.clearDecisions(payload.Plan); err != nil {
+					render.Status(r, http.StatusInternalServerError)
+					render.PlainText(w, r, err.Error())
+					return
+				}
+				render.Status(r, http.StatusCreated)
+				render.JSON(w, r, step)
+			})
+
+			r.Put("/steps/{stepID}", func(w http.ResponseWriter, r *http.Request) {
+				payload, ok := s.editablePlan(w, r)
+				if !ok {
+					return
+				}
+
+				stepID := chi.URLParam(r, "stepID")
+				var step *models.ReleaseStep
+				for i := range payload.Steps {
+					if payload.Steps[i].Id == stepID {
+						step = &payload.Steps[i]
+					}
+				}
+				if step == nil {
+					render.Status(r, http.StatusNotFound)
+					render.PlainText(w, r, "Step "+stepID+" not found")
+					return
+				}
+
+				data := ReleaseStepForm{}
+				if err := render.Bind(r, &data); err != nil {
+					render.Status(r, http.StatusBadRequest)
+					render.PlainText(w, r, err.Error())
+					return
+				}
+
+				step.Name = data.Name
+				step.Environment = data.Environment
+				step.Host = data.Host
+				step.Command = data.Command
+				if data.Order > 0 {
+					step.Order = data.Order
+				}
+				if err := s.Repository.ReleaseStepUpsert(*step); err != nil {
+					render.Status(r, http.StatusInternalServerError)
+					render.PlainText(w, r, err.Error())
+					return
+				}
+				if err := s.clearDecisions(payload.Plan); err != nil {
+					render.Status(r, http.StatusInternalServerError)
+					render.PlainText(w, r, err.Error())
+					return
+				}
+				render.JSON(w, r, step)
+			})
+
+			r.Delete("/steps/{stepID}", func(w http.ResponseWriter, r *http.Request) {
+				payload, ok := s.editablePlan(w, r)
+				if !ok {
+					return
+				}
+
+				if err := s.Repository.ReleaseStepDelete(payload.Plan.Id, chi.URLParam(r, "stepID")); err != nil {
+					render.Status(r, http.StatusNotFound)
+					render.PlainText(w, r, err.Error())
+					return
+				}
+				if err := s.clearDecisions(payload.Plan); err != nil {
+					render.Status(r, http.StatusInternalServerError)
+					render.PlainText(w, r, err.Error())
+					return
+				}
+				render.Status(r, http.StatusNoContent)
+				render.PlainText(w, r, "")
+			})
+
+			r.Post("/approve", func(w http.ResponseWriter, r *http.Request) {
+				s.decidePlan(w, r, models.Approved)
+			})
+
+			r.Post("/reject", func(w http.ResponseWriter, r *http.Request) {
+				s.decidePlan(w, r, models.Rejected)
+			})
+
+			r.Post("/execute", func(w http.ResponseWriter, r *http.Request) {
+				override, err := s.windowOverride(r, requestRole(r))
+				if err != nil {
+					render.Status(r, http.StatusForbidden)
+					render.PlainText(w, r, err.Error())
+					return
+				}
+				if err := s.Executor.Start(chi.URLParam(r, "planID"), false, override); err != nil {
+					render.Status(r, http.StatusConflict)
+					render.PlainText(w, r, err.Error())
+					return
+				}
+				render.Status(r, http.StatusAccepted)
+				render.PlainText(w, r, "Plan execution started")
+			})
+
+			r.Post("/resume", func(w http.ResponseWriter, r *http.Request) {
+				override, err := s.windowOverride(r, requestRole(r))
+				if err != nil {
+					render.Status(r, http.StatusForbidden)
+					render.PlainText(w, r, err.Error())
+					return
+				}
+				if err := s.Executor.Start(chi.URLParam(r, "planID"), true, override); err != nil {
+					render.Status(r, http.StatusConflict)
+					render.PlainText(w, r, err.Error())
+					return
+				}
+				render.Status(r, http.StatusAccepted)
+				render.PlainText(w, r, "Plan execution resumed")
+			})
+		})
+	})
+
+	return r
+}
+
+// editablePlan loads the plan from the URL and rejects the request if the plan is not a draft
+func (s *HttpServer) editablePlan(w http.ResponseWriter, r *http.Request) (*models.ReleasePlanDto, bool) {
+	payload, err := s.Repository.ReleasePlan(chi.URLParam(r, "planID"))
+	if err != nil {
+		render.Status(r, http.StatusNotFound)
+		render.PlainText(w, r, err.Error())
+		return nil, false
+	}
+
+	if payload.Plan.Status != models.PlanDraft {
+		render.Status(r, http.StatusConflict)
+		render.PlainText(w, r, "Only draft plans can be changed, plan is "+payload.Plan.Status)
+		return nil, false
+	}
+
+	return payload, true
+}
+
+// clearDecisions drops the approvals and rejections of a plan after its steps changed,
+// the approvers have to sign off the new version
+func (s *HttpServer) clearDecisions(plan models.ReleasePlan) error {
+	if len(plan.Decisions) < 1 {
+		return nil
+	}
+	plan.Decisions = []models.ApprovalDecision{}
+	return s.Repository.ReleasePlanUpsert(plan)
+}
+
+// decidePlan records an approve or reject decision for a plan gate,
+// decisions are accepted until the plan starts running
+func (s *HttpServer) decidePlan(w http.ResponseWriter, r *http.Request, decision string) {
+	payload, err := s.Repository.ReleasePlan(chi.URLParam(r, "planID"))
+	if err != nil {
+		render.Status(r, http.StatusNotFound)
+		render.PlainText(w, r, err.Error())
+		return
+	}
+
+	plan := payload.Plan
+	if plan.Status != models.PlanDraft && plan.Status != models.PlanFailed {
+		render.Status(r, http.StatusConflict)
+		render.PlainText(w, r, "Plan is "+plan.Status+", decisions are accepted only for draft or failed plans")
+		return
+	}
+
+	data := ApprovalForm{}
+	if err := render.Bind(r, &data); err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.PlainText(w, r, err.Error())
+		return
+	}
+
+	rec := models.ApprovalDecision{
+		Environment: data.Environment,
+		User:        requestUser(r),
+		Role:        requestRole(r),
+		Decision:    decision,
+		Comment:     data.Comment,
+		Timestamp:   time.Now().UTC(),
+	}
+	if err := ValidateDecision(plan, rec, s.Executor.Policy); err != nil {
+		render.Status(r, http.StatusForbidden)
+		render.PlainText(w, r, err.Error())
+		return
+	}
+
+	if err := s.Repository.ReleasePlanDecision(plan.Id, rec); err != nil {
+		render.Status(r, http.StatusInternalServerError)
+		render.PlainText(w, r, err.Error())
+		return
+	}
+
+	plan.Decisions = append(plan.Decisions, rec)
+	render.JSON(w, r, plan)
+}
+
+type ApprovalForm struct {
+	Environment string `json:"environment"`
+	Comment     string `json:"comment"`
+}
+
+func (p *ApprovalForm) Bind(r *http.Request) error {
+	if len(p.Environment) < 1 {
+		return errors.New("environment is required")
+	}
+	return nil
+}
+
+type ReleasePlanForm struct {
+	models.ReleasePlan
+}
+
+func (p *ReleasePlanForm) Bind(r *http.Request) error {
+	if len(p.Name) < 1 {
+		return errors.New("name is required")
+	}
+	if !p.Scheduled.IsZero() && p.Scheduled.Before(time.Now()) {
+		return errors.New("scheduled_at must be in the future")
+	}
+	return ValidateGates(p.Gates)
+}
+
+type ReleaseStepForm struct {
+	models.ReleaseStep
+}
+
+func (p *ReleaseStepForm) Bind(r *http.Request) error {
+	if len(p.Host) < 1 {
+		return errors.New("host is required")
+	}
+	if len(p.Command) < 1 {
+		return errors.New("command is required")
+	}
+	return nil
+}
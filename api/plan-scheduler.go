@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/robfig/cron"
+)
+
+// PlanScheduler starts the release plans that are due,
+// a plan that can't be started is unscheduled and the reason is appended to the plan log
+type PlanScheduler struct {
+	Executor *PlanExecutor
+	Cron     *cron.Cron
+}
+
+func NewPlanScheduler(executor *PlanExecutor, cron *cron.Cron) *PlanScheduler {
+	scheduler := &PlanScheduler{
+		Executor: executor,
+		Cron:     cron,
+	}
+
+	return scheduler
+}
+
+func (p *PlanScheduler) Start() {
+	p.Cron.AddFunc("@every 1m", func() {
+		p.startDuePlans()
+	})
+}
+
+func (p *PlanScheduler) startDuePlans() {
+	repo := p.Executor.Repository
+	plans, err := repo.DueReleasePlans(time.Now().UTC())
+	if err != nil {
+		return
+	}
+
+	for _, plan := range plans {
+		log.Infof("Starting scheduled plan %v due at %v", plan.Id, plan.Scheduled)
+		err := p.Executor.Start(plan.Id, false, false)
+		if err == nil {
+			continue
+		}
+
+		log.Errorf("Scheduled plan %v start failed %v", plan.Id, err)
+		dto, derr := repo.ReleasePlan(plan.Id)
+		if derr != nil {
+			continue
+		}
+		dto.Plan.Log += fmt.Sprintf("Scheduled start at %v failed: %v\n", plan.Scheduled.Format(time.RFC3339), err)
+		dto.Plan.Scheduled = time.Time{}
+		repo.ReleasePlanUpsert(dto.Plan)
+	}
+}
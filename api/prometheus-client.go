@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PrometheusClient runs range queries against the Prometheus HTTP API v1
+type PrometheusClient struct {
+	Address string
+	client  *http.Client
+}
+
+func NewPrometheusClient(address string) *PrometheusClient {
+	return &PrometheusClient{
+		Address: strings.TrimSuffix(address, "/"),
+		client: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+type PrometheusSample struct {
+	Metric map[string]string
+	Points [][2]float64
+}
+
+func (p *PrometheusClient) QueryRange(query string, start time.Time, end time.Time, step time.Duration) ([]PrometheusSample, error) {
+	params := url.Values{}
+	params.Set("query", query)
+	params.Set("start", strconv.FormatInt(start.Unix(), 10))
+	params.Set("end", strconv.FormatInt(end.Unix(), 10))
+	params.Set("step", strconv.FormatInt(int64(step/time.Second), 10))
+
+	resp, err := p.client.Get(p.Address + "/api/v1/query_range?" + params.Encode())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	result := struct {
+		Status    string `json:"status"`
+		ErrorType string `json:"errorType"`
+		Error     string `json:"error"`
+		Data      struct {
+			ResultType string `json:"resultType"`
+			Result     []struct {
+				Metric map[string]string `json:"metric"`
+				Values [][]interface{}   `json:"values"`
+			} `json:"result"`
+		} `json:"data"`
+	}{}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("Prometheus query_range returned %v %v", resp.StatusCode, string(body))
+	}
+	if result.Status != "success" {
+		return nil, fmt.Errorf("Prometheus query_range %v error %v %v", query, result.ErrorType, result.Error)
+	}
+
+	samples := make([]PrometheusSample, 0, len(result.Data.Result))
+	for _, r := range result.Data.Result {
+		sample := PrometheusSample{
+			Metric: r.Metric,
+			Points: make([][2]float64, 0, len(r.Values)),
+		}
+		for _, v := range r.Values {
+			if len(v) != 2 {
+				continue
+			}
+			ts, ok := v[0].(float64)
+			if !ok {
+				continue
+			}
+			str, ok := v[1].(string)
+			if !ok {
+				continue
+			}
+			// NaN and Inf values can't be encoded as JSON
+			val, err := strconv.ParseFloat(str, 64)
+			if err != nil || math.IsNaN(val) || math.IsInf(val, 0) {
+				continue
+			}
+			sample.Points = append(sample.Points, [2]float64{ts, val})
+		}
+		samples = append(samples, sample)
+	}
+
+	return samples, nil
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newPrometheusFake answers query_range by the query text, the queries containing
+// "error" fail with a PromQL error, "proxy" with a non JSON body and "mountpoint" with two series
+func newPrometheusFake(t *testing.T, queries *[]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/query_range" {
+			http.NotFound(w, r)
+			return
+		}
+		q := r.URL.Query()
+		query := q.Get("query")
+		if queries != nil {
+			*queries = append(*queries, query)
+		}
+		if len(q.Get("start")) < 1 || len(q.Get("end")) < 1 || len(q.Get("step")) < 1 {
+			t.Errorf("Range params missing %v", q)
+		}
+
+		switch {
+		case strings.Contains(query, "error"):
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"status":"error","errorType":"bad_data","error":"parse error at char 5"}`))
+		case strings.Contains(query, "proxy"):
+			w.WriteHeader(http.StatusBadGateway)
+			w.Write([]byte(`<html>Bad Gateway</html>`))
+		case strings.Contains(query, "node_filesystem"):
+			w.Write([]byte(`{"status":"success","data":{"resultType":"matrix","result":[
+				{"metric":{"mountpoint":"/"},"values":[[1500000000,"40"]]},
+				{"metric":{"mountpoint":"/data"},"values":[[1500000000,"75.5"]]}]}}`))
+		default:
+			w.Write([]byte(`{"status":"success","data":{"resultType":"matrix","result":[
+				{"metric":{"instance":"web-01:9100"},"values":[
+					[1500000000,"1.5"],[1500000015,"NaN"],[1500000030,"+Inf"],[1500000045,"-Inf"],
+					[1500000060,"bad"],[1500000075],["1500000090","2"],[1500000105,3],[1500000120,"2.25"]]}]}}`))
+		}
+	}))
+}
+
+func TestPrometheusQueryRange(t *testing.T) {
+	ts := newPrometheusFake(t, nil)
+	defer ts.Close()
+
+	prom := NewPrometheusClient(ts.URL + "/")
+	end := time.Unix(1500000120, 0)
+	samples, err := prom.QueryRange("up", end.Add(-2*time.Minute), end, 15*time.Second)
+	if err != nil {
+		t.Fatalf("QueryRange failed %v", err)
+	}
+
+	if len(samples) != 1 || samples[0].Metric["instance"] != "web-01:9100" {
+		t.Fatalf("Samples %+v, want one series of web-01", samples)
+	}
+
+	// NaN, Inf, malformed and short points are dropped
+	want := [][2]float64{{1500000000, 1.5}, {1500000120, 2.25}}
+	points := samples[0].Points
+	if len(points) != len(want) {
+		t.Fatalf("Points %v, want %v", points, want)
+	}
+	for i := range want {
+		if points[i] != want[i] {
+			t.Errorf("Point %v is %v, want %v", i, points[i], want[i])
+		}
+	}
+
+	// the points must be JSON encodable
+	if _, err := json.Marshal(samples); err != nil {
+		t.Errorf("Samples can't be encoded %v", err)
+	}
+}
+
+func TestPrometheusQueryRangeErrors(t *testing.T) {
+	ts := newPrometheusFake(t, nil)
+	defer ts.Close()
+
+	prom := NewPrometheusClient(ts.URL)
+	end := time.Now()
+
+	_, err := prom.QueryRange("error(", end.Add(-time.Hour), end, time.Minute)
+	if err == nil || !strings.Contains(err.Error(), "bad_data") || !strings.Contains(err.Error(), "parse error") {
+		t.Errorf("QueryRange error %v, want the PromQL error", err)
+	}
+
+	_, err = prom.QueryRange("proxy", end.Add(-time.Hour), end, time.Minute)
+	if err == nil || !strings.Contains(err.Error(), "502") {
+		t.Errorf("QueryRange error %v, want the HTTP status", err)
+	}
+
+	ts.Close()
+	if _, err := prom.QueryRange("up", end.Add(-time.Hour), end, time.Minute); err == nil {
+		t.Error("QueryRange should fail when Prometheus is down")
+	}
+}
@@ -1,37 +1,109 @@
 package main
 
 import (
+	"time"
+
 	log "github.com/Sirupsen/logrus"
 	"github.com/stefanprodan/syros/models"
 	"gopkg.in/mgo.v2/bson"
 )
 
-func (repo *Repository) AllReleases() ([]models.Release, error) {
+func (repo *Repository) AllReleases(query ListQuery) ([]models.Release, int, error) {
+	rels := []models.Release{}
+	total, err := repo.list("releases", query, &rels)
+	if err != nil {
+		log.Errorf("Repository AllReleases query failed %v", err)
+		return nil, 0, err
+	}
+
+	return rels, total, nil
+}
+
+func (repo *Repository) ReleaseDeployments(releaseId string) ([]models.Deployment, error) {
+	s := repo.Session.Copy()
+	defer s.Close()
+
+	c := s.DB(repo.Config.Database).C("deployments")
+	deployments := []models.Deployment{}
+	err := c.Find(bson.M{"release_id": releaseId}).Sort("-begin").All(&deployments)
+	if err != nil {
+		log.Errorf("Repository ReleaseDeployments query failed %v", err)
+		return nil, err
+	}
+
+	return deployments, nil
+}
+
+// DeploymentCommitsUpdate stores the git commits linked to a deployment
+func (repo *Repository) DeploymentCommitsUpdate(deploymentId string, commits []models.GitCommit) error {
+	s := repo.Session.Copy()
+	defer s.Close()
+
+	c := s.DB(repo.Config.Database).C("deployments")
+	err := c.UpdateId(deploymentId, bson.M{"$set": bson.M{
+		"commits":      commits,
+		"commit_count": len(commits),
+		"authors":      CommitAuthors(commits),
+	}})
+	if err != nil {
+		log.Errorf("Repository DeploymentCommitsUpdate failed for deployment %v %v", deploymentId, err)
+		return err
+	}
+
+	return nil
+}
+
+func (repo *Repository) Release(releaseId string) (*models.Release, error) {
 	s := repo.Session.Copy()
 	defer s.Close()
 
 	c := s.DB(repo.Config.Database).C("releases")
-	rels := []models.Release{}
-	err := c.Find(nil).Sort("end").Limit(1000).All(&rels)
+	rel := models.Release{}
+	err := c.FindId(releaseId).One(&rel)
 	if err != nil {
-		log.Errorf("Repository AllReleases query failed %v", err)
+		log.Errorf("Repository Release query failed for release %v %v", releaseId, err)
 		return nil, err
 	}
 
-	return rels, nil
+	return &rel, nil
 }
 
-func (repo *Repository) ReleaseDeployments(releaseId string) ([]models.Deployment, error) {
+// FinishedDeployments returns the deployments finished after the given time,
+// optionally filtered by service and environment
+func (repo *Repository) FinishedDeployments(from time.Time, service string, environment string) ([]models.Deployment, error) {
 	s := repo.Session.Copy()
 	defer s.Close()
 
+	// the legacy deployments have no end and are matched by timestamp
+	query := bson.M{
+		"$or": []bson.M{
+			{"end": bson.M{"$gte": from}},
+			{"end": bson.M{"$in": []interface{}{nil, time.Time{}}}, "timestamp": bson.M{"$gte": from}},
+		},
+		"status": bson.M{"$ne": models.DeploymentRunning},
+	}
+	if len(service) > 0 {
+		query["service_name"] = service
+	}
+	if len(environment) > 0 {
+		query["environment"] = environment
+	}
+
 	c := s.DB(repo.Config.Database).C("deployments")
 	deployments := []models.Deployment{}
-	err := c.Find(bson.M{"release_id": releaseId}).Sort("-end").All(&deployments)
+	err := c.Find(query).Select(bson.M{"log": 0, "env": 0, "labels": 0}).Sort("begin").All(&deployments)
 	if err != nil {
-		log.Errorf("Repository ReleaseDeployments query failed %v", err)
+		log.Errorf("Repository FinishedDeployments query failed %v", err)
 		return nil, err
 	}
 
+	// the legacy deployments are placed at their timestamp
+	for i, dep := range deployments {
+		if dep.End.IsZero() {
+			deployments[i].Begin = dep.Timestamp
+			deployments[i].End = dep.Timestamp
+		}
+	}
+
 	return deployments, nil
 }
@@ -2,6 +2,7 @@ package main
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/go-chi/chi"
 	"github.com/go-chi/jwtauth"
@@ -17,7 +18,14 @@ func (s *HttpServer) releaseRoutes() chi.Router {
 		r.Use(jwtauth.Authenticator)
 
 		r.Get("/all", func(w http.ResponseWriter, r *http.Request) {
-			rels, err := s.Repository.AllReleases()
+			query, err := ParseListQuery(r, releasesListSpec, s.Config.PageSize)
+			if err != nil {
+				render.Status(r, http.StatusBadRequest)
+				render.PlainText(w, r, err.Error())
+				return
+			}
+
+			rels, total, err := s.Repository.AllReleases(query)
 			if err != nil {
 				render.Status(r, http.StatusInternalServerError)
 				render.PlainText(w, r, err.Error())
@@ -28,39 +36,98 @@ func (s *HttpServer) releaseRoutes() chi.Router {
 				Labels: make([]string, 0),
 				Values: make([]int64, 0),
 			}
-			deployments := 0
+			deployments, succeeded, leadTime, finished := 0, 0, int64(0), 0
 			// aggregate chart per day based on release end date
 			for _, cont := range rels {
 				deployments += cont.Deployments
-				date := cont.End.Format("06-01")
-				found := -1
-				for i, s := range chart.Labels {
-					if s == date {
-						found = i
-						break
-					}
-				}
-				if found > -1 {
-					chart.Values[found] += int64(cont.Deployments)
-				} else {
-					chart.Labels = append(chart.Labels, date)
-					chart.Values = append(chart.Values, int64(cont.Deployments))
+				succeeded += cont.Succeeded
+				if cont.Deployments > 0 {
+					leadTime += cont.LeadTime
+					finished++
 				}
+				chartAdd(&chart, cont.End.Format("06-01"), int64(cont.Deployments))
 			}
 
 			data := struct {
 				Releases    []models.Release `json:"releases"`
 				Chart       models.ChartDto  `json:"chart"`
 				Deployments int              `json:"deployments"`
+				SuccessRate float64          `json:"success_rate"`
+				LeadTime    int64            `json:"lead_time"`
 			}{
 				Releases:    rels,
 				Chart:       chart,
 				Deployments: deployments,
 			}
+			if deployments > 0 {
+				data.SuccessRate = float64(succeeded) * 100 / float64(deployments)
+			}
+			if finished > 0 {
+				data.LeadTime = leadTime / int64(finished)
+			}
 
+			setPageHeaders(w, r, query, total)
 			render.JSON(w, r, data)
 		})
 
+		r.Get("/metrics", func(w http.ResponseWriter, r *http.Request) {
+			q := r.URL.Query()
+			window := q.Get("window")
+			if len(window) < 1 {
+				window = "30d"
+			}
+			duration, err := ParseDeliveryWindow(window)
+			if err != nil {
+				render.Status(r, http.StatusBadRequest)
+				render.PlainText(w, r, err.Error())
+				return
+			}
+
+			group := q.Get("group")
+			if group != "" && group != "service" && group != "environment" {
+				render.Status(r, http.StatusBadRequest)
+				render.PlainText(w, r, "Invalid group "+group+" allowed values service or environment")
+				return
+			}
+
+			to := time.Now().UTC()
+			from := to.Add(-duration)
+			deployments, err := s.Repository.FinishedDeployments(from, q.Get("service"), q.Get("environment"))
+			if err != nil {
+				render.Status(r, http.StatusInternalServerError)
+				render.PlainText(w, r, err.Error())
+				return
+			}
+
+			render.JSON(w, r, DeliveryMetrics(deployments, group, from, to))
+		})
+
+		r.Get("/{releaseID}/changelog", func(w http.ResponseWriter, r *http.Request) {
+			releaseID := chi.URLParam(r, "releaseID")
+
+			rel, err := s.Repository.Release(releaseID)
+			if err != nil {
+				render.Status(r, http.StatusNotFound)
+				render.PlainText(w, r, err.Error())
+				return
+			}
+
+			deployments, err := s.Repository.ReleaseDeployments(releaseID)
+			if err != nil {
+				render.Status(r, http.StatusInternalServerError)
+				render.PlainText(w, r, err.Error())
+				return
+			}
+
+			changelog := BuildChangelog(*rel, deployments)
+			if r.URL.Query().Get("format") == "markdown" {
+				w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+				w.Write([]byte(ChangelogMarkdown(changelog)))
+				return
+			}
+			render.JSON(w, r, changelog)
+		})
+
 		r.Get("/{releaseID}", func(w http.ResponseWriter, r *http.Request) {
 			releaseID := chi.URLParam(r, "releaseID")
 
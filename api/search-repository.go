@@ -0,0 +1,178 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/stefanprodan/syros/models"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// searchTypes lists the result types and the collections backing them
+var searchTypes = map[string]string{
+	"host":          "hosts",
+	"container":     "containers",
+	"consul_check":  "checks",
+	"cluster_check": "cluster_checks",
+	"vm":            "vsphere_vms",
+	"release":       "releases",
+}
+
+// Search runs a text query against every collection in types (all if empty)
+// and returns the results ranked by text score
+func (repo *Repository) Search(text string, types []string, limit int) ([]models.SearchResult, error) {
+	s := repo.Session.Copy()
+	defer s.Close()
+
+	if len(types) < 1 {
+		for t := range searchTypes {
+			types = append(types, t)
+		}
+	}
+
+	term := searchTerm(text)
+	results := make([]models.SearchResult, 0)
+
+	for _, t := range types {
+		col, ok := searchTypes[t]
+		if !ok {
+			return nil, fmt.Errorf("Invalid search type %v", t)
+		}
+		c := s.DB(repo.Config.Database).C(col)
+
+		var err error
+		switch t {
+		case "host":
+			hosts := []struct {
+				models.DockerHost `bson:",inline"`
+				Score             float64 `bson:"score"`
+			}{}
+			err = textSearch(c, term, limit, &hosts)
+			for _, h := range hosts {
+				results = append(results, models.SearchResult{
+					Type:        t,
+					Id:          h.Id,
+					Title:       h.Name,
+					Details:     fmt.Sprintf("%v Docker %v", h.OperatingSystem, h.ServerVersion),
+					Environment: h.Environment,
+					Score:       h.Score,
+				})
+			}
+		case "container":
+			containers := []struct {
+				models.DockerContainer `bson:",inline"`
+				Score                  float64 `bson:"score"`
+			}{}
+			err = textSearch(c, term, limit, &containers)
+			for _, k := range containers {
+				results = append(results, models.SearchResult{
+					Type:        t,
+					Id:          k.Id,
+					Title:       k.Name,
+					Details:     fmt.Sprintf("%v %v on %v", k.Image, k.State, k.HostName),
+					Environment: k.Environment,
+					Score:       k.Score,
+				})
+			}
+		case "consul_check":
+			checks := []struct {
+				models.ConsulHealthCheck `bson:",inline"`
+				Score                    float64 `bson:"score"`
+			}{}
+			err = textSearch(c, term, limit, &checks)
+			for _, k := range checks {
+				results = append(results, models.SearchResult{
+					Type:        t,
+					Id:          k.Id,
+					Title:       k.ServiceName,
+					Details:     fmt.Sprintf("%v %v on %v", k.Name, k.Status, k.Node),
+					Environment: k.Environment,
+					Score:       k.Score,
+				})
+			}
+		case "cluster_check":
+			checks := []struct {
+				models.ClusterHealthCheck `bson:",inline"`
+				Score                     float64 `bson:"score"`
+			}{}
+			err = textSearch(c, term, limit, &checks)
+			for _, k := range checks {
+				results = append(results, models.SearchResult{
+					Type:        t,
+					Id:          k.Id,
+					Title:       k.ServiceName,
+					Details:     fmt.Sprintf("%v on %v", k.Status, k.HostName),
+					Environment: k.Environment,
+					Score:       k.Score,
+				})
+			}
+		case "vm":
+			vms := []struct {
+				models.VSphereVM `bson:",inline"`
+				Score            float64 `bson:"score"`
+			}{}
+			err = textSearch(c, term, limit, &vms)
+			for _, v := range vms {
+				results = append(results, models.SearchResult{
+					Type:        t,
+					Id:          v.Id,
+					Title:       v.Name,
+					Details:     fmt.Sprintf("%v %v on %v", v.IP, v.PowerState, v.HostName),
+					Environment: v.Environment,
+					Score:       v.Score,
+				})
+			}
+		case "release":
+			rels := []struct {
+				models.Release `bson:",inline"`
+				Score          float64 `bson:"score"`
+			}{}
+			err = textSearch(c, term, limit, &rels)
+			for _, r := range rels {
+				results = append(results, models.SearchResult{
+					Type:        t,
+					Id:          r.Id,
+					Title:       r.TicketId,
+					Details:     fmt.Sprintf("%v deployments on %v", r.Deployments, r.Environments),
+					Environment: r.Environments,
+					Score:       r.Score,
+				})
+			}
+		}
+
+		if err != nil {
+			log.Errorf("Repository Search %v query failed %v", col, err)
+			return nil, err
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+
+	return results, nil
+}
+
+func textSearch(c *mgo.Collection, term string, limit int, result interface{}) error {
+	return c.Find(bson.M{"$text": bson.M{"$search": term}}).
+		Select(bson.M{"score": bson.M{"$meta": "textScore"}}).
+		Sort("$textScore:score").
+		Limit(limit).
+		All(result)
+}
+
+// searchTerm turns identifiers like image names or IPs into a phrase search
+// since the text tokenizer splits words on punctuation
+func searchTerm(text string) string {
+	text = strings.TrimSpace(text)
+	if strings.ContainsAny(text, ".:/-@") && !strings.Contains(text, "\"") {
+		return "\"" + text + "\""
+	}
+	return text
+}
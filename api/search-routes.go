@@ -0,0 +1,63 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/jwtauth"
+	"github.com/go-chi/render"
+)
+
+func (s *HttpServer) searchRoutes() chi.Router {
+	r := chi.NewRouter()
+
+	// JWT protected
+	r.Group(func(r chi.Router) {
+		r.Use(jwtauth.Verifier(s.TokenAuth))
+		r.Use(jwtauth.Authenticator)
+
+		r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+			text := strings.TrimSpace(r.URL.Query().Get("q"))
+			if len(text) < 2 {
+				render.Status(r, http.StatusBadRequest)
+				render.PlainText(w, r, "q must have at least 2 characters")
+				return
+			}
+
+			limit := 50
+			if v := r.URL.Query().Get("limit"); len(v) > 0 {
+				l, err := strconv.Atoi(v)
+				if err != nil || l < 1 || l > maxPageSize {
+					render.Status(r, http.StatusBadRequest)
+					render.PlainText(w, r, "Invalid limit "+v)
+					return
+				}
+				limit = l
+			}
+
+			types := make([]string, 0)
+			if v := r.URL.Query().Get("type"); len(v) > 0 {
+				types = strings.Split(v, ",")
+				for _, t := range types {
+					if _, ok := searchTypes[t]; !ok {
+						render.Status(r, http.StatusBadRequest)
+						render.PlainText(w, r, "Invalid search type "+t)
+						return
+					}
+				}
+			}
+
+			results, err := s.Repository.Search(text, types, limit)
+			if err != nil {
+				render.Status(r, http.StatusInternalServerError)
+				render.PlainText(w, r, err.Error())
+				return
+			}
+			render.JSON(w, r, results)
+		})
+	})
+
+	return r
+}
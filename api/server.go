@@ -21,6 +21,11 @@ type HttpServer struct {
 	Config     *Config
 	Repository *Repository
 	TokenAuth  *jwtauth.JwtAuth
+	Executor   *PlanExecutor
+	Git        *GitLog
+	Mailer     *Mailer
+	Kibana     Kibana
+	Prometheus *PrometheusClient
 }
 
 func (s *HttpServer) Start() {
@@ -33,7 +38,7 @@ func (s *HttpServer) Start() {
 		AllowedOrigins:   []string{"*"},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
 		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
-		ExposedHeaders:   []string{"Link"},
+		ExposedHeaders:   []string{"Link", "X-Total-Count", "X-Next-Cursor"},
 		AllowCredentials: true,
 		MaxAge:           300,
 	})
@@ -42,6 +47,7 @@ func (s *HttpServer) Start() {
 	r.Use(middleware.RealIP)
 	r.Use(middleware.RequestID)
 	r.Use(middleware.Recoverer)
+	r.Use(s.AuditMiddleware)
 	r.Use(middleware.DefaultCompress)
 	if s.Config.LogLevel == "debug" {
 		r.Use(middleware.DefaultLogger)
@@ -94,6 +100,16 @@ func (s *HttpServer) Start() {
 	r.Mount("/api/release", s.releaseRoutes())
 	r.Mount("/api/vsphere", s.vsphereRoutes())
 	r.Mount("/api/cluster", s.clusterRoutes())
+	r.Mount("/api/audit", s.auditRoutes())
+	r.Mount("/api/search", s.searchRoutes())
+	r.Mount("/api/inventory", s.inventoryRoutes())
+	r.Mount("/api/plan", s.planRoutes())
+	r.Mount("/api/maintenance", s.maintenanceRoutes())
+	r.Mount("/api/build", s.buildRoutes())
+	r.Mount("/api/registry", s.registryRoutes())
+	r.Mount("/api/incident", s.incidentRoutes())
+	r.Mount("/api/metrics", s.metricsRoutes())
+	r.Mount("/api/capacity", s.capacityRoutes())
 
 	// ui paths
 	indexPath := filepath.Join(s.Config.AppPath, "index.html")
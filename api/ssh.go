@@ -1,22 +1,44 @@
 package main
 
 import (
+	"bytes"
 	"crypto/x509"
 	"encoding/pem"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"net"
+	"time"
 
 	"golang.org/x/crypto/ssh"
 )
 
+// sshDialTimeout limits the TCP connect and the SSH handshake
+const sshDialTimeout = 30 * time.Second
+
+// SshClient runs commands on a server, a zero Timeout lets the commands run forever
 type SshClient struct {
-	Config *ssh.ClientConfig
-	Server string
+	Config  *ssh.ClientConfig
+	Server  string
+	Timeout time.Duration
 }
 
 func NewSshClient(user string, host string, port int, privateKeyPath string, privateKeyPassword string) (*SshClient, error) {
+	config, err := NewSshClientConfig(user, privateKeyPath, privateKeyPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &SshClient{
+		Config: config,
+		Server: fmt.Sprintf("%v:%v", host, port),
+	}
+
+	return client, nil
+}
+
+// Builds a public key auth config that can be shared between clients
+func NewSshClientConfig(user string, privateKeyPath string, privateKeyPassword string) (*ssh.ClientConfig, error) {
 	// read private key file
 	pemBytes, err := ioutil.ReadFile(privateKeyPath)
 	if err != nil {
@@ -37,21 +59,17 @@ func NewSshClient(user string, host string, port int, privateKeyPath string, pri
 			// use OpenSSH's known_hosts file if you care about host validation
 			return nil
 		},
+		Timeout: sshDialTimeout,
 	}
 
-	client := &SshClient{
-		Config: config,
-		Server: fmt.Sprintf("%v:%v", host, port),
-	}
-
-	return client, nil
+	return config, nil
 }
 
 // Opens a new SSH connection and runs the specified command
-// Returns the combined output of stdout and stderr
+// Returns the combined output of stdout and stderr,
+// the connection is closed if the command doesn't finish before the client timeout
 func (s *SshClient) RunCommand(cmd string) (string, error) {
-	// open connection
-	conn, err := ssh.Dial("tcp", s.Server, s.Config)
+	conn, err := s.dial()
 	if err != nil {
 		return "", fmt.Errorf("Dial to %v failed %v", s.Server, err)
 	}
@@ -65,9 +83,58 @@ func (s *SshClient) RunCommand(cmd string) (string, error) {
 	defer session.Close()
 
 	// run command and capture stdout/stderr
-	output, err := session.CombinedOutput(cmd)
+	var output bytes.Buffer
+	session.Stdout = &output
+	session.Stderr = &output
+	if err := session.Start(cmd); err != nil {
+		return "", err
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- session.Wait()
+	}()
+
+	var timeout <-chan time.Time
+	if s.Timeout > 0 {
+		timer := time.NewTimer(s.Timeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case err = <-done:
+		return output.String(), err
+	case <-timeout:
+		// closing the connection unblocks Wait
+		conn.Close()
+		<-done
+		return output.String(), fmt.Errorf("Command on %v timed out after %v", s.Server, s.Timeout)
+	}
+}
+
+// dial opens the connection with a deadline on both the TCP connect and the SSH handshake,
+// ssh.Dial only limits the TCP connect
+func (s *SshClient) dial() (*ssh.Client, error) {
+	timeout := s.Config.Timeout
+	if timeout <= 0 {
+		timeout = sshDialTimeout
+	}
+
+	conn, err := net.DialTimeout("tcp", s.Server, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	conn.SetDeadline(time.Now().Add(timeout))
+	c, chans, reqs, err := ssh.NewClientConn(conn, s.Server, s.Config)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	conn.SetDeadline(time.Time{})
 
-	return fmt.Sprintf("%s", output), err
+	return ssh.NewClient(c, chans, reqs), nil
 }
 
 func signerFromPem(pemBytes []byte, password []byte) (ssh.Signer, error) {
@@ -1,11 +1,15 @@
 package main
 
 import (
+	"time"
+
 	log "github.com/Sirupsen/logrus"
 	"github.com/stefanprodan/syros/models"
+	"gopkg.in/mgo.v2/bson"
 )
 
-func (repo *Repository) AllVSphere() (*models.VSpherePayload, error) {
+// AllVSphere returns all hosts and datastores, the query applies to the VMs list
+func (repo *Repository) AllVSphere(query ListQuery) (*models.VSpherePayload, int, error) {
 	s := repo.Session.Copy()
 	defer s.Close()
 
@@ -14,15 +18,14 @@ func (repo *Repository) AllVSphere() (*models.VSpherePayload, error) {
 	err := c.Find(nil).Sort("-collected").All(&hosts)
 	if err != nil {
 		log.Errorf("Repository AllVSphere vsphere_hosts cursor failed %v", err)
-		return nil, err
+		return nil, 0, err
 	}
 
-	v := s.DB(repo.Config.Database).C("vsphere_vms")
 	vms := []models.VSphereVM{}
-	err = v.Find(nil).Sort("name").All(&vms)
+	total, err := repo.list("vsphere_vms", query, &vms)
 	if err != nil {
 		log.Errorf("Repository AllVSphere vsphere_vms cursor failed %v", err)
-		return nil, err
+		return nil, 0, err
 	}
 
 	d := s.DB(repo.Config.Database).C("vsphere_dstores")
@@ -30,7 +33,7 @@ func (repo *Repository) AllVSphere() (*models.VSpherePayload, error) {
 	err = d.Find(nil).Sort("-collected").All(&ds)
 	if err != nil {
 		log.Errorf("Repository AllVSphere vsphere_dstores cursor failed %v", err)
-		return nil, err
+		return nil, 0, err
 	}
 
 	payload := &models.VSpherePayload{
@@ -39,5 +42,100 @@ func (repo *Repository) AllVSphere() (*models.VSpherePayload, error) {
 		DataStores: ds,
 	}
 
-	return payload, nil
+	return payload, total, nil
+}
+
+// VSphereMetrics returns the performance samples of a VM or host in the time range
+func (repo *Repository) VSphereMetrics(entityId string, start time.Time, end time.Time) ([]models.VSphereMetric, error) {
+	s := repo.Session.Copy()
+	defer s.Close()
+
+	c := s.DB(repo.Config.Database).C("vsphere_metrics")
+	metrics := []models.VSphereMetric{}
+	err := c.Find(bson.M{
+		"entity_id": entityId,
+		"timestamp": bson.M{"$gte": start, "$lte": end},
+	}).Sort("timestamp").All(&metrics)
+	if err != nil {
+		log.Errorf("Repository VSphereMetrics query failed %v", err)
+		return nil, err
+	}
+
+	return metrics, nil
+}
+
+func (repo *Repository) VSphereResourcePools(datacenter string) ([]models.VSphereResourcePool, error) {
+	s := repo.Session.Copy()
+	defer s.Close()
+
+	query := bson.M{}
+	if len(datacenter) > 0 {
+		query["datacenter"] = datacenter
+	}
+
+	c := s.DB(repo.Config.Database).C("vsphere_pools")
+	pools := []models.VSphereResourcePool{}
+	err := c.Find(query).Sort("datacenter", "path").All(&pools)
+	if err != nil {
+		log.Errorf("Repository VSphereResourcePools query failed %v", err)
+		return nil, err
+	}
+
+	return pools, nil
+}
+
+func (repo *Repository) VSphereNetworks(datacenter string) ([]models.VSphereNetwork, error) {
+	s := repo.Session.Copy()
+	defer s.Close()
+
+	query := bson.M{}
+	if len(datacenter) > 0 {
+		query["datacenter"] = datacenter
+	}
+
+	c := s.DB(repo.Config.Database).C("vsphere_networks")
+	networks := []models.VSphereNetwork{}
+	err := c.Find(query).Sort("datacenter", "name").All(&networks)
+	if err != nil {
+		log.Errorf("Repository VSphereNetworks query failed %v", err)
+		return nil, err
+	}
+
+	return networks, nil
+}
+
+func (repo *Repository) VSphereSnapshots(query ListQuery) ([]models.VSphereSnapshot, int, error) {
+	snapshots := []models.VSphereSnapshot{}
+	total, err := repo.list("vsphere_snapshots", query, &snapshots)
+	if err != nil {
+		log.Errorf("Repository VSphereSnapshots vsphere_snapshots cursor failed %v", err)
+		return nil, 0, err
+	}
+
+	return snapshots, total, nil
+}
+
+// VSphereCapacity returns the daily capacity snapshots since the specified time sorted by date,
+// the query can be narrowed by type and entity
+func (repo *Repository) VSphereCapacity(kind string, entityId string, since time.Time) ([]models.VSphereCapacity, error) {
+	s := repo.Session.Copy()
+	defer s.Close()
+
+	query := bson.M{"date": bson.M{"$gte": since}}
+	if len(kind) > 0 {
+		query["type"] = kind
+	}
+	if len(entityId) > 0 {
+		query["entity_id"] = entityId
+	}
+
+	c := s.DB(repo.Config.Database).C("vsphere_capacity")
+	snapshots := []models.VSphereCapacity{}
+	err := c.Find(query).Sort("date").All(&snapshots)
+	if err != nil {
+		log.Errorf("Repository VSphereCapacity query failed %v", err)
+		return nil, err
+	}
+
+	return snapshots, nil
 }
@@ -2,11 +2,13 @@ package main
 
 import (
 	"net/http"
+	"strconv"
 
 	"github.com/go-chi/chi"
 	"github.com/go-chi/jwtauth"
 	"github.com/go-chi/render"
 	"github.com/stefanprodan/syros/models"
+	"gopkg.in/mgo.v2/bson"
 )
 
 func (s *HttpServer) vsphereRoutes() chi.Router {
@@ -18,7 +20,14 @@ func (s *HttpServer) vsphereRoutes() chi.Router {
 		r.Use(jwtauth.Authenticator)
 
 		r.Get("/", func(w http.ResponseWriter, r *http.Request) {
-			vsphere, err := s.Repository.AllVSphere()
+			query, err := ParseListQuery(r, vmsListSpec, s.Config.PageSize)
+			if err != nil {
+				render.Status(r, http.StatusBadRequest)
+				render.PlainText(w, r, err.Error())
+				return
+			}
+
+			vsphere, total, err := s.Repository.AllVSphere(query)
 			if err != nil {
 				render.Status(r, http.StatusInternalServerError)
 				render.PlainText(w, r, err.Error())
@@ -71,9 +80,77 @@ func (s *HttpServer) vsphereRoutes() chi.Router {
 				Chart:      chart,
 			}
 
+			setPageHeaders(w, r, query, total)
 			render.JSON(w, r, data)
 		})
 
+		r.Get("/pools", func(w http.ResponseWriter, r *http.Request) {
+			pools, err := s.Repository.VSphereResourcePools(r.URL.Query().Get("datacenter"))
+			if err != nil {
+				render.Status(r, http.StatusInternalServerError)
+				render.PlainText(w, r, err.Error())
+				return
+			}
+			render.JSON(w, r, pools)
+		})
+
+		r.Get("/networks", func(w http.ResponseWriter, r *http.Request) {
+			networks, err := s.Repository.VSphereNetworks(r.URL.Query().Get("datacenter"))
+			if err != nil {
+				render.Status(r, http.StatusInternalServerError)
+				render.PlainText(w, r, err.Error())
+				return
+			}
+			render.JSON(w, r, networks)
+		})
+
+		r.Get("/snapshots", func(w http.ResponseWriter, r *http.Request) {
+			query, err := ParseListQuery(r, snapshotsListSpec, s.Config.PageSize)
+			if err != nil {
+				render.Status(r, http.StatusBadRequest)
+				render.PlainText(w, r, err.Error())
+				return
+			}
+
+			// older than the specified number of days
+			if v := r.URL.Query().Get("older"); len(v) > 0 {
+				days, err := strconv.Atoi(v)
+				if err != nil || days < 0 {
+					render.Status(r, http.StatusBadRequest)
+					render.PlainText(w, r, "Invalid older "+v)
+					return
+				}
+				query.Filter["age"] = bson.M{"$gte": days * 24 * 3600}
+			}
+
+			snapshots, total, err := s.Repository.VSphereSnapshots(query)
+			if err != nil {
+				render.Status(r, http.StatusInternalServerError)
+				render.PlainText(w, r, err.Error())
+				return
+			}
+
+			setPageHeaders(w, r, query, total)
+			render.JSON(w, r, snapshots)
+		})
+
+		r.Get("/metrics/{entityID}", func(w http.ResponseWriter, r *http.Request) {
+			start, end, _, err := parseRange(r)
+			if err != nil {
+				render.Status(r, http.StatusBadRequest)
+				render.PlainText(w, r, err.Error())
+				return
+			}
+
+			metrics, err := s.Repository.VSphereMetrics(chi.URLParam(r, "entityID"), start, end)
+			if err != nil {
+				render.Status(r, http.StatusInternalServerError)
+				render.PlainText(w, r, err.Error())
+				return
+			}
+			render.JSON(w, r, metrics)
+		})
+
 	})
 
 	return r
@@ -30,6 +30,11 @@ func componentMigrate(c *cli.Context) error {
 	log.Printf("Config downloaded to %s", dir)
 	log.Print("-----------------")
 
+	// mark the deployment as started in SYROS on all target hosts before deploying any of them
+	if err := syrosStartAll(dir, ticket, components, environments, "", "migrate", ""); err != nil {
+		log.Fatal(err.Error())
+	}
+
 	for _, component := range components {
 		for _, env := range environments {
 
@@ -47,23 +52,6 @@ func componentMigrate(c *cli.Context) error {
 
 			// run migration on each target host
 			for _, target := range targets {
-				// mark deployment as started in SYROS
-				if len(ticket) > 0 {
-					syrosApi, cfgExists, err := loadSyrosConfig(dir, "syros")
-					if err != nil {
-						log.Printf("Syros config load failed %s", err.Error())
-					} else {
-						if !cfgExists {
-							log.Print("Syros config not found")
-						} else {
-							err := syrosApi.Start(ticket, env, component, target.Host)
-							if err != nil {
-								log.Print(err.Error())
-							}
-						}
-					}
-				}
-
 				// download migrations from Jenkins
 				jenkinsConfig, cfgExists, err := loadJenkinsConfig(dir, "jenkins")
 				if err != nil {
@@ -176,7 +164,7 @@ func componentMigrate(c *cli.Context) error {
 						if !cfgExists {
 							log.Print("Syros config not found")
 						} else {
-							err := syrosApi.Finish(ticket, env, component, target.Host, path.Join(dir, "deployctl.log"))
+							err := syrosApi.Finish(ticket, env, component, target.Host, path.Join(dir, "deployctl.log"), "migrate", "", "succeeded")
 							if err != nil {
 								log.Print(err.Error())
 							}
@@ -32,6 +32,11 @@ func componentPromote(c *cli.Context) error {
 	log.Printf("Config downloaded to %s", dir)
 	log.Print("-----------------")
 
+	// mark the deployment as started in SYROS on all target hosts before deploying any of them
+	if err := syrosStartAll(dir, ticket, components, environments, "docker", "promote", tag); err != nil {
+		log.Fatal(err.Error())
+	}
+
 	for _, component := range components {
 		for _, env := range environments {
 
@@ -77,23 +82,6 @@ func componentPromote(c *cli.Context) error {
 				// run promotion on each target host
 				for _, target := range targets {
 
-					// mark deployment as started in SYROS
-					if len(ticket) > 0 {
-						syrosApi, cfgExists, err := loadSyrosConfig(dir, "syros")
-						if err != nil {
-							log.Printf("Syros config load failed %s", err.Error())
-						} else {
-							if !cfgExists {
-								log.Print("Syros config not found")
-							} else {
-								err := syrosApi.Start(ticket, env, component, target.Host)
-								if err != nil {
-									log.Print(err.Error())
-								}
-							}
-						}
-					}
-
 					fromEnv := promotionCfg.Rules.Source
 					hostFrom := strings.Replace(target.Host, env, fromEnv, 1)
 					cd := ContainerDeploy{
@@ -109,6 +97,7 @@ func componentPromote(c *cli.Context) error {
 
 					err = cd.Promote()
 					if err != nil {
+						syrosFailed(dir, ticket, env, component, target.Host, "promote", tag)
 						log.Fatal(err.Error())
 					}
 
@@ -135,7 +124,7 @@ func componentPromote(c *cli.Context) error {
 							if !cfgExists {
 								log.Print("Syros config not found")
 							} else {
-								err := syrosApi.Finish(ticket, env, component, target.Host, path.Join(dir, "deployctl.log"))
+								err := syrosApi.Finish(ticket, env, component, target.Host, path.Join(dir, "deployctl.log"), "promote", tag, "succeeded")
 								if err != nil {
 									log.Print(err.Error())
 								}
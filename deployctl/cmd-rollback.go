@@ -29,6 +29,11 @@ func componentRollback(c *cli.Context) error {
 	log.Printf("Config downloaded to %s", dir)
 	log.Print("-----------------")
 
+	// mark the deployment as started in SYROS on all target hosts before deploying any of them
+	if err := syrosStartAll(dir, ticket, components, environments, "docker", "rollback", ""); err != nil {
+		log.Fatal(err.Error())
+	}
+
 	for _, component := range components {
 		for _, env := range environments {
 
@@ -69,23 +74,6 @@ func componentRollback(c *cli.Context) error {
 				// run reload on each target host
 				for _, target := range targets {
 
-					// mark deployment as started in SYROS
-					if len(ticket) > 0 {
-						syrosApi, cfgExists, err := loadSyrosConfig(dir, "syros")
-						if err != nil {
-							log.Printf("Syros config load failed %s", err.Error())
-						} else {
-							if !cfgExists {
-								log.Print("Syros config not found")
-							} else {
-								err := syrosApi.Start(ticket, env, component, target.Host)
-								if err != nil {
-									log.Print(err.Error())
-								}
-							}
-						}
-					}
-
 					cd := ContainerDeploy{
 						Dir:      dir,
 						Env:      env,
@@ -98,6 +86,7 @@ func componentRollback(c *cli.Context) error {
 
 					err = cd.Rollback()
 					if err != nil {
+						syrosFailed(dir, ticket, env, component, target.Host, "rollback", "")
 						log.Fatal(err.Error())
 					}
 
@@ -124,7 +113,7 @@ func componentRollback(c *cli.Context) error {
 							if !cfgExists {
 								log.Print("Syros config not found")
 							} else {
-								err := syrosApi.Finish(ticket, env, component, target.Host, path.Join(dir, "deployctl.log"))
+								err := syrosApi.Finish(ticket, env, component, target.Host, path.Join(dir, "deployctl.log"), "rollback", "", "succeeded")
 								if err != nil {
 									log.Print(err.Error())
 								}
@@ -8,6 +8,12 @@ import (
 
 var version = "undefined"
 
+// SYROS bearer token and maintenance windows override set by the global flags
+var (
+	syrosToken    string
+	syrosOverride bool
+)
+
 func main() {
 
 	app := cli.NewApp()
@@ -22,6 +28,18 @@ func main() {
 			Usage:  "Download URL for the config.tar.gz file",
 			EnvVar: "DCTL_CONFIG_URL",
 		},
+		cli.StringFlag{
+			Name:        "syros-token",
+			Usage:       "SYROS JWT token sent as bearer with the deployment updates",
+			EnvVar:      "DCTL_SYROS_TOKEN",
+			Destination: &syrosToken,
+		},
+		cli.BoolFlag{
+			Name:        "syros-override",
+			Usage:       "Deploy outside the SYROS maintenance windows, requires a token of an override role",
+			EnvVar:      "DCTL_SYROS_OVERRIDE",
+			Destination: &syrosOverride,
+		},
 	}
 
 	app.Commands = []cli.Command{
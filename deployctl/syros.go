@@ -24,6 +24,26 @@ type SyrosConfig struct {
 		URL      string `yaml:"url"`
 		User     string `yaml:"user"`
 	} `yaml:"api"`
+	// the bearer token and the maintenance windows override are set from the command line
+	Token    string `yaml:"-"`
+	Override bool   `yaml:"-"`
+}
+
+// SyrosRejected is returned when SYROS refuses to start a deployment, e.g. outside the maintenance windows
+type SyrosRejected struct {
+	Host   string
+	Reason string
+}
+
+func (e *SyrosRejected) Error() string {
+	return fmt.Sprintf("Syros rejected the deployment on %s: %s", e.Host, e.Reason)
+}
+
+// syrosTarget is a host registered in SYROS before the deployment starts
+type syrosTarget struct {
+	env       string
+	component string
+	host      string
 }
 
 func loadSyrosConfig(dir string, name string) (SyrosConfig, bool, error) {
@@ -54,10 +74,25 @@ func loadSyrosConfig(dir string, name string) (SyrosConfig, bool, error) {
 		return plan, false, errors.Wrapf(err, "Parsing %v failed", planPath)
 	}
 
+	plan.Token = syrosToken
+	plan.Override = syrosOverride
+
 	return plan, true, nil
 }
 
-func (j SyrosConfig) Start(ticket string, env string, component string, host string) error {
+// authorize adds the bearer token and the override flag to a SYROS request
+func (j SyrosConfig) authorize(req *http.Request) {
+	if len(j.Token) > 0 {
+		req.Header.Set("Authorization", "Bearer "+j.Token)
+	}
+	if j.Override {
+		q := req.URL.Query()
+		q.Set("override", "true")
+		req.URL.RawQuery = q.Encode()
+	}
+}
+
+func (j SyrosConfig) Start(ticket string, env string, component string, host string, action string, tag string) error {
 	url := fmt.Sprintf("%s/deployment/start", j.API.URL)
 	log.Printf("Updating Syros %s", url)
 	transport := &http.Transport{
@@ -82,6 +117,9 @@ func (j SyrosConfig) Start(ticket string, env string, component string, host str
 		"service_name": component,
 		"host_name":    strings.Split(host, ".")[0],
 		"environment":  env,
+		"action":       action,
+		"tag":          tag,
+		"initiator":    os.Getenv("USER"),
 	}
 	jsonData, _ := json.Marshal(data)
 
@@ -91,6 +129,7 @@ func (j SyrosConfig) Start(ticket string, env string, component string, host str
 	}
 
 	req.Header.Set("Content-Type", "application/json")
+	j.authorize(req)
 
 	resp, err := client.Do(req)
 	if err != nil {
@@ -103,18 +142,18 @@ func (j SyrosConfig) Start(ticket string, env string, component string, host str
 		return errors.Wrapf(err, "Syros HTTP POST %s body read failed", url)
 	}
 
-	if resp.StatusCode == 200 || resp.StatusCode == 201 {
-		return nil
-	} else {
-		log.Print(string(body))
-		return errors.Errorf("Syros HTTP error code %d received", resp.StatusCode)
+	if resp.StatusCode == http.StatusForbidden {
+		return &SyrosRejected{Host: host, Reason: strings.TrimSpace(string(body))}
 	}
 
-	return nil
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return errors.Errorf("Syros HTTP error code %d received %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
 
+	return nil
 }
 
-func (j SyrosConfig) Finish(ticket string, env string, component string, host string, logFile string) error {
+func (j SyrosConfig) Finish(ticket string, env string, component string, host string, logFile string, action string, tag string, status string) error {
 
 	logData, err := ioutil.ReadFile(logFile)
 	if err != nil {
@@ -146,6 +185,10 @@ func (j SyrosConfig) Finish(ticket string, env string, component string, host st
 		"host_name":    strings.Split(host, ".")[0],
 		"environment":  env,
 		"log":          string(logData),
+		"action":       action,
+		"tag":          tag,
+		"initiator":    os.Getenv("USER"),
+		"status":       status,
 	}
 	jsonData, _ := json.Marshal(data)
 
@@ -155,6 +198,7 @@ func (j SyrosConfig) Finish(ticket string, env string, component string, host st
 	}
 
 	req.Header.Set("Content-Type", "application/json")
+	j.authorize(req)
 
 	resp, err := client.Do(req)
 	if err != nil {
@@ -177,3 +221,77 @@ func (j SyrosConfig) Finish(ticket string, env string, component string, host st
 	return nil
 
 }
+
+// syrosFailed marks the deployment as failed in SYROS and uploads the log
+func syrosFailed(dir string, ticket string, env string, component string, host string, action string, tag string) {
+	if len(ticket) < 1 {
+		return
+	}
+
+	syrosApi, cfgExists, err := loadSyrosConfig(dir, "syros")
+	if err != nil {
+		log.Printf("Syros config load failed %s", err.Error())
+		return
+	}
+	if !cfgExists {
+		log.Print("Syros config not found")
+		return
+	}
+
+	err = syrosApi.Finish(ticket, env, component, host, path.Join(dir, "deployctl.log"), action, tag, "failed")
+	if err != nil {
+		log.Print(err.Error())
+	}
+}
+
+// syrosStartAll marks the deployment as started in SYROS on all target hosts before any of them is deployed,
+// only a rejection aborts the deployment, SYROS being unreachable is logged so an outage doesn't block the deploys.
+// If componentType is set only the components of that type are registered.
+func syrosStartAll(dir string, ticket string, components []string, environments []string, componentType string, action string, tag string) error {
+	if len(ticket) < 1 {
+		return nil
+	}
+
+	syrosApi, cfgExists, err := loadSyrosConfig(dir, "syros")
+	if err != nil {
+		log.Printf("Syros config load failed %s", err.Error())
+		return nil
+	}
+	if !cfgExists {
+		log.Print("Syros config not found")
+		return nil
+	}
+
+	targets := make([]syrosTarget, 0)
+	for _, component := range components {
+		for _, env := range environments {
+			componentCfg, exists, err := loadComponent(dir, env, component)
+			if err != nil {
+				return err
+			}
+			if !exists || (len(componentType) > 0 && componentCfg.Component.Type != componentType) {
+				continue
+			}
+			for _, target := range componentCfg.Component.Target {
+				targets = append(targets, syrosTarget{env: env, component: component, host: target.Host})
+			}
+		}
+	}
+
+	for i, t := range targets {
+		err := syrosApi.Start(ticket, t.env, t.component, t.host, action, tag)
+		if err == nil {
+			continue
+		}
+		if _, rejected := err.(*SyrosRejected); rejected {
+			// nothing is deployed yet, the hosts registered so far are marked as failed
+			for _, started := range targets[:i] {
+				syrosFailed(dir, ticket, started.env, started.component, started.host, action, tag)
+			}
+			return err
+		}
+		log.Print(err.Error())
+	}
+
+	return nil
+}
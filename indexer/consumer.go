@@ -17,6 +17,10 @@ type Consumer struct {
 	consulChan     chan *models.ConsulPayload
 	clusterChan    chan *models.ClusterPayload
 	vsphereChan    chan *models.VSpherePayload
+	jenkinsChan    chan *models.JenkinsPayload
+	registryChan   chan *models.RegistryPayload
+	incidentChan   chan *models.IncidentPayload
+	logChan        chan *models.LogPayload
 }
 
 func NewConsumer(config *Config, nc *nats.EncodedConn, repo *Repository, buffer int) (*Consumer, error) {
@@ -28,6 +32,10 @@ func NewConsumer(config *Config, nc *nats.EncodedConn, repo *Repository, buffer
 		consulChan:     make(chan *models.ConsulPayload, buffer),
 		clusterChan:    make(chan *models.ClusterPayload, buffer),
 		vsphereChan:    make(chan *models.VSpherePayload, buffer),
+		jenkinsChan:    make(chan *models.JenkinsPayload, buffer),
+		registryChan:   make(chan *models.RegistryPayload, buffer),
+		incidentChan:   make(chan *models.IncidentPayload, buffer),
+		logChan:        make(chan *models.LogPayload, buffer),
 	}
 
 	consumer.metrics = NewPrometheus("syros", "indexer")
@@ -40,6 +48,10 @@ func (c *Consumer) Consume() {
 	c.ConsulConsume()
 	c.ClusterConsume()
 	c.VSphereConsume()
+	c.JenkinsConsume()
+	c.RegistryConsume()
+	c.IncidentConsume()
+	c.LogConsume()
 }
 
 func (c *Consumer) DockerConsume() {
@@ -88,8 +100,13 @@ func consulSave(payload *models.ConsulPayload, c *Consumer) {
 	if payload == nil {
 		log.Error("Consul payload is nil")
 	} else {
-		log.Debugf("Consul payload received %v checks", len(payload.HealthChecks))
+		log.Debugf("Consul payload received %v checks %v node checks", len(payload.HealthChecks), len(payload.NodeChecks))
 		c.Repository.ChecksUpsert(payload.HealthChecks)
+		c.Repository.NodeChecksUpsert(payload.NodeChecks)
+		if payload.Catalog {
+			c.Repository.ConsulServicesUpsert(payload.Environment, payload.Datacenter, payload.Services, payload.Collected)
+			c.Repository.ConsulKVUpsert(payload.Environment, payload.Datacenter, payload.KV, payload.Collected)
+		}
 	}
 	t2 := time.Now()
 	c.metrics.requestsTotal.WithLabelValues("consul", c.Config.CollectorQueue, status).Inc()
@@ -116,12 +133,53 @@ func clusterSave(payload *models.ClusterPayload, c *Consumer) {
 	} else {
 		log.Debugf("Cluster payload received %v", payload.HealthCheck.ServiceName)
 		c.Repository.ClusterChecksUpsert(payload.HealthCheck)
+		clusterState(payload.HealthCheck, c)
 	}
 	t2 := time.Now()
 	c.metrics.requestsTotal.WithLabelValues("cluster", c.Config.CollectorQueue, status).Inc()
 	c.metrics.requestsLatency.WithLabelValues("cluster", c.Config.CollectorQueue, status).Observe(t2.Sub(t1).Seconds())
 }
 
+// clusterStale is the offline window of a cluster member when the database GC is disabled
+const clusterStale = 5 * time.Minute
+
+// clusterState aggregates the service endpoints into a cluster state and warns when the cluster becomes unhealthy,
+// members older than the stale window are offline and the ones older than three windows are ignored
+func clusterState(check models.ClusterHealthCheck, c *Consumer) {
+	members, err := c.Repository.ClusterMembers(check.Environment, check.ServiceName)
+	if err != nil {
+		return
+	}
+
+	window := clusterStale
+	if c.Config.DatabaseStale > 0 {
+		window = time.Duration(c.Config.DatabaseStale) * time.Minute
+	}
+	now := time.Now().UTC()
+
+	state := models.NewClusterState(check.Environment, check.ServiceName, members, now.Add(-window), now.Add(-3*window))
+	c.metrics.clusterLeaders.WithLabelValues(check.Environment, check.ServiceName).Set(float64(len(state.Leaders)))
+
+	// each payload carries a single endpoint, a transition is confirmed after a round of all members
+	previous, changed := c.Repository.ClusterStateUpsert(state, state.Members)
+	if !changed {
+		return
+	}
+
+	switch state.State {
+	case models.ClusterHealthy:
+		if previous != "" {
+			log.Infof("Cluster %v on %v recovered from %v", check.ServiceName, check.Environment, previous)
+		}
+	case models.ClusterDegraded:
+		log.Warnf("Cluster %v on %v is degraded, offline members %v", check.ServiceName, check.Environment, state.Offline)
+	case models.ClusterLeaderless:
+		log.Warnf("Cluster %v on %v has no leader, members %v", check.ServiceName, check.Environment, state.Members)
+	case models.ClusterSplitBrain:
+		log.Warnf("Cluster %v on %v is split-brain, leaders %v", check.ServiceName, check.Environment, state.Leaders)
+	}
+}
+
 func (c *Consumer) VSphereConsume() {
 	c.NatsConnection.BindRecvQueueChan("vsphere", c.Config.CollectorQueue, c.vsphereChan)
 	go func() {
@@ -141,13 +199,127 @@ func vsphereSave(payload *models.VSpherePayload, c *Consumer) {
 		log.Errorf("VSphere payload is nil")
 		status = "500"
 	} else {
-		log.Debugf("VSphere payload received %v vms %v hosts %v datastores",
-			len(payload.VMs), len(payload.Hosts), len(payload.DataStores))
+		log.Debugf("VSphere payload received %v datacenters %v vms %v hosts %v datastores %v metrics",
+			len(payload.Datacenters), len(payload.VMs), len(payload.Hosts), len(payload.DataStores), len(payload.Metrics))
 		c.Repository.VSphereDatastoresUpsert(payload.DataStores)
 		c.Repository.VSphereHostsUpsert(payload.Hosts)
 		c.Repository.VSphereVMsUpsert(payload.VMs)
+		c.Repository.VSphereResourcePoolsUpsert(payload.ResourcePools)
+		c.Repository.VSphereNetworksUpsert(payload.Networks)
+		c.Repository.VSphereSnapshotsUpsert(payload.Snapshots)
+		c.Repository.VSphereCapacityUpsert(models.NewVSphereCapacity(payload, time.Now().UTC()))
+		c.Repository.VSphereMetricsUpsert(payload.Metrics)
 	}
 	t2 := time.Now()
 	c.metrics.requestsTotal.WithLabelValues("vsphere", c.Config.CollectorQueue, status).Inc()
 	c.metrics.requestsLatency.WithLabelValues("vsphere", c.Config.CollectorQueue, status).Observe(t2.Sub(t1).Seconds())
 }
+
+func (c *Consumer) JenkinsConsume() {
+	c.NatsConnection.BindRecvQueueChan("jenkins", c.Config.CollectorQueue, c.jenkinsChan)
+	go func() {
+		for {
+			select {
+			case payload := <-c.jenkinsChan:
+				jenkinsSave(payload, c)
+			}
+		}
+	}()
+}
+
+func jenkinsSave(payload *models.JenkinsPayload, c *Consumer) {
+	status := "200"
+	t1 := time.Now()
+	if payload == nil {
+		log.Errorf("Jenkins payload is nil")
+		status = "500"
+	} else {
+		log.Debugf("Jenkins payload received %v builds", len(payload.Builds))
+		c.Repository.JenkinsBuildsUpsert(payload.Builds)
+	}
+	t2 := time.Now()
+	c.metrics.requestsTotal.WithLabelValues("jenkins", c.Config.CollectorQueue, status).Inc()
+	c.metrics.requestsLatency.WithLabelValues("jenkins", c.Config.CollectorQueue, status).Observe(t2.Sub(t1).Seconds())
+}
+
+func (c *Consumer) RegistryConsume() {
+	c.NatsConnection.BindRecvQueueChan("registry", c.Config.CollectorQueue, c.registryChan)
+	go func() {
+		for {
+			select {
+			case payload := <-c.registryChan:
+				registrySave(payload, c)
+			}
+		}
+	}()
+}
+
+func registrySave(payload *models.RegistryPayload, c *Consumer) {
+	status := "200"
+	t1 := time.Now()
+	if payload == nil {
+		log.Errorf("Registry payload is nil")
+		status = "500"
+	} else {
+		log.Debugf("Registry payload received from %v images %v", payload.Registry, len(payload.Images))
+		c.Repository.RegistryImagesUpsert(payload.Registry, payload.Images, payload.Skipped, payload.Collected)
+	}
+	t2 := time.Now()
+	c.metrics.requestsTotal.WithLabelValues("registry", c.Config.CollectorQueue, status).Inc()
+	c.metrics.requestsLatency.WithLabelValues("registry", c.Config.CollectorQueue, status).Observe(t2.Sub(t1).Seconds())
+}
+
+func (c *Consumer) IncidentConsume() {
+	c.NatsConnection.BindRecvQueueChan("incidents", c.Config.CollectorQueue, c.incidentChan)
+	go func() {
+		for {
+			select {
+			case payload := <-c.incidentChan:
+				incidentSave(payload, c)
+			}
+		}
+	}()
+}
+
+func incidentSave(payload *models.IncidentPayload, c *Consumer) {
+	status := "200"
+	t1 := time.Now()
+	if payload == nil {
+		log.Errorf("Incident payload is nil")
+		status = "500"
+	} else {
+		log.Debugf("Incident payload received from %v incidents %v on-call %v", payload.Provider, len(payload.Incidents), len(payload.OnCalls))
+		c.Repository.IncidentsUpsert(payload.Provider, payload.Incidents, payload.Collected)
+		c.Repository.OnCallsUpsert(payload.Provider, payload.OnCalls, payload.Collected)
+	}
+	t2 := time.Now()
+	c.metrics.requestsTotal.WithLabelValues("incidents", c.Config.CollectorQueue, status).Inc()
+	c.metrics.requestsLatency.WithLabelValues("incidents", c.Config.CollectorQueue, status).Observe(t2.Sub(t1).Seconds())
+}
+
+func (c *Consumer) LogConsume() {
+	c.NatsConnection.BindRecvQueueChan("logs", c.Config.CollectorQueue, c.logChan)
+	go func() {
+		for {
+			select {
+			case payload := <-c.logChan:
+				logSave(payload, c)
+			}
+		}
+	}()
+}
+
+func logSave(payload *models.LogPayload, c *Consumer) {
+	status := "200"
+	t1 := time.Now()
+	if payload == nil {
+		log.Errorf("Log payload is nil")
+		status = "500"
+	} else {
+		log.Debugf("Log payload received from %v stats %v", payload.Endpoint, len(payload.Stats))
+		c.Repository.LogStatsUpsert(payload.Stats)
+	}
+	t2 := time.Now()
+	c.metrics.requestsTotal.WithLabelValues("logs", c.Config.CollectorQueue, status).Inc()
+	c.metrics.requestsLatency.WithLabelValues("logs", c.Config.CollectorQueue, status).Observe(t2.Sub(t1).Seconds())
+}
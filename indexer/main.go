@@ -36,7 +36,7 @@ func main() {
 	repo.Initialize()
 	log.Infof("Connected to MongoDB cluster %v database initialization done", config.MongoDB)
 
-	repo.RunGarbageCollector([]string{"containers", "hosts", "checks", "syros_services", "vsphere_hosts", "vsphere_dstores", "vsphere_vms"})
+	repo.RunGarbageCollector([]string{"containers", "hosts", "checks", "node_checks", "cluster_checks", "cluster_states", "syros_services", "vsphere_hosts", "vsphere_dstores", "vsphere_vms", "vsphere_pools", "vsphere_networks", "vsphere_snapshots"})
 
 	nc, err := NewNatsConnection(config.Nats, "syros-indexer")
 	if err != nil {
@@ -5,6 +5,7 @@ import "github.com/prometheus/client_golang/prometheus"
 type Prometheus struct {
 	requestsTotal   *prometheus.CounterVec
 	requestsLatency *prometheus.SummaryVec
+	clusterLeaders  *prometheus.GaugeVec
 }
 
 func NewPrometheus(namespace string, subsystem string) *Prometheus {
@@ -30,8 +31,19 @@ func NewPrometheus(namespace string, subsystem string) *Prometheus {
 		[]string{"method", "path", "status"},
 	)
 
+	prom.clusterLeaders = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "cluster_leaders",
+			Help:      "The number of leaders across the endpoints of a clustered service.",
+		},
+		[]string{"environment", "service"},
+	)
+
 	prometheus.MustRegister(prom.requestsTotal)
 	prometheus.MustRegister(prom.requestsLatency)
+	prometheus.MustRegister(prom.clusterLeaders)
 
 	return prom
 }
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"sort"
 	"strings"
 	"time"
 
@@ -42,27 +43,110 @@ func NewRepository(config *Config) (*Repository, error) {
 func (repo *Repository) Initialize() {
 	repo.CreateIndex("hosts", "environment")
 	repo.CreateIndex("hosts", "collected")
+	repo.CreateIndex("hosts", "name")
 	repo.CreateIndex("containers", "host_id")
 	repo.CreateIndex("containers", "environment")
 	repo.CreateIndex("containers", "collected")
+	repo.CreateIndex("containers", "host_name")
+	repo.CreateIndex("containers", "state")
+	repo.CreateIndex("containers", "image")
+	repo.CreateIndex("containers", "name")
 	repo.CreateIndex("checks", "host_id")
 	repo.CreateIndex("checks", "environment")
 	repo.CreateIndex("checks", "collected")
+	repo.CreateIndex("checks", "host_name")
+	repo.CreateIndex("checks", "status")
+	repo.CreateIndex("checks", "service_name")
 	repo.CreateIndex("checks_log", "check_id")
 	repo.CreateIndex("checks_log", "begin")
 	repo.CreateIndex("checks_log", "end")
+	repo.CreateIndex("node_checks", "host_id")
+	repo.CreateIndex("node_checks", "node")
+	repo.CreateIndex("node_checks", "environment")
+	repo.CreateIndex("node_checks", "collected")
+	repo.CreateIndex("node_checks_log", "check_id")
+	repo.CreateIndex("node_checks_log", "host_id")
+	repo.CreateIndex("node_checks_log", "environment")
+	repo.CreateIndex("node_checks_log", "begin")
+	repo.CreateIndex("consul_services", "environment")
+	repo.CreateIndex("consul_services", "name")
+	repo.CreateIndex("consul_kv", "environment")
+	repo.CreateIndex("consul_kv", "key")
 	repo.CreateIndex("syros_services", "environment")
 	repo.CreateIndex("syros_services", "collected")
 	repo.CreateIndex("releases", "ticket_id")
+	repo.CreateIndex("releases", "end")
+	repo.CreateIndex("releases", "environments")
 	repo.CreateIndex("deployments", "release_id")
+	repo.CreateIndex("deployments", "ticket_id")
+	repo.CreateIndex("deployments", "service_name")
+	repo.CreateIndex("deployments", "begin")
+	repo.CreateIndex("deployments", "end")
+	repo.CreateIndex("release_plans", "timestamp")
+	repo.CreateIndex("release_steps", "releaseplan_id")
+	repo.CreateIndex("release_plans", "scheduled_at")
+	repo.CreateIndex("maintenance_windows", "environment")
+	repo.CreateIndex("jenkins_builds", "job")
+	repo.CreateIndex("jenkins_builds", "tag")
+	repo.CreateIndex("jenkins_builds", "timestamp")
+	repo.CreateIndex("registry_images", "registry")
+	repo.CreateIndex("registry_images", "repository")
+	repo.CreateIndex("registry_images", "digest")
+	repo.CreateIndex("incidents", "created")
+	repo.CreateIndex("incidents", "resolved")
+	repo.CreateIndex("incidents", "status")
+	repo.CreateIndex("incidents", "service")
+	repo.CreateIndex("oncalls", "provider")
+	repo.CreateIndex("handovers", "timestamp")
+	repo.CreateIndex("log_stats", "environment")
+	repo.CreateIndex("log_stats", "container")
+	repo.CreateIndex("log_stats", "bucket")
 	repo.CreateIndex("vsphere_hosts", "collected")
 	repo.CreateIndex("vsphere_dstores", "collected")
 	repo.CreateIndex("vsphere_vms", "collected")
+	repo.CreateIndex("vsphere_vms", "name")
+	repo.CreateIndex("vsphere_vms", "environment")
+	repo.CreateIndex("vsphere_vms", "host_name")
+	repo.CreateIndex("vsphere_vms", "power_state")
+	repo.CreateIndex("vsphere_vms", "datacenter")
+	repo.CreateIndex("vsphere_vms", "resource_pool")
+	repo.CreateIndex("vsphere_pools", "collected")
+	repo.CreateIndex("vsphere_pools", "datacenter")
+	repo.CreateIndex("vsphere_networks", "collected")
+	repo.CreateIndex("vsphere_networks", "datacenter")
+	repo.CreateIndex("vsphere_snapshots", "collected")
+	repo.CreateIndex("vsphere_snapshots", "environment")
+	repo.CreateIndex("vsphere_snapshots", "datacenter")
+	repo.CreateIndex("vsphere_snapshots", "vm_id")
+	repo.CreateIndex("vsphere_snapshots", "vm_name")
+	repo.CreateIndex("vsphere_snapshots", "created")
+	repo.CreateIndex("vsphere_snapshots", "age")
+	repo.CreateIndex("vsphere_capacity", "type")
+	repo.CreateIndex("vsphere_capacity", "entity_id")
+	repo.CreateIndex("vsphere_capacity", "date")
+	repo.CreateIndex("vsphere_metrics", "entity_id")
+	repo.CreateIndex("vsphere_metrics", "timestamp")
 	repo.CreateIndex("cluster_checks", "environment")
 	repo.CreateIndex("cluster_checks", "collected")
 	repo.CreateIndex("cluster_checks_log", "check_id")
 	repo.CreateIndex("cluster_checks_log", "begin")
 	repo.CreateIndex("cluster_checks_log", "end")
+	repo.CreateIndex("cluster_states", "environment")
+	repo.CreateIndex("cluster_states", "collected")
+	repo.CreateIndex("cluster_state_log", "state_id")
+	repo.CreateIndex("cluster_state_log", "state")
+	repo.CreateIndex("cluster_state_log", "begin")
+	repo.CreateIndex("audit_log", "timestamp")
+	repo.CreateIndex("audit_log", "actor")
+	repo.CreateIndex("audit_log", "route")
+
+	// text indexes used by the app global search, one per collection
+	repo.CreateTextIndex("hosts", map[string]int{"name": 10, "labels": 2, "environment": 1})
+	repo.CreateTextIndex("containers", map[string]int{"name": 10, "image": 8, "search_labels": 3, "env_keys": 2, "host_name": 1})
+	repo.CreateTextIndex("checks", map[string]int{"service_name": 10, "name": 5, "host_name": 3, "node": 3, "output": 1})
+	repo.CreateTextIndex("cluster_checks", map[string]int{"service_name": 10, "host_name": 5})
+	repo.CreateTextIndex("vsphere_vms", map[string]int{"name": 10, "ip": 10, "host_name": 2, "cluster": 1})
+	repo.CreateTextIndex("releases", map[string]int{"ticket_id": 10, "name": 5, "environments": 1})
 }
 
 func (repo *Repository) CreateIndex(col string, index string) {
@@ -74,6 +158,28 @@ func (repo *Repository) CreateIndex(col string, index string) {
 	}
 }
 
+func (repo *Repository) CreateTextIndex(col string, weights map[string]int) {
+	c := repo.Session.DB(repo.Config.Database).C(col)
+
+	keys := make([]string, 0, len(weights))
+	for field := range weights {
+		keys = append(keys, "$text:"+field)
+	}
+	sort.Strings(keys)
+
+	err := c.EnsureIndex(mgo.Index{
+		Key:             keys,
+		Name:            col + "_text",
+		Weights:         weights,
+		DefaultLanguage: "none",
+		Background:      true,
+	})
+
+	if err != nil {
+		log.Fatalf("MongoDB text index on %v init failed %v", col, err)
+	}
+}
+
 func (repo *Repository) HostUpsert(host models.DockerHost) {
 	s := repo.Session.Copy()
 	defer s.Close()
@@ -92,6 +198,8 @@ func (repo *Repository) ContainerUpsert(container models.DockerContainer) {
 
 	c := s.DB(repo.Config.Database).C("containers")
 
+	container.EnvKeys = models.EnvKeys(container.Env)
+	container.SearchLabels = models.SearchLabels(container.Labels)
 	_, err := c.UpsertId(container.Id, &container)
 	if err != nil {
 		log.Errorf("Repository containers upsert failed %v", err)
@@ -105,6 +213,8 @@ func (repo *Repository) ContainersUpsert(containers []models.DockerContainer) {
 	c := s.DB(repo.Config.Database).C("containers")
 
 	for _, container := range containers {
+		container.EnvKeys = models.EnvKeys(container.Env)
+		container.SearchLabels = models.SearchLabels(container.Labels)
 		_, err := c.UpsertId(container.Id, &container)
 		if err != nil {
 			log.Errorf("Repository containers upsert failed %v", err)
@@ -156,6 +266,116 @@ func (repo *Repository) ChecksUpsert(checks []models.ConsulHealthCheck) {
 	}
 }
 
+func (repo *Repository) NodeChecksUpsert(checks []models.ConsulNodeCheck) {
+	s := repo.Session.Copy()
+	defer s.Close()
+
+	c := s.DB(repo.Config.Database).C("node_checks")
+
+	for _, check := range checks {
+		res := models.ConsulNodeCheck{}
+		err := c.FindId(check.Id).One(&res)
+		if err != nil {
+			// insert check
+			if err.Error() == "not found" {
+				check.Since = check.Collected
+				err = c.Insert(&check)
+				if err != nil {
+					log.Errorf("Repository node_checks insert failed %v", err)
+				}
+			} else {
+				log.Errorf("Repository node_checks find by id failed %v", err)
+			}
+			continue
+		}
+
+		// if status changed insert into logs and reset since
+		if res.Status != check.Status {
+			checkLog := models.NewConsulNodeCheckLog(res, res.Since, check.Collected)
+			l := s.DB(repo.Config.Database).C("node_checks_log")
+			err = l.Insert(&checkLog)
+			if err != nil {
+				log.Errorf("Repository node_checks_log insert failed %v", err)
+			}
+			check.Since = check.Collected
+		} else {
+			check.Since = res.Since
+		}
+
+		// update check
+		_, err = c.UpsertId(check.Id, &check)
+		if err != nil {
+			log.Errorf("Repository node_checks upsert failed %v", err)
+		}
+	}
+}
+
+// ClusterMembers returns the endpoints checks of a clustered service
+func (repo *Repository) ClusterMembers(env string, service string) ([]models.ClusterHealthCheck, error) {
+	s := repo.Session.Copy()
+	defer s.Close()
+
+	c := s.DB(repo.Config.Database).C("cluster_checks")
+	members := []models.ClusterHealthCheck{}
+	err := c.Find(bson.M{"environment": env, "service_name": service}).All(&members)
+	if err != nil {
+		log.Errorf("Repository cluster_checks members query failed %v", err)
+		return nil, err
+	}
+
+	return members, nil
+}
+
+// ConsulServicesUpsert saves the catalog services and removes the deregistered instances of the environment datacenter
+func (repo *Repository) ConsulServicesUpsert(env string, dc string, services []models.ConsulService, collected time.Time) {
+	s := repo.Session.Copy()
+	defer s.Close()
+
+	c := s.DB(repo.Config.Database).C("consul_services")
+
+	for _, svc := range services {
+		_, err := c.UpsertId(svc.Id, &svc)
+		if err != nil {
+			log.Errorf("Repository consul_services upsert failed %v", err)
+		}
+	}
+
+	_, err := c.RemoveAll(consulPruneQuery(env, dc, collected))
+	if err != nil {
+		log.Errorf("Repository consul_services remove failed for %v %v %v", env, dc, err)
+	}
+}
+
+// ConsulKVUpsert saves the watched KV pairs and removes the deleted keys of the environment datacenter
+func (repo *Repository) ConsulKVUpsert(env string, dc string, pairs []models.ConsulKV, collected time.Time) {
+	s := repo.Session.Copy()
+	defer s.Close()
+
+	c := s.DB(repo.Config.Database).C("consul_kv")
+
+	for _, pair := range pairs {
+		_, err := c.UpsertId(pair.Id, &pair)
+		if err != nil {
+			log.Errorf("Repository consul_kv upsert failed %v", err)
+		}
+	}
+
+	_, err := c.RemoveAll(consulPruneQuery(env, dc, collected))
+	if err != nil {
+		log.Errorf("Repository consul_kv remove failed for %v %v %v", env, dc, err)
+	}
+}
+
+// consulPruneQuery matches the documents of a Consul datacenter not refreshed by the last collect,
+// the documents stored before the datacenter was recorded are matched too
+func consulPruneQuery(env string, dc string, collected time.Time) bson.M {
+	return bson.M{
+		"environment": env,
+		"datacenter":  bson.M{"$in": []interface{}{dc, nil}},
+		"collected":   bson.M{"$lt": collected},
+	}
+}
+
 func (repo *Repository) ClusterChecksUpsert(check models.ClusterHealthCheck) {
 	s := repo.Session.Copy()
 	defer s.Close()
@@ -199,6 +419,70 @@ func (repo *Repository) ClusterChecksUpsert(check models.ClusterHealthCheck) {
 
 }
 
+// ClusterStateUpsert saves the cluster state and returns the previous state and if it changed,
+// a new state is recorded only after it's evaluated confirm times in a row
+// so the partial views of a leader failover don't log spurious transitions
+func (repo *Repository) ClusterStateUpsert(state models.ClusterState, confirm int) (string, bool) {
+	s := repo.Session.Copy()
+	defer s.Close()
+
+	c := s.DB(repo.Config.Database).C("cluster_states")
+
+	res := models.ClusterState{}
+	err := c.FindId(state.Id).One(&res)
+	if err != nil {
+		// insert state
+		if err.Error() == "not found" {
+			state.Since = state.Collected
+			err = c.Insert(&state)
+			if err != nil {
+				log.Errorf("Repository cluster_states insert failed %v", err)
+			}
+			return "", true
+		}
+		log.Errorf("Repository cluster_states find by id failed %v", err)
+		return "", false
+	}
+
+	if res.State == state.State {
+		state.Since = res.Since
+		_, err = c.UpsertId(state.Id, &state)
+		if err != nil {
+			log.Errorf("Repository cluster_states upsert failed %v", err)
+		}
+		return res.State, false
+	}
+
+	// keep the current state until the new one is confirmed
+	runs := 1
+	if res.Pending == state.State {
+		runs = res.PendingRuns + 1
+	}
+	if runs < confirm {
+		err = c.UpdateId(state.Id, bson.M{"$set": bson.M{"pending": state.State, "pending_runs": runs, "collected": state.Collected}})
+		if err != nil {
+			log.Errorf("Repository cluster_states update failed %v", err)
+		}
+		return res.State, false
+	}
+
+	// the state changed, insert into logs and reset since
+	stateLog := models.NewClusterStateLog(res, res.Since, state.Collected)
+	l := s.DB(repo.Config.Database).C("cluster_state_log")
+	err = l.Insert(&stateLog)
+	if err != nil {
+		log.Errorf("Repository cluster_state_log insert failed %v", err)
+	}
+	state.Since = state.Collected
+
+	_, err = c.UpsertId(state.Id, &state)
+	if err != nil {
+		log.Errorf("Repository cluster_states upsert failed %v", err)
+	}
+
+	return res.State, true
+}
+
 func (repo *Repository) SyrosServiceUpsert(service models.SyrosService) {
 	s := repo.Session.Copy()
 	defer s.Close()
@@ -253,6 +537,197 @@ func (repo *Repository) VSphereVMsUpsert(vms []models.VSphereVM) {
 	}
 }
 
+func (repo *Repository) VSphereResourcePoolsUpsert(pools []models.VSphereResourcePool) {
+	s := repo.Session.Copy()
+	defer s.Close()
+
+	c := s.DB(repo.Config.Database).C("vsphere_pools")
+
+	for _, pool := range pools {
+		_, err := c.UpsertId(pool.Id, &pool)
+		if err != nil {
+			log.Errorf("Repository vsphere_pools upsert failed %v", err)
+		}
+	}
+}
+
+func (repo *Repository) VSphereNetworksUpsert(networks []models.VSphereNetwork) {
+	s := repo.Session.Copy()
+	defer s.Close()
+
+	c := s.DB(repo.Config.Database).C("vsphere_networks")
+
+	for _, network := range networks {
+		_, err := c.UpsertId(network.Id, &network)
+		if err != nil {
+			log.Errorf("Repository vsphere_networks upsert failed %v", err)
+		}
+	}
+}
+
+func (repo *Repository) VSphereSnapshotsUpsert(snapshots []models.VSphereSnapshot) {
+	s := repo.Session.Copy()
+	defer s.Close()
+
+	c := s.DB(repo.Config.Database).C("vsphere_snapshots")
+
+	for _, snapshot := range snapshots {
+		_, err := c.UpsertId(snapshot.Id, &snapshot)
+		if err != nil {
+			log.Errorf("Repository vsphere_snapshots upsert failed %v", err)
+		}
+	}
+}
+
+// VSphereCapacityUpsert saves the daily capacity snapshots, the last collection of the day wins
+func (repo *Repository) VSphereCapacityUpsert(snapshots []models.VSphereCapacity) {
+	s := repo.Session.Copy()
+	defer s.Close()
+
+	c := s.DB(repo.Config.Database).C("vsphere_capacity")
+
+	for _, snapshot := range snapshots {
+		_, err := c.UpsertId(snapshot.Id, &snapshot)
+		if err != nil {
+			log.Errorf("Repository vsphere_capacity upsert failed %v", err)
+		}
+	}
+
+	_, err := c.RemoveAll(bson.M{"date": bson.M{"$lt": time.Now().UTC().Add(-365 * 24 * time.Hour)}})
+	if err != nil {
+		log.Errorf("Repository vsphere_capacity remove failed %v", err)
+	}
+}
+
+func (repo *Repository) JenkinsBuildsUpsert(builds []models.JenkinsBuild) {
+	s := repo.Session.Copy()
+	defer s.Close()
+
+	c := s.DB(repo.Config.Database).C("jenkins_builds")
+
+	for _, build := range builds {
+		_, err := c.UpsertId(build.Id, &build)
+		if err != nil {
+			log.Errorf("Repository jenkins_builds upsert failed %v", err)
+		}
+	}
+}
+
+// RegistryImagesUpsert saves the registry images and removes the tags deleted from the registry,
+// the repositories skipped by the collector are not pruned
+func (repo *Repository) RegistryImagesUpsert(registry string, images []models.RegistryImage, skipped []string, collected time.Time) {
+	s := repo.Session.Copy()
+	defer s.Close()
+
+	c := s.DB(repo.Config.Database).C("registry_images")
+
+	for _, image := range images {
+		_, err := c.UpsertId(image.Id, &image)
+		if err != nil {
+			log.Errorf("Repository registry_images upsert failed %v", err)
+		}
+	}
+
+	query := bson.M{"registry": registry, "collected": bson.M{"$lt": collected}}
+	if len(skipped) > 0 {
+		query["repository"] = bson.M{"$nin": skipped}
+	}
+	info, err := c.RemoveAll(query)
+	if err != nil {
+		log.Errorf("Repository registry_images remove failed for %v %v", registry, err)
+	} else if info.Removed > 0 {
+		log.Infof("Repository removed %v deleted tags of %v", info.Removed, registry)
+	}
+}
+
+// IncidentsUpsert saves the incidents, the payload holds all the open incidents of the provider
+// so the stored open incidents missing from it are marked as resolved
+func (repo *Repository) IncidentsUpsert(provider string, incidents []models.Incident, collected time.Time) {
+	s := repo.Session.Copy()
+	defer s.Close()
+
+	c := s.DB(repo.Config.Database).C("incidents")
+
+	for _, incident := range incidents {
+		_, err := c.UpsertId(incident.Id, &incident)
+		if err != nil {
+			log.Errorf("Repository incidents upsert failed %v", err)
+		}
+	}
+
+	info, err := c.UpdateAll(bson.M{
+		"provider":  provider,
+		"status":    bson.M{"$in": []string{models.IncidentTriggered, models.IncidentAcknowledged}},
+		"collected": bson.M{"$lt": collected},
+	}, bson.M{"$set": bson.M{"status": models.IncidentResolved, "resolved": collected, "collected": collected}})
+	if err != nil {
+		log.Errorf("Repository incidents resolve failed for %v %v", provider, err)
+	} else if info.Updated > 0 {
+		log.Infof("Repository resolved %v closed incidents of %v", info.Updated, provider)
+	}
+}
+
+// OnCallsUpsert saves the current on-call shifts and removes the ended ones
+func (repo *Repository) OnCallsUpsert(provider string, oncalls []models.OnCall, collected time.Time) {
+	s := repo.Session.Copy()
+	defer s.Close()
+
+	c := s.DB(repo.Config.Database).C("oncalls")
+
+	for _, oncall := range oncalls {
+		_, err := c.UpsertId(oncall.Id, &oncall)
+		if err != nil {
+			log.Errorf("Repository oncalls upsert failed %v", err)
+		}
+	}
+
+	_, err := c.RemoveAll(bson.M{"provider": provider, "collected": bson.M{"$lt": collected}})
+	if err != nil {
+		log.Errorf("Repository oncalls remove failed for %v %v", provider, err)
+	}
+}
+
+// LogStatsUpsert saves the log buckets, the counts of the current bucket are replaced on every collect
+// and the buckets older than 30 days are removed
+func (repo *Repository) LogStatsUpsert(stats []models.LogStat) {
+	s := repo.Session.Copy()
+	defer s.Close()
+
+	c := s.DB(repo.Config.Database).C("log_stats")
+
+	for _, stat := range stats {
+		_, err := c.UpsertId(stat.Id, &stat)
+		if err != nil {
+			log.Errorf("Repository log_stats upsert failed %v", err)
+		}
+	}
+
+	_, err := c.RemoveAll(bson.M{"bucket": bson.M{"$lt": time.Now().UTC().Add(-30 * 24 * time.Hour)}})
+	if err != nil {
+		log.Errorf("Repository log_stats remove failed %v", err)
+	}
+}
+
+// VSphereMetricsUpsert saves the performance samples and removes the ones older than 7 days
+func (repo *Repository) VSphereMetricsUpsert(metrics []models.VSphereMetric) {
+	s := repo.Session.Copy()
+	defer s.Close()
+
+	c := s.DB(repo.Config.Database).C("vsphere_metrics")
+
+	for _, metric := range metrics {
+		_, err := c.UpsertId(metric.Id, &metric)
+		if err != nil {
+			log.Errorf("Repository vsphere_metrics upsert failed %v", err)
+		}
+	}
+
+	_, err := c.RemoveAll(bson.M{"timestamp": bson.M{"$lt": time.Now().UTC().Add(-7 * 24 * time.Hour)}})
+	if err != nil {
+		log.Errorf("Repository vsphere_metrics remove failed %v", err)
+	}
+}
+
 // Removes stale records
 func (repo *Repository) RunGarbageCollector(cols []string) {
 	if repo.Config.DatabaseStale > 0 {
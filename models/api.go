@@ -9,9 +9,17 @@ type EnvironmentStats struct {
 }
 
 type EnvironmentDto struct {
-	Host        DockerHost        `json:"host"`
-	Containers  []DockerContainer `json:"containers"`
-	Deployments ChartDto          `json:"deployments"`
+	Host        DockerHost               `json:"host"`
+	Containers  []DockerContainer        `json:"containers"`
+	Deployments ChartDto                 `json:"deployments"`
+	Logs        map[string]ContainerLogs `json:"logs"`
+	LogLevels   LogLevelChart            `json:"log_levels"`
+}
+
+type ContainerDto struct {
+	Host       DockerHost               `json:"host"`
+	Containers []DockerContainer        `json:"containers"`
+	Logs       map[string]ContainerLogs `json:"logs"`
 }
 
 type ChartDto struct {
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+type AuditRecord struct {
+	Id        string    `bson:"_id,omitempty" json:"id"`
+	Actor     string    `bson:"actor" json:"actor"`
+	Role      string    `bson:"role" json:"role"`
+	Method    string    `bson:"method" json:"method"`
+	Route     string    `bson:"route" json:"route"`
+	Target    string    `bson:"target" json:"target"`
+	RequestId string    `bson:"request_id" json:"request_id"`
+	SourceIP  string    `bson:"source_ip" json:"source_ip"`
+	Status    int       `bson:"status" json:"status"`
+	Result    string    `bson:"result" json:"result"`
+	Duration  int64     `bson:"duration" json:"duration"`
+	Timestamp time.Time `bson:"timestamp" json:"timestamp"`
+}
@@ -0,0 +1,194 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	CapacityDatastore = "datastore"
+	CapacityCluster   = "cluster"
+)
+
+// VSphereCapacity is the daily capacity snapshot of a datastore or cluster.
+// Datastore capacity and used are in bytes, cluster CPU values are cores and vCPUs,
+// memory values are in bytes and only the powered on VMs are allocated
+type VSphereCapacity struct {
+	Id         string    `bson:"_id,omitempty" json:"id"`
+	Type       string    `bson:"type" json:"type"`
+	EntityId   string    `bson:"entity_id" json:"entity_id"`
+	Name       string    `bson:"name" json:"name"`
+	Datacenter string    `bson:"datacenter" json:"datacenter"`
+	Capacity   int64     `bson:"capacity" json:"capacity"`
+	Used       int64     `bson:"used" json:"used"`
+	CPU        int64     `bson:"cpu" json:"cpu"`
+	VCPU       int64     `bson:"vcpu" json:"vcpu"`
+	Memory     int64     `bson:"memory" json:"memory"`
+	VMemory    int64     `bson:"vmemory" json:"vmemory"`
+	Hosts      int       `bson:"hosts" json:"hosts"`
+	VMs        int       `bson:"vms" json:"vms"`
+	Date       time.Time `bson:"date" json:"date"`
+	Collected  time.Time `bson:"collected" json:"collected"`
+}
+
+// ClusterCapacity holds the allocation and overcommit ratios of a cluster
+type ClusterCapacity struct {
+	Id          string   `json:"id"`
+	Name        string   `json:"name"`
+	Datacenter  string   `json:"datacenter"`
+	Hosts       int      `json:"hosts"`
+	VMs         int      `json:"vms"`
+	CPU         int64    `json:"cpu"`
+	VCPU        int64    `json:"vcpu"`
+	CPURatio    float64  `json:"cpu_ratio"`
+	Memory      int64    `json:"memory"`
+	VMemory     int64    `json:"vmemory"`
+	MemoryRatio float64  `json:"memory_ratio"`
+	Datastores  []string `json:"datastores"`
+}
+
+// CapacityForecast is the linear trend of a resource usage, days until full is -1 if the usage is not growing
+type CapacityForecast struct {
+	Type          string     `json:"type"`
+	Resource      string     `json:"resource"`
+	EntityId      string     `json:"entity_id"`
+	Name          string     `json:"name"`
+	Datacenter    string     `json:"datacenter"`
+	Capacity      int64      `json:"capacity"`
+	Used          int64      `json:"used"`
+	Growth        float64    `json:"growth"`
+	DaysUntilFull int        `json:"days_until_full"`
+	FullDate      *time.Time `json:"full_date"`
+	Samples       int        `json:"samples"`
+}
+
+// Placement is a cluster, host and datastore that can fit a new VM,
+// the ratios and free space are computed with the new VM included
+type Placement struct {
+	Datacenter    string  `json:"datacenter"`
+	Cluster       string  `json:"cluster"`
+	HostId        string  `json:"host_id"`
+	HostName      string  `json:"host_name"`
+	DatastoreId   string  `json:"datastore_id"`
+	DatastoreName string  `json:"datastore_name"`
+	CPURatio      float64 `json:"cpu_ratio"`
+	MemoryRatio   float64 `json:"memory_ratio"`
+	DatastoreFree int64   `json:"datastore_free"`
+	Score         float64 `json:"score"`
+}
+
+// ClusterId prefixes the cluster name with the vCenter and datacenter of the host ID
+func ClusterId(host VSphereHost) string {
+	return fmt.Sprintf("%v%v", strings.TrimSuffix(host.Id, host.Ref), host.Cluster)
+}
+
+// NewClusterCapacity aggregates the hosts and the powered on VMs per cluster,
+// the datastores are the ones used by the cluster VMs, hosts outside of a cluster are ignored
+func NewClusterCapacity(hosts []VSphereHost, vms []VSphereVM) []ClusterCapacity {
+	result := make([]ClusterCapacity, 0)
+	index := make(map[string]int)
+	hostCluster := make(map[string]int)
+
+	for _, host := range hosts {
+		if len(host.Cluster) < 1 {
+			continue
+		}
+		id := ClusterId(host)
+		i, ok := index[id]
+		if !ok {
+			result = append(result, ClusterCapacity{
+				Id:         id,
+				Name:       host.Cluster,
+				Datacenter: host.Datacenter,
+				Datastores: make([]string, 0),
+			})
+			i = len(result) - 1
+			index[id] = i
+		}
+		hostCluster[host.Id] = i
+		result[i].Hosts++
+		result[i].CPU += int64(host.NCPU)
+		result[i].Memory += host.Memory
+	}
+
+	for _, vm := range vms {
+		i, ok := hostCluster[vm.HostId]
+		if !ok {
+			continue
+		}
+		found := false
+		for _, ds := range result[i].Datastores {
+			if ds == vm.DatastoreId {
+				found = true
+				break
+			}
+		}
+		if !found {
+			result[i].Datastores = append(result[i].Datastores, vm.DatastoreId)
+		}
+		if vm.PowerState != "poweredOn" {
+			continue
+		}
+		result[i].VMs++
+		result[i].VCPU += int64(vm.NCPU)
+		result[i].VMemory += vm.Memory * 1024 * 1024
+	}
+
+	for i, c := range result {
+		result[i].CPURatio = Ratio(c.VCPU, c.CPU)
+		result[i].MemoryRatio = Ratio(c.VMemory, c.Memory)
+	}
+
+	return result
+}
+
+// NewVSphereCapacity returns the snapshots of the datastores and clusters for the day of the collected time
+func NewVSphereCapacity(payload *VSpherePayload, collected time.Time) []VSphereCapacity {
+	result := make([]VSphereCapacity, 0)
+	date := time.Date(collected.Year(), collected.Month(), collected.Day(), 0, 0, 0, 0, time.UTC)
+	day := date.Format("2006-01-02")
+
+	for _, ds := range payload.DataStores {
+		result = append(result, VSphereCapacity{
+			Id:         fmt.Sprintf("%v:%v", ds.Id, day),
+			Type:       CapacityDatastore,
+			EntityId:   ds.Id,
+			Name:       ds.Name,
+			Datacenter: ds.Datacenter,
+			Capacity:   ds.Capacity,
+			Used:       ds.Capacity - ds.Free,
+			VMs:        ds.VMs,
+			Date:       date,
+			Collected:  collected,
+		})
+	}
+
+	for _, c := range NewClusterCapacity(payload.Hosts, payload.VMs) {
+		result = append(result, VSphereCapacity{
+			Id:         fmt.Sprintf("%v:%v", c.Id, day),
+			Type:       CapacityCluster,
+			EntityId:   c.Id,
+			Name:       c.Name,
+			Datacenter: c.Datacenter,
+			CPU:        c.CPU,
+			VCPU:       c.VCPU,
+			Memory:     c.Memory,
+			VMemory:    c.VMemory,
+			Hosts:      c.Hosts,
+			VMs:        c.VMs,
+			Date:       date,
+			Collected:  collected,
+		})
+	}
+
+	return result
+}
+
+// Ratio returns the allocated to physical ratio rounded to two decimals, zero if there is no physical capacity
+func Ratio(allocated int64, physical int64) float64 {
+	if physical < 1 {
+		return 0
+	}
+	return float64(int64(float64(allocated)/float64(physical)*100+0.5)) / 100
+}
@@ -49,3 +49,104 @@ func NewClusterHealthCheckLog(check ClusterHealthCheck, begin time.Time, end tim
 
 	return log
 }
+
+const (
+	ClusterHealthy    = "healthy"
+	ClusterDegraded   = "degraded"
+	ClusterLeaderless = "leaderless"
+	ClusterSplitBrain = "split-brain"
+)
+
+type ClusterState struct {
+	Id          string    `bson:"_id,omitempty" json:"id"`
+	State       string    `bson:"state" json:"state"`
+	ServiceName string    `bson:"service_name" json:"service_name"`
+	Environment string    `bson:"environment" json:"environment"`
+	Members     int       `bson:"members" json:"members"`
+	Leaders     []string  `bson:"leaders" json:"leaders"`
+	Followers   []string  `bson:"followers" json:"followers"`
+	Offline     []string  `bson:"offline" json:"offline"`
+	Pending     string    `bson:"pending" json:"pending"`
+	PendingRuns int       `bson:"pending_runs" json:"pending_runs"`
+	Collected   time.Time `bson:"collected" json:"collected"`
+	Since       time.Time `bson:"since" json:"since"`
+}
+
+type ClusterStateLog struct {
+	Id          string    `bson:"_id,omitempty" json:"id"`
+	StateId     string    `bson:"state_id,omitempty" json:"state_id"`
+	State       string    `bson:"state" json:"state"`
+	ServiceName string    `bson:"service_name" json:"service_name"`
+	Environment string    `bson:"environment" json:"environment"`
+	Members     int       `bson:"members" json:"members"`
+	Leaders     []string  `bson:"leaders" json:"leaders"`
+	Offline     []string  `bson:"offline" json:"offline"`
+	Begin       time.Time `bson:"begin" json:"begin"`
+	End         time.Time `bson:"end" json:"end"`
+	Timestamp   time.Time `bson:"timestamp" json:"timestamp"`
+	Duration    int64     `bson:"duration" json:"duration"`
+}
+
+// NewClusterState aggregates the endpoints checks of a clustered service,
+// members that haven't reported since the stale time are counted as offline
+// and members that haven't reported since the expired time are ignored
+func NewClusterState(env string, service string, members []ClusterHealthCheck, stale time.Time, expired time.Time) ClusterState {
+	state := ClusterState{
+		Id:          Hash(env + service),
+		ServiceName: service,
+		Environment: env,
+		Leaders:     []string{},
+		Followers:   []string{},
+		Offline:     []string{},
+		Collected:   time.Now().UTC(),
+	}
+
+	for _, m := range members {
+		if m.Collected.Before(expired) {
+			continue
+		}
+		state.Members++
+		switch {
+		case m.Collected.Before(stale):
+			state.Offline = append(state.Offline, m.HostName)
+		case m.Status == "leader":
+			state.Leaders = append(state.Leaders, m.HostName)
+		case m.Status == "follower":
+			state.Followers = append(state.Followers, m.HostName)
+		default:
+			state.Offline = append(state.Offline, m.HostName)
+		}
+	}
+
+	switch {
+	case len(state.Leaders) > 1:
+		state.State = ClusterSplitBrain
+	case len(state.Leaders) == 0:
+		state.State = ClusterLeaderless
+	case len(state.Offline) > 0:
+		state.State = ClusterDegraded
+	default:
+		state.State = ClusterHealthy
+	}
+
+	return state
+}
+
+func NewClusterStateLog(state ClusterState, begin time.Time, end time.Time) ClusterStateLog {
+	log := ClusterStateLog{
+		Begin:       begin,
+		End:         end,
+		StateId:     state.Id,
+		State:       state.State,
+		ServiceName: state.ServiceName,
+		Environment: state.Environment,
+		Members:     state.Members,
+		Leaders:     state.Leaders,
+		Offline:     state.Offline,
+		Timestamp:   time.Now().UTC(),
+	}
+
+	log.Duration = int64(end.Sub(begin).Seconds())
+
+	return log
+}
@@ -1,10 +1,20 @@
 package models
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
+// ConsulPayload carries the health checks and, if Catalog is set, the full services catalog and KV pairs
 type ConsulPayload struct {
 	HealthChecks []ConsulHealthCheck `json:"health_checks"`
+	NodeChecks   []ConsulNodeCheck   `json:"node_checks"`
+	Catalog      bool                `json:"catalog"`
+	Services     []ConsulService     `json:"services"`
+	KV           []ConsulKV          `json:"kv"`
+	Datacenter   string              `json:"datacenter"`
 	Environment  string              `json:"environment"`
+	Collected    time.Time           `json:"collected"`
 }
 
 type ConsulHealthCheck struct {
@@ -65,3 +75,131 @@ func NewConsulHealthCheckLog(check ConsulHealthCheck, begin time.Time, end time.
 
 	return log
 }
+
+// ConsulService is a service instance registered in the Consul catalog,
+// host and container are parsed from the registrator service id
+type ConsulService struct {
+	Id             string            `bson:"_id,omitempty" json:"id"`
+	Name           string            `bson:"name" json:"name"`
+	ServiceID      string            `bson:"service_id" json:"service_id"`
+	Node           string            `bson:"node" json:"node"`
+	Address        string            `bson:"address" json:"address"`
+	ServiceAddress string            `bson:"service_address" json:"service_address"`
+	Port           int               `bson:"port" json:"port"`
+	Tags           []string          `bson:"tags" json:"tags"`
+	Meta           map[string]string `bson:"meta" json:"meta"`
+	HostName       string            `bson:"host_name" json:"host_name"`
+	ContainerName  string            `bson:"container_name" json:"container_name"`
+	Datacenter     string            `bson:"datacenter" json:"datacenter"`
+	Collected      time.Time         `bson:"collected" json:"collected"`
+	Environment    string            `bson:"environment" json:"environment"`
+}
+
+// ConsulKV is a pair under a watched prefix, the value is stored and served as is
+type ConsulKV struct {
+	Id          string    `bson:"_id,omitempty" json:"id"`
+	Key         string    `bson:"key" json:"key"`
+	Value       string    `bson:"value" json:"value"`
+	ModifyIndex uint64    `bson:"modify_index" json:"modify_index"`
+	Datacenter  string    `bson:"datacenter" json:"datacenter"`
+	Collected   time.Time `bson:"collected" json:"collected"`
+	Environment string    `bson:"environment" json:"environment"`
+}
+
+type ConsulServiceStats struct {
+	Environment string   `json:"environment"`
+	Name        string   `json:"name"`
+	Instances   int      `json:"instances"`
+	Nodes       []string `json:"nodes"`
+	Tags        []string `json:"tags"`
+}
+
+// ConsulMismatch is a registration without a running container or a running container with published ports not registered
+type ConsulMismatch struct {
+	Type          string `json:"type"`
+	Environment   string `json:"environment"`
+	HostName      string `json:"host_name"`
+	ContainerName string `json:"container_name"`
+	ContainerId   string `json:"container_id"`
+	ServiceID     string `json:"service_id"`
+	ServiceName   string `json:"service_name"`
+}
+
+// ParseRegistratorServiceID splits a gliderlabs/registrator service id host:container:port[:udp]
+func ParseRegistratorServiceID(id string) (string, string, string, bool) {
+	parts := strings.Split(id, ":")
+	if len(parts) < 3 || len(parts) > 4 || (len(parts) == 4 && parts[3] != "udp") {
+		return "", "", "", false
+	}
+	return parts[0], parts[1], parts[2], true
+}
+
+// ConsulNodeCheck is a health check without a service like serfHealth,
+// the host id matches the Docker host id of the node
+type ConsulNodeCheck struct {
+	Id          string    `bson:"_id,omitempty" json:"id"`
+	HostId      string    `bson:"host_id" json:"host_id"`
+	Node        string    `bson:"node" json:"node"`
+	CheckID     string    `bson:"check_id" json:"check_id"`
+	Name        string    `bson:"name" json:"name"`
+	Status      string    `bson:"status" json:"status"`
+	Notes       string    `bson:"notes" json:"notes"`
+	Output      string    `bson:"output" json:"output"`
+	Collected   time.Time `bson:"collected" json:"collected"`
+	Since       time.Time `bson:"since" json:"since"`
+	Environment string    `bson:"environment" json:"environment"`
+}
+
+type ConsulNodeCheckLog struct {
+	Id          string    `bson:"_id,omitempty" json:"id"`
+	CheckId     string    `bson:"check_id,omitempty" json:"check_id"`
+	HostId      string    `bson:"host_id" json:"host_id"`
+	Node        string    `bson:"node" json:"node"`
+	Name        string    `bson:"name" json:"name"`
+	Status      string    `bson:"status" json:"status"`
+	Notes       string    `bson:"notes" json:"notes"`
+	Output      string    `bson:"output" json:"output"`
+	Begin       time.Time `bson:"begin" json:"begin"`
+	End         time.Time `bson:"end" json:"end"`
+	Timestamp   time.Time `bson:"timestamp" json:"timestamp"`
+	Duration    int64     `bson:"duration" json:"duration"`
+	Environment string    `bson:"environment" json:"environment"`
+}
+
+func NewConsulNodeCheckLog(check ConsulNodeCheck, begin time.Time, end time.Time) ConsulNodeCheckLog {
+	log := ConsulNodeCheckLog{
+		Begin:       begin,
+		End:         end,
+		CheckId:     check.Id,
+		Environment: check.Environment,
+		HostId:      check.HostId,
+		Node:        check.Node,
+		Name:        check.Name,
+		Notes:       check.Notes,
+		Output:      check.Output,
+		Status:      check.Status,
+		Timestamp:   time.Now().UTC(),
+	}
+
+	log.Duration = int64(end.Sub(begin).Seconds())
+
+	return log
+}
+
+// ConsulNode groups the node checks and links the node to the Docker and vSphere hosts with the same name
+type ConsulNode struct {
+	Node          string            `json:"node"`
+	Environment   string            `json:"environment"`
+	Status        string            `json:"status"`
+	DockerHostId  string            `json:"docker_host_id"`
+	VSphereHostId string            `json:"vsphere_host_id"`
+	Checks        []ConsulNodeCheck `json:"checks"`
+}
+
+// HealthChart holds the seconds spent in each status per day
+type HealthChart struct {
+	Labels   []string `json:"labels"`
+	Passing  []int64  `json:"passing"`
+	Warning  []int64  `json:"warning"`
+	Critical []int64  `json:"critical"`
+}
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// DeliveryMetrics holds the DORA delivery metrics of a service and environment over a time window,
+// empty service or environment means all
+type DeliveryMetrics struct {
+	Service             string    `json:"service"`
+	Environment         string    `json:"environment"`
+	From                time.Time `json:"from"`
+	To                  time.Time `json:"to"`
+	Deployments         int       `json:"deployments"`
+	Failures            int       `json:"failures"`
+	Restored            int       `json:"restored"`
+	DeploymentFrequency float64   `json:"deployment_frequency"`
+	ChangeFailureRate   float64   `json:"change_failure_rate"`
+	TimeToRestore       int64     `json:"time_to_restore"`
+}
+
+type DeliveryMetricsDto struct {
+	Metrics []DeliveryMetrics `json:"metrics"`
+	Total   DeliveryMetrics   `json:"total"`
+	Chart   ChartDto          `json:"chart"`
+}
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+type EnvironmentDiff struct {
+	From      string        `json:"from"`
+	To        string        `json:"to"`
+	Services  []ServiceDiff `json:"services"`
+	Changed   int           `json:"changed"`
+	Missing   int           `json:"missing"`
+	Extra     int           `json:"extra"`
+	Generated time.Time     `json:"generated"`
+}
+
+// ServiceDiff compares the running replicas of a service between two environments,
+// env vars are compared by key only so values are never exposed
+type ServiceDiff struct {
+	Service           string   `json:"service"`
+	Status            string   `json:"status"`
+	FromImage         string   `json:"from_image"`
+	ToImage           string   `json:"to_image"`
+	FromTags          []string `json:"from_tags"`
+	ToTags            []string `json:"to_tags"`
+	FromHosts         []string `json:"from_hosts"`
+	ToHosts           []string `json:"to_hosts"`
+	EnvOnlyInFrom     []string `json:"env_only_in_from"`
+	EnvOnlyInTo       []string `json:"env_only_in_to"`
+	FromRestartPolicy string   `json:"from_restart_policy"`
+	ToRestartPolicy   string   `json:"to_restart_policy"`
+	PortsOnlyInFrom   []string `json:"ports_only_in_from"`
+	PortsOnlyInTo     []string `json:"ports_only_in_to"`
+}
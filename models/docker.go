@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 type DockerPayload struct {
 	Host       DockerHost        `json:"host"`
@@ -59,6 +62,8 @@ type DockerContainer struct {
 	Name          string            `bson:"name" json:"name"`
 	RestartCount  int               `bson:"restart_count" json:"restart_count"`
 	Env           []string          `bson:"env" json:"env"`
+	EnvKeys       []string          `bson:"env_keys" json:"env_keys"`
+	SearchLabels  []string          `bson:"search_labels" json:"-"`
 	PortBindings  map[string]string `bson:"port_bindings" json:"port_bindings"`
 	NetworkMode   string            `bson:"network_mode" json:"network_mode"`
 	RestartPolicy string            `bson:"restart_policy" json:"restart_policy"`
@@ -69,3 +74,21 @@ type DockerContainer struct {
 	Collected     time.Time         `bson:"collected" json:"collected"`
 	Environment   string            `bson:"environment" json:"environment"`
 }
+
+// EnvKeys returns the variable names of a KEY=value env list
+func EnvKeys(env []string) []string {
+	keys := make([]string, 0, len(env))
+	for _, e := range env {
+		keys = append(keys, strings.SplitN(e, "=", 2)[0])
+	}
+	return keys
+}
+
+// SearchLabels flattens the container labels to "key value" strings for text indexing
+func SearchLabels(labels map[string]string) []string {
+	result := make([]string, 0, len(labels))
+	for key, value := range labels {
+		result = append(result, key+" "+value)
+	}
+	return result
+}
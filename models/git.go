@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+type GitCommit struct {
+	Hash    string    `bson:"hash" json:"hash"`
+	Author  string    `bson:"author" json:"author"`
+	Email   string    `bson:"email" json:"email"`
+	Date    time.Time `bson:"date" json:"date"`
+	Subject string    `bson:"subject" json:"subject"`
+}
+
+type ServiceChangelog struct {
+	Service     string      `json:"service"`
+	Environment string      `json:"environment"`
+	FromTag     string      `json:"from_tag"`
+	ToTag       string      `json:"to_tag"`
+	Authors     []string    `json:"authors"`
+	Commits     []GitCommit `json:"commits"`
+}
+
+type ReleaseChangelog struct {
+	ReleaseId string             `json:"release_id"`
+	TicketId  string             `json:"ticket_id"`
+	Services  []ServiceChangelog `json:"services"`
+	Commits   int                `json:"commits"`
+}
@@ -0,0 +1,94 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+type ImageRef struct {
+	Registry   string `bson:"registry" json:"registry"`
+	Repository string `bson:"repository" json:"repository"`
+	Tag        string `bson:"tag" json:"tag"`
+	Digest     string `bson:"digest" json:"digest"`
+}
+
+// ParseImageRef splits a Docker image reference into registry, repository, tag and digest,
+// the registry defaults to docker.io and the tag to latest when no digest is present
+func ParseImageRef(image string) ImageRef {
+	ref := ImageRef{Registry: "docker.io"}
+
+	// containers whose tag was removed report the image id
+	if strings.HasPrefix(image, "sha256:") {
+		ref.Repository = "<none>"
+		ref.Digest = image
+		return ref
+	}
+
+	name := image
+	if i := strings.Index(name, "@"); i > -1 {
+		ref.Digest = name[i+1:]
+		name = name[:i]
+	}
+
+	if i := strings.LastIndex(name, ":"); i > -1 && !strings.Contains(name[i:], "/") {
+		ref.Tag = name[i+1:]
+		name = name[:i]
+	}
+
+	// the first component is a registry if it looks like a host
+	if i := strings.Index(name, "/"); i > -1 {
+		host := name[:i]
+		if strings.ContainsAny(host, ".:") || host == "localhost" {
+			ref.Registry = host
+			name = name[i+1:]
+		}
+	}
+	ref.Repository = name
+
+	if len(ref.Tag) < 1 && len(ref.Digest) < 1 {
+		ref.Tag = "latest"
+	}
+
+	return ref
+}
+
+// Version returns the tag or the digest if the image is not tagged
+func (ref ImageRef) Version() string {
+	if len(ref.Tag) > 0 {
+		return ref.Tag
+	}
+	return ref.Digest
+}
+
+// Name returns registry/repository omitting the default registry
+func (ref ImageRef) Name() string {
+	if ref.Registry == "docker.io" {
+		return ref.Repository
+	}
+	return ref.Registry + "/" + ref.Repository
+}
+
+type ImageInventory struct {
+	Registry   string         `json:"registry"`
+	Repository string         `json:"repository"`
+	Latest     string         `json:"latest"`
+	Versions   []ImageVersion `json:"versions"`
+}
+
+type ImageVersion struct {
+	Tag           string    `json:"tag"`
+	Digest        string    `json:"digest"`
+	Containers    int       `json:"containers"`
+	Hosts         []string  `json:"hosts"`
+	Environments  []string  `json:"environments"`
+	FirstDeployed time.Time `json:"first_deployed"`
+	LastDeployed  time.Time `json:"last_deployed"`
+	Outdated      bool      `json:"outdated"`
+}
+
+type ImageDrift struct {
+	Service     string              `json:"service"`
+	Environment string              `json:"environment"`
+	Repository  string              `json:"repository"`
+	Tags        map[string][]string `json:"tags"`
+}
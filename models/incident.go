@@ -0,0 +1,83 @@
+package models
+
+import "time"
+
+// Incident statuses
+const (
+	IncidentTriggered    = "triggered"
+	IncidentAcknowledged = "acknowledged"
+	IncidentResolved     = "resolved"
+)
+
+type IncidentPayload struct {
+	Provider  string     `json:"provider"`
+	Incidents []Incident `json:"incidents"`
+	OnCalls   []OnCall   `json:"oncalls"`
+	Collected time.Time  `json:"collected"`
+}
+
+type Incident struct {
+	Id           string    `bson:"_id,omitempty" json:"id"`
+	Provider     string    `bson:"provider" json:"provider"`
+	Number       int       `bson:"number" json:"number"`
+	Title        string    `bson:"title" json:"title"`
+	Status       string    `bson:"status" json:"status"`
+	Urgency      string    `bson:"urgency" json:"urgency"`
+	Service      string    `bson:"service" json:"service"`
+	Assignees    []string  `bson:"assignees" json:"assignees"`
+	Url          string    `bson:"url" json:"url"`
+	Created      time.Time `bson:"created" json:"created"`
+	Acknowledged time.Time `bson:"acknowledged" json:"acknowledged"`
+	Resolved     time.Time `bson:"resolved" json:"resolved"`
+	Collected    time.Time `bson:"collected" json:"collected"`
+}
+
+type OnCall struct {
+	Id               string    `bson:"_id,omitempty" json:"id"`
+	Provider         string    `bson:"provider" json:"provider"`
+	User             string    `bson:"user" json:"user"`
+	Email            string    `bson:"email" json:"email"`
+	Schedule         string    `bson:"schedule" json:"schedule"`
+	EscalationPolicy string    `bson:"escalation_policy" json:"escalation_policy"`
+	Level            int       `bson:"level" json:"level"`
+	Start            time.Time `bson:"start" json:"start"`
+	End              time.Time `bson:"end" json:"end"`
+	Collected        time.Time `bson:"collected" json:"collected"`
+}
+
+type Handover struct {
+	Id            string    `bson:"_id,omitempty" json:"id"`
+	From          string    `bson:"from" json:"from"`
+	To            string    `bson:"to" json:"to"`
+	Summary       string    `bson:"summary" json:"summary"`
+	Notes         string    `bson:"notes" json:"notes"`
+	OpenIncidents []string  `bson:"open_incidents" json:"open_incidents"`
+	CreatedBy     string    `bson:"created_by" json:"created_by"`
+	Mailed        bool      `bson:"mailed" json:"mailed"`
+	MailError     string    `bson:"mail_error" json:"mail_error"`
+	Timestamp     time.Time `bson:"timestamp" json:"timestamp"`
+}
+
+type IncidentStats struct {
+	Open        int   `json:"open"`
+	Opened24h   int   `json:"opened_24h"`
+	Resolved24h int   `json:"resolved_24h"`
+	Opened30d   int   `json:"opened_30d"`
+	Resolved30d int   `json:"resolved_30d"`
+	MTTA30d     int64 `json:"mtta_30d"`
+	MTTR30d     int64 `json:"mttr_30d"`
+}
+
+type IncidentReport struct {
+	Month     string         `json:"month"`
+	From      time.Time      `json:"from"`
+	To        time.Time      `json:"to"`
+	Total     int            `json:"total"`
+	Resolved  int            `json:"resolved"`
+	MTTA      int64          `json:"mtta"`
+	MTTR      int64          `json:"mttr"`
+	Services  map[string]int `json:"services"`
+	Urgencies map[string]int `json:"urgencies"`
+	Assignees map[string]int `json:"assignees"`
+	Incidents []Incident     `json:"incidents"`
+}
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+type JenkinsPayload struct {
+	Environment string         `json:"environment"`
+	Builds      []JenkinsBuild `json:"builds"`
+}
+
+type JenkinsBuild struct {
+	Id          string    `bson:"_id,omitempty" json:"id"`
+	Job         string    `bson:"job" json:"job"`
+	Number      int       `bson:"number" json:"number"`
+	Result      string    `bson:"result" json:"result"`
+	Building    bool      `bson:"building" json:"building"`
+	Duration    int64     `bson:"duration" json:"duration"`
+	Timestamp   time.Time `bson:"timestamp" json:"timestamp"`
+	Url         string    `bson:"url" json:"url"`
+	Tag         string    `bson:"tag" json:"tag"`
+	Commit      string    `bson:"commit" json:"commit"`
+	Artifacts   []string  `bson:"artifacts" json:"artifacts"`
+	Environment string    `bson:"environment" json:"environment"`
+	Collected   time.Time `bson:"collected" json:"collected"`
+}
@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+type LogPayload struct {
+	Endpoint    string    `json:"endpoint"`
+	Environment string    `json:"environment"`
+	Stats       []LogStat `json:"stats"`
+	Collected   time.Time `json:"collected"`
+}
+
+// LogStat holds the log levels count of a container for a time bucket,
+// the container key is the name or the id depending on the group by field
+type LogStat struct {
+	Id          string    `bson:"_id,omitempty" json:"id"`
+	Environment string    `bson:"environment" json:"environment"`
+	GroupBy     string    `bson:"group_by" json:"group_by"`
+	Field       string    `bson:"field" json:"field"`
+	LevelField  string    `bson:"level_field" json:"level_field"`
+	Container   string    `bson:"container" json:"container"`
+	Bucket      time.Time `bson:"bucket" json:"bucket"`
+	Info        int64     `bson:"info" json:"info"`
+	Warn        int64     `bson:"warn" json:"warn"`
+	Error       int64     `bson:"error" json:"error"`
+	Other       int64     `bson:"other" json:"other"`
+	Collected   time.Time `bson:"collected" json:"collected"`
+}
+
+type ContainerLogs struct {
+	ContainerId  string        `json:"container_id"`
+	Info         int64         `json:"info"`
+	Warn         int64         `json:"warn"`
+	Error        int64         `json:"error"`
+	Other        int64         `json:"other"`
+	Kibana       string        `json:"kibana"`
+	KibanaErrors string        `json:"kibana_errors"`
+	Chart        LogLevelChart `json:"chart"`
+}
+
+type LogLevelChart struct {
+	Labels []string `json:"labels"`
+	Info   []int64  `json:"info"`
+	Warn   []int64  `json:"warn"`
+	Error  []int64  `json:"error"`
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// MaintenanceWindow allows deployments on an environment,
+// recurring windows open on each Schedule activation for Duration minutes
+// and one-off windows are open between Begin and End
+type MaintenanceWindow struct {
+	Id          string    `bson:"_id,omitempty" json:"id"`
+	Environment string    `bson:"environment" json:"environment"`
+	Name        string    `bson:"name" json:"name"`
+	Schedule    string    `bson:"schedule" json:"schedule"`
+	Duration    int       `bson:"duration" json:"duration"`
+	Begin       time.Time `bson:"begin" json:"begin"`
+	End         time.Time `bson:"end" json:"end"`
+	CreatedBy   string    `bson:"created_by" json:"created_by"`
+	Timestamp   time.Time `bson:"timestamp" json:"timestamp"`
+}
+
+type MaintenanceStatus struct {
+	Environment string              `json:"environment"`
+	Open        bool                `json:"open"`
+	Windows     []MaintenanceWindow `json:"windows"`
+	NextOpen    time.Time           `json:"next_open"`
+}
@@ -0,0 +1,20 @@
+package models
+
+type MetricsGraph struct {
+	Target string          `json:"target"`
+	Name   string          `json:"name"`
+	Graph  string          `json:"graph"`
+	Title  string          `json:"title"`
+	Unit   string          `json:"unit"`
+	Start  int64           `json:"start"`
+	End    int64           `json:"end"`
+	Step   int64           `json:"step"`
+	Series []MetricsSeries `json:"series"`
+}
+
+// MetricsSeries holds the [unix timestamp, value] points of a Prometheus range vector
+type MetricsSeries struct {
+	Name   string       `json:"name"`
+	Query  string       `json:"query"`
+	Points [][2]float64 `json:"points"`
+}
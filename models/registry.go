@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// RegistryPayload holds the images of a registry collect,
+// Skipped lists the repositories with failed tag or manifest calls whose images are not pruned
+type RegistryPayload struct {
+	Registry  string          `json:"registry"`
+	Images    []RegistryImage `json:"images"`
+	Skipped   []string        `json:"skipped"`
+	Collected time.Time       `json:"collected"`
+}
+
+type RegistryImage struct {
+	Id          string    `bson:"_id,omitempty" json:"id"`
+	Registry    string    `bson:"registry" json:"registry"`
+	Repository  string    `bson:"repository" json:"repository"`
+	Tag         string    `bson:"tag" json:"tag"`
+	Digest      string    `bson:"digest" json:"digest"`
+	Size        int64     `bson:"size" json:"size"`
+	Pushed      time.Time `bson:"pushed" json:"pushed"`
+	Environment string    `bson:"environment" json:"environment"`
+	Collected   time.Time `bson:"collected" json:"collected"`
+}
+
+// RegistryImageUsage tells if a registry image is used by containers,
+// a tag is in use if a container runs it by tag or by digest
+type RegistryImageUsage struct {
+	RegistryImage
+	InUse        bool      `json:"in_use"`
+	Containers   int       `json:"containers"`
+	Environments []string  `json:"environments"`
+	LastUsed     time.Time `json:"last_used"`
+}
+
+type RegistryCleanupCandidate struct {
+	RegistryImage
+	Reason string `json:"reason"`
+}
+
+type RegistrySummary struct {
+	Registry     string    `json:"registry"`
+	Repositories int       `json:"repositories"`
+	Tags         int       `json:"tags"`
+	Size         int64     `json:"size"`
+	Hosts        int       `json:"hosts"`
+	Collected    time.Time `json:"collected"`
+}
@@ -9,19 +9,50 @@ type Release struct {
 	Begin        time.Time `bson:"begin" json:"begin"`
 	End          time.Time `bson:"end" json:"end"`
 	Deployments  int       `bson:"deployments" json:"deployments"`
+	Succeeded    int       `bson:"succeeded" json:"succeeded"`
+	Failed       int       `bson:"failed" json:"failed"`
+	RolledBack   int       `bson:"rolled_back" json:"rolled_back"`
+	SuccessRate  float64   `bson:"success_rate" json:"success_rate"`
+	LeadTime     int64     `bson:"lead_time" json:"lead_time"`
 	Environments string    `bson:"environments" json:"environments"`
 	Log          string    `bson:"log" json:"log"`
 }
 
+// Deployment statuses and actions
+const (
+	DeploymentRunning    = "running"
+	DeploymentSucceeded  = "succeeded"
+	DeploymentFailed     = "failed"
+	DeploymentRolledBack = "rolled-back"
+	DeploymentFinished   = "Finished" // legacy status, counts as succeeded
+	ActionPromote        = "promote"
+	ActionReload         = "reload"
+	ActionRollback       = "rollback"
+	ActionMigrate        = "migrate"
+)
+
 type Deployment struct {
 	Id          string            `bson:"_id,omitempty" json:"id"`
 	ReleaseId   string            `bson:"release_id,omitempty" json:"release_id"`
 	TicketId    string            `bson:"ticket_id,omitempty" json:"ticket_id"`
 	Status      string            `bson:"status" json:"status"`
+	Action      string            `bson:"action" json:"action"`
+	Initiator   string            `bson:"initiator" json:"initiator"`
 	ServiceName string            `bson:"service_name" json:"service_name"`
 	HostName    string            `bson:"host_name" json:"host_name"`
 	Environment string            `bson:"environment" json:"environment"`
 	Timestamp   time.Time         `bson:"timestamp" json:"timestamp"`
+	Begin       time.Time         `bson:"begin" json:"begin"`
+	End         time.Time         `bson:"end" json:"end"`
+	Duration    int64             `bson:"duration" json:"duration"`
+	Tag         string            `bson:"tag" json:"tag"`
+	PreviousTag string            `bson:"previous_tag" json:"previous_tag"`
+	Commits     []GitCommit       `bson:"commits" json:"commits"`
+	CommitCount int               `bson:"commit_count" json:"commit_count"`
+	Authors     []string          `bson:"authors" json:"authors"`
+	BuildId     string            `bson:"build_id" json:"build_id"`
+	BuildNumber int               `bson:"build_number" json:"build_number"`
+	BuildUrl    string            `bson:"build_url" json:"build_url"`
 	Image       string            `bson:"image" json:"image"`
 	Command     string            `bson:"command" json:"command"`
 	Labels      map[string]string `bson:"labels" json:"labels"`
@@ -29,11 +60,51 @@ type Deployment struct {
 	Log         string            `bson:"log" json:"log"`
 }
 
+// Release plan and step statuses
+const (
+	PlanDraft     = "draft"
+	PlanRunning   = "running"
+	PlanFailed    = "failed"
+	PlanSucceeded = "succeeded"
+	StepPending   = "pending"
+	StepRunning   = "running"
+	StepFailed    = "failed"
+	StepSucceeded = "succeeded"
+	Approved      = "approved"
+	Rejected      = "rejected"
+)
+
 type ReleasePlan struct {
-	Id        string    `bson:"_id,omitempty" json:"id"`
-	TicketId  string    `bson:"ticket_id,omitempty" json:"ticket_id"`
-	Name      string    `bson:"name" json:"name"`
-	Timestamp time.Time `bson:"timestamp" json:"timestamp"`
+	Id        string             `bson:"_id,omitempty" json:"id"`
+	TicketId  string             `bson:"ticket_id,omitempty" json:"ticket_id"`
+	Name      string             `bson:"name" json:"name"`
+	Status    string             `bson:"status" json:"status"`
+	CreatedBy string             `bson:"created_by" json:"created_by"`
+	Gates     []ApprovalGate     `bson:"gates" json:"gates"`
+	Decisions []ApprovalDecision `bson:"decisions" json:"decisions"`
+	Scheduled time.Time          `bson:"scheduled_at" json:"scheduled_at"`
+	Log       string             `bson:"log" json:"log"`
+	Begin     time.Time          `bson:"begin" json:"begin"`
+	End       time.Time          `bson:"end" json:"end"`
+	Timestamp time.Time          `bson:"timestamp" json:"timestamp"`
+}
+
+// ApprovalGate requires a number of approvals from the listed roles
+// before the plan steps targeting the environment can run
+type ApprovalGate struct {
+	Environment string   `bson:"environment" json:"environment"`
+	Roles       []string `bson:"roles" json:"roles"`
+	Required    int      `bson:"required" json:"required"`
+}
+
+// ApprovalDecision is an approve or reject vote, decisions are never removed from the plan
+type ApprovalDecision struct {
+	Environment string    `bson:"environment" json:"environment"`
+	User        string    `bson:"user" json:"user"`
+	Role        string    `bson:"role" json:"role"`
+	Decision    string    `bson:"decision" json:"decision"`
+	Comment     string    `bson:"comment" json:"comment"`
+	Timestamp   time.Time `bson:"timestamp" json:"timestamp"`
 }
 
 type ReleaseStep struct {
@@ -41,10 +112,17 @@ type ReleaseStep struct {
 	ReleaseId   string    `bson:"releaseplan_id,omitempty" json:"releaseplan_id"`
 	TicketId    string    `bson:"ticket_id,omitempty" json:"ticket_id"`
 	Order       int       `bson:"order" json:"order"`
+	Name        string    `bson:"name" json:"name"`
 	Environment string    `bson:"environment" json:"environment"`
+	Host        string    `bson:"host" json:"host"`
 	Command     string    `bson:"command" json:"command"`
 	Status      string    `bson:"status" json:"status"`
 	Log         string    `bson:"log" json:"log"`
 	Begin       time.Time `bson:"begin" json:"begin"`
 	End         time.Time `bson:"end" json:"end"`
 }
+
+type ReleasePlanDto struct {
+	Plan  ReleasePlan   `json:"plan"`
+	Steps []ReleaseStep `json:"steps"`
+}
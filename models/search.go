@@ -0,0 +1,10 @@
+package models
+
+type SearchResult struct {
+	Type        string  `json:"type"`
+	Id          string  `json:"id"`
+	Title       string  `json:"title"`
+	Details     string  `json:"details"`
+	Environment string  `json:"environment"`
+	Score       float64 `json:"score"`
+}
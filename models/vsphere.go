@@ -3,14 +3,22 @@ package models
 import "time"
 
 type VSpherePayload struct {
-	Hosts      []VSphereHost      `json:"hosts"`
-	DataStores []VSphereDatastore `json:"data_stores"`
-	VMs        []VSphereVM        `json:"vms"`
+	Datacenters   []string              `json:"datacenters"`
+	Hosts         []VSphereHost         `json:"hosts"`
+	DataStores    []VSphereDatastore    `json:"data_stores"`
+	VMs           []VSphereVM           `json:"vms"`
+	ResourcePools []VSphereResourcePool `json:"resource_pools"`
+	Networks      []VSphereNetwork      `json:"networks"`
+	Snapshots     []VSphereSnapshot     `json:"snapshots"`
+	Metrics       []VSphereMetric       `json:"metrics"`
 }
 
 type VSphereDatastore struct {
 	Id          string    `bson:"_id,omitempty" json:"id"`
+	Ref         string    `bson:"ref" json:"ref"`
 	Name        string    `bson:"name" json:"name"`
+	Datacenter  string    `bson:"datacenter" json:"datacenter"`
+	Folder      string    `bson:"folder" json:"folder"`
 	Type        string    `bson:"type" json:"type"`
 	Capacity    int64     `bson:"capacity" json:"capacity"`
 	Free        int64     `bson:"free" json:"free"`
@@ -21,7 +29,10 @@ type VSphereDatastore struct {
 
 type VSphereHost struct {
 	Id          string     `bson:"_id,omitempty" json:"id"`
+	Ref         string     `bson:"ref" json:"ref"`
 	Name        string     `bson:"name" json:"name"`
+	Datacenter  string     `bson:"datacenter" json:"datacenter"`
+	Folder      string     `bson:"folder" json:"folder"`
 	Cluster     string     `bson:"cluster" json:"cluster"`
 	PowerState  string     `bson:"power_state" json:"power_state"`
 	BootTime    *time.Time `bson:"boot_time" json:"boot_time"`
@@ -34,6 +45,12 @@ type VSphereHost struct {
 
 type VSphereVM struct {
 	Id            string     `bson:"_id,omitempty" json:"id"`
+	Ref           string     `bson:"ref" json:"ref"`
+	Datacenter    string     `bson:"datacenter" json:"datacenter"`
+	Folder        string     `bson:"folder" json:"folder"`
+	ResourcePool  string     `bson:"resource_pool" json:"resource_pool"`
+	Networks      []string   `bson:"networks" json:"networks"`
+	Snapshots     int        `bson:"snapshots" json:"snapshots"`
 	HostId        string     `bson:"host_id" json:"host_id"`
 	HostName      string     `bson:"host_name" json:"host_name"`
 	Cluster       string     `bson:"cluster" json:"cluster"`
@@ -49,3 +66,73 @@ type VSphereVM struct {
 	Collected     time.Time  `bson:"collected" json:"collected"`
 	Environment   string     `bson:"environment" json:"environment"`
 }
+
+// VSphereResourcePool holds the pool allocation, CPU values are in MHz and memory values in MB,
+// a limit of -1 means unlimited, the usage is in MHz and bytes
+type VSphereResourcePool struct {
+	Id                string    `bson:"_id,omitempty" json:"id"`
+	Ref               string    `bson:"ref" json:"ref"`
+	Name              string    `bson:"name" json:"name"`
+	Path              string    `bson:"path" json:"path"`
+	Datacenter        string    `bson:"datacenter" json:"datacenter"`
+	Cluster           string    `bson:"cluster" json:"cluster"`
+	CPUReservation    int64     `bson:"cpu_reservation" json:"cpu_reservation"`
+	CPULimit          int64     `bson:"cpu_limit" json:"cpu_limit"`
+	CPUUsage          int64     `bson:"cpu_usage" json:"cpu_usage"`
+	MemoryReservation int64     `bson:"memory_reservation" json:"memory_reservation"`
+	MemoryLimit       int64     `bson:"memory_limit" json:"memory_limit"`
+	MemoryUsage       int64     `bson:"memory_usage" json:"memory_usage"`
+	VMs               int       `bson:"vms" json:"vms"`
+	Collected         time.Time `bson:"collected" json:"collected"`
+	Environment       string    `bson:"environment" json:"environment"`
+}
+
+// VSphereNetwork is a standard network, a distributed port group or an opaque network
+type VSphereNetwork struct {
+	Id          string    `bson:"_id,omitempty" json:"id"`
+	Ref         string    `bson:"ref" json:"ref"`
+	Name        string    `bson:"name" json:"name"`
+	Type        string    `bson:"type" json:"type"`
+	Datacenter  string    `bson:"datacenter" json:"datacenter"`
+	Folder      string    `bson:"folder" json:"folder"`
+	Accessible  bool      `bson:"accessible" json:"accessible"`
+	Hosts       int       `bson:"hosts" json:"hosts"`
+	VMs         int       `bson:"vms" json:"vms"`
+	Collected   time.Time `bson:"collected" json:"collected"`
+	Environment string    `bson:"environment" json:"environment"`
+}
+
+// VSphereSnapshot age is in seconds and is computed at collection time
+type VSphereSnapshot struct {
+	Id          string    `bson:"_id,omitempty" json:"id"`
+	Ref         string    `bson:"ref" json:"ref"`
+	Name        string    `bson:"name" json:"name"`
+	Description string    `bson:"description" json:"description"`
+	VMId        string    `bson:"vm_id" json:"vm_id"`
+	VMName      string    `bson:"vm_name" json:"vm_name"`
+	Datacenter  string    `bson:"datacenter" json:"datacenter"`
+	PowerState  string    `bson:"power_state" json:"power_state"`
+	Quiesced    bool      `bson:"quiesced" json:"quiesced"`
+	Created     time.Time `bson:"created" json:"created"`
+	Age         int64     `bson:"age" json:"age"`
+	Collected   time.Time `bson:"collected" json:"collected"`
+	Environment string    `bson:"environment" json:"environment"`
+}
+
+// VSphereMetric is a performance sample of a VM or host, CPU values are percentages,
+// memory values are in bytes, disk latency in milliseconds and network throughput in bytes per second
+type VSphereMetric struct {
+	Id            string    `bson:"_id,omitempty" json:"id"`
+	EntityId      string    `bson:"entity_id" json:"entity_id"`
+	EntityType    string    `bson:"entity_type" json:"entity_type"`
+	Name          string    `bson:"name" json:"name"`
+	Interval      int32     `bson:"interval" json:"interval"`
+	CPUUsage      float64   `bson:"cpu_usage" json:"cpu_usage"`
+	CPUReady      float64   `bson:"cpu_ready" json:"cpu_ready"`
+	MemActive     int64     `bson:"mem_active" json:"mem_active"`
+	MemBallooned  int64     `bson:"mem_ballooned" json:"mem_ballooned"`
+	DiskLatency   int64     `bson:"disk_latency" json:"disk_latency"`
+	NetThroughput int64     `bson:"net_throughput" json:"net_throughput"`
+	Timestamp     time.Time `bson:"timestamp" json:"timestamp"`
+	Environment   string    `bson:"environment" json:"environment"`
+}